@@ -0,0 +1,250 @@
+package audit
+
+import (
+	"fmt"
+	"reflect"
+	"rented-backend/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const beforeKey = "audit:before"
+
+// trackedTables maps audited table names to the entity name stored on the
+// audit event. Tables not listed here are never audited.
+var trackedTables = map[string]string{
+	"houses":              "house",
+	"flats":               "flat",
+	"tenants":             "tenant",
+	"rent_payments":       "rent_payment",
+	"payment_items":       "payment_item",
+	"charges":             "charge",
+	"meter_readings":      "meter_reading",
+	"shared_bills":        "shared_bill",
+	"charge_types":        "charge_type",
+	"flat_charges":        "flat_charge",
+	"rent_policies":       "rent_policy",
+	"expenses":            "expense",
+	"maintenance_tickets": "maintenance_ticket",
+	"vendors":             "vendor",
+	"payment_proofs":      "payment_proof",
+	"reminder_settings":   "reminder_settings",
+	"message_templates":   "message_template",
+}
+
+// ignoredColumns are left out of update diffs.
+var ignoredColumns = map[string]bool{
+	"updated_at": true,
+}
+
+// statusColumns are the columns an activation or deactivation touches.
+var statusColumns = map[string]bool{
+	"is_active":  true,
+	"leave_date": true,
+}
+
+// RegisterCallbacks hooks audit recording into every create, update and
+// delete issued through db. A statement on a model with its primary key
+// set, or on a slice of them, is audited for those rows; a bulk statement
+// such as Where(...).Delete(&T{}) is audited for every row its WHERE clause
+// matched. Each row gets its own event.
+func RegisterCallbacks(db *gorm.DB) error {
+	cb := db.Callback()
+
+	if err := cb.Update().Before("gorm:update").Register("audit:before_update", captureBefore); err != nil {
+		return err
+	}
+	if err := cb.Delete().Before("gorm:delete").Register("audit:before_delete", captureBefore); err != nil {
+		return err
+	}
+	if err := cb.Create().After("gorm:create").Register("audit:after_create", record("create")); err != nil {
+		return err
+	}
+	if err := cb.Update().After("gorm:update").Register("audit:after_update", record("update")); err != nil {
+		return err
+	}
+	return cb.Delete().After("gorm:delete").Register("audit:after_delete", record("delete"))
+}
+
+func captureBefore(tx *gorm.DB) {
+	if tx.Error != nil {
+		return
+	}
+	if _, ok := trackedTables[tx.Statement.Table]; !ok {
+		return
+	}
+	query, ok := targets(tx)
+	if !ok {
+		return
+	}
+	tx.InstanceSet(beforeKey, loadRows(query))
+}
+
+func record(action string) func(*gorm.DB) {
+	return func(tx *gorm.DB) {
+		if tx.Error != nil || tx.Statement.RowsAffected == 0 {
+			return
+		}
+		entity, ok := trackedTables[tx.Statement.Table]
+		if !ok || !hasPrimaryKey(tx) {
+			return
+		}
+		column := tx.Statement.Schema.PrioritizedPrimaryField.DBName
+
+		// The rows touched: those loaded before an update or delete, or
+		// the ones just created
+		before := map[string]map[string]any{}
+		var ids []any
+		if v, ok := tx.InstanceGet(beforeKey); ok {
+			rows, _ := v.([]map[string]any)
+			for _, row := range rows {
+				before[fmt.Sprintf("%v", row[column])] = row
+				ids = append(ids, row[column])
+			}
+		}
+		if action == "create" {
+			ids = primaryKeys(tx)
+		}
+		if len(ids) == 0 {
+			return
+		}
+
+		after := map[string]map[string]any{}
+		if action != "delete" {
+			for _, row := range loadRows(newQuery(tx).Where(column+" IN ?", ids)) {
+				after[fmt.Sprintf("%v", row[column])] = row
+			}
+		}
+
+		actor := ActorFromContext(tx.Statement.Context)
+		entries := make([]models.AuditLog, 0, len(ids))
+		for _, id := range ids {
+			key := fmt.Sprintf("%v", id)
+			rowAction, rowBefore, rowAfter := action, before[key], after[key]
+			if action == "update" {
+				rowBefore, rowAfter = diff(rowBefore, rowAfter)
+				if len(rowBefore) == 0 && len(rowAfter) == 0 {
+					continue
+				}
+				if _, changed := rowAfter["is_active"]; changed && onlyStatusColumns(rowAfter) {
+					rowAction = "status_change"
+				}
+			}
+			entries = append(entries, models.AuditLog{
+				ID:        uuid.New(),
+				AccountID: actor.AccountID,
+				ActorID:   actor.ID,
+				ActorType: actor.Type,
+				IP:        actor.IP,
+				Entity:    entity,
+				EntityID:  key,
+				Action:    rowAction,
+				Before:    rowBefore,
+				After:     rowAfter,
+				CreatedAt: time.Now().UTC(),
+			})
+		}
+		if len(entries) == 0 {
+			return
+		}
+
+		// Failing to audit fails the mutation: the surrounding default
+		// transaction is rolled back.
+		if err := tx.Session(&gorm.Session{NewDB: true}).Create(&entries).Error; err != nil {
+			tx.AddError(err)
+		}
+	}
+}
+
+func hasPrimaryKey(tx *gorm.DB) bool {
+	return tx.Statement.Schema != nil && tx.Statement.Schema.PrioritizedPrimaryField != nil
+}
+
+// primaryKeys returns the primary keys set on the model a statement was
+// issued on, a struct or a slice of them.
+func primaryKeys(tx *gorm.DB) []any {
+	stmt := tx.Statement
+	if !hasPrimaryKey(tx) {
+		return nil
+	}
+	field := stmt.Schema.PrioritizedPrimaryField
+
+	var ids []any
+	add := func(rv reflect.Value) {
+		rv = reflect.Indirect(rv)
+		if rv.Kind() != reflect.Struct {
+			return
+		}
+		if value, isZero := field.ValueOf(stmt.Context, rv); !isZero {
+			ids = append(ids, value)
+		}
+	}
+	rv := reflect.Indirect(stmt.ReflectValue)
+	switch rv.Kind() {
+	case reflect.Struct:
+		add(rv)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			add(rv.Index(i))
+		}
+	}
+	return ids
+}
+
+// targets returns a query for the rows an update or delete will touch: the
+// model's own rows when their keys are set, or else whatever the
+// statement's WHERE clause matches.
+func targets(tx *gorm.DB) (*gorm.DB, bool) {
+	if !hasPrimaryKey(tx) {
+		return nil, false
+	}
+	if ids := primaryKeys(tx); len(ids) > 0 {
+		column := tx.Statement.Schema.PrioritizedPrimaryField.DBName
+		return newQuery(tx).Where(column+" IN ?", ids), true
+	}
+	if where, ok := tx.Statement.Clauses["WHERE"]; ok && where.Expression != nil {
+		return newQuery(tx).Clauses(where.Expression), true
+	}
+	return nil, false
+}
+
+func newQuery(tx *gorm.DB) *gorm.DB {
+	return tx.Session(&gorm.Session{NewDB: true}).Table(tx.Statement.Table)
+}
+
+func loadRows(query *gorm.DB) []map[string]any {
+	var rows []map[string]any
+	if err := query.Find(&rows).Error; err != nil {
+		return nil
+	}
+	return rows
+}
+
+func onlyStatusColumns(changed map[string]any) bool {
+	for column := range changed {
+		if !statusColumns[column] {
+			return false
+		}
+	}
+	return true
+}
+
+// diff reduces two full rows to the columns whose values differ.
+func diff(before, after map[string]any) (map[string]any, map[string]any) {
+	changedBefore := map[string]any{}
+	changedAfter := map[string]any{}
+	for column, newValue := range after {
+		if ignoredColumns[column] {
+			continue
+		}
+		oldValue, existed := before[column]
+		if existed && reflect.DeepEqual(oldValue, newValue) {
+			continue
+		}
+		changedBefore[column] = oldValue
+		changedAfter[column] = newValue
+	}
+	return changedBefore, changedAfter
+}
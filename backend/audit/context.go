@@ -0,0 +1,43 @@
+package audit
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ActorUser      = "user"
+	ActorCaretaker = "caretaker"
+	ActorTenant    = "tenant"
+	ActorSystem    = "system"
+)
+
+// Actor identifies who performed a mutation. AccountID is the landlord
+// account the change belongs to; for a landlord acting on their own
+// properties it is the same as ID, for a caretaker it is their owner.
+type Actor struct {
+	AccountID uuid.UUID
+	ID        uuid.UUID
+	Type      string
+	IP        string
+}
+
+type actorKey struct{}
+
+// WithActor returns a copy of ctx carrying the given actor. Any GORM
+// statement executed with this context is attributed to that actor.
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the actor stored in ctx, falling back to a
+// system actor for background jobs and migrations.
+func ActorFromContext(ctx context.Context) Actor {
+	if ctx != nil {
+		if actor, ok := ctx.Value(actorKey{}).(Actor); ok {
+			return actor
+		}
+	}
+	return Actor{Type: ActorSystem}
+}
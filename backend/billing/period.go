@@ -0,0 +1,213 @@
+// Package billing holds the billing period every charge, payment and
+// reading belongs to, and the calendar dates periods are worked out from.
+package billing
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Period is a calendar month that is billed as a unit. In JSON and query
+// strings it is written "2026-01"; in the database it is a date column
+// holding the month's first day. The zero Period means no period and is
+// stored as NULL and written as null.
+type Period struct {
+	Year  int
+	Month time.Month
+}
+
+var (
+	ErrInvalidPeriod = errors.New("period must be a month like 2026-01")
+	ErrInvalidDate   = errors.New("date must be like 2026-01-31")
+)
+
+// New returns the period for year and month.
+func New(year int, month time.Month) Period {
+	return Period{Year: year, Month: month}
+}
+
+// Of returns the period t falls in.
+func Of(t time.Time) Period {
+	return Period{Year: t.Year(), Month: t.Month()}
+}
+
+// Parse reads a period written as YYYY-MM.
+func Parse(s string) (Period, error) {
+	if len(s) != 7 || s[4] != '-' {
+		return Period{}, ErrInvalidPeriod
+	}
+	year, err := strconv.Atoi(s[:4])
+	if err != nil || year < 1900 {
+		return Period{}, ErrInvalidPeriod
+	}
+	month, err := strconv.Atoi(s[5:])
+	if err != nil || month < 1 || month > 12 {
+		return Period{}, ErrInvalidPeriod
+	}
+	return Period{Year: year, Month: time.Month(month)}, nil
+}
+
+// Today returns the calendar date now falls on in loc. Like every stored
+// date it is midnight UTC, so it compares directly with due dates and
+// period starts.
+func Today(now time.Time, loc *time.Location) time.Time {
+	year, month, day := now.In(loc).Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// DateOf returns the calendar date a client-sent timestamp stands for.
+// Midnight UTC already is one, as every stored date is; any other instant
+// is the date it falls on in loc.
+func DateOf(t time.Time, loc *time.Location) time.Time {
+	if u := t.UTC(); u.Equal(time.Date(u.Year(), u.Month(), u.Day(), 0, 0, 0, 0, time.UTC)) {
+		return u
+	}
+	return Today(t, loc)
+}
+
+// ParseDate reads a calendar date written as YYYY-MM-DD. A timestamp is
+// accepted too and read as DateOf does; one without an offset is a wall
+// clock time in loc.
+func ParseDate(s string, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return DateOf(t, loc), nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02T15:04:05.999999999", s, loc); err == nil {
+		return Today(t, loc), nil
+	}
+	return time.Time{}, ErrInvalidDate
+}
+
+// StartOf returns the instant the calendar date begins in loc, for
+// comparing dates with recorded timestamps. It is the inverse of Today.
+func StartOf(date time.Time, loc *time.Location) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, loc)
+}
+
+func (p Period) IsZero() bool {
+	return p == Period{}
+}
+
+// Start is midnight UTC on the first day of the period.
+func (p Period) Start() time.Time {
+	return time.Date(p.Year, p.Month, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// End is the start of the next period.
+func (p Period) End() time.Time {
+	return p.Start().AddDate(0, 1, 0)
+}
+
+// Days is the number of days in the period.
+func (p Period) Days() int {
+	return time.Date(p.Year, p.Month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// AddMonths returns the period n months later, or earlier if n < 0.
+func (p Period) AddMonths(n int) Period {
+	return Of(p.Start().AddDate(0, n, 0))
+}
+
+func (p Period) Before(q Period) bool {
+	return p.Year < q.Year || (p.Year == q.Year && p.Month < q.Month)
+}
+
+func (p Period) After(q Period) bool {
+	return q.Before(p)
+}
+
+// String formats the period as YYYY-MM, or "" for no period.
+func (p Period) String() string {
+	if p.IsZero() {
+		return ""
+	}
+	return fmt.Sprintf("%04d-%02d", p.Year, int(p.Month))
+}
+
+// Label is the period for people to read, e.g. "January 2026".
+func (p Period) Label() string {
+	return fmt.Sprintf("%s %d", p.Month, p.Year)
+}
+
+func (p Period) MarshalJSON() ([]byte, error) {
+	if p.IsZero() {
+		return []byte("null"), nil
+	}
+	return []byte(`"` + p.String() + `"`), nil
+}
+
+func (p *Period) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		*p = Period{}
+		return nil
+	}
+	s, err := strconv.Unquote(string(data))
+	if err != nil {
+		return ErrInvalidPeriod
+	}
+	parsed, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	*p = parsed
+	return nil
+}
+
+// UnmarshalParam reads periods sent as form fields or query parameters.
+func (p *Period) UnmarshalParam(param string) error {
+	parsed, err := Parse(param)
+	if err != nil {
+		return err
+	}
+	*p = parsed
+	return nil
+}
+
+func (p Period) Value() (driver.Value, error) {
+	if p.IsZero() {
+		return nil, nil
+	}
+	return p.Start(), nil
+}
+
+func (p *Period) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*p = Period{}
+	case time.Time:
+		*p = Of(v)
+	case string:
+		return p.scanText(v)
+	case []byte:
+		return p.scanText(string(v))
+	default:
+		return fmt.Errorf("cannot scan %T into a billing period", src)
+	}
+	return nil
+}
+
+// scanText reads a date as drivers without a date type return it, e.g.
+// "2026-01-01" or "2026-01-01 00:00:00+00:00".
+func (p *Period) scanText(s string) error {
+	if len(s) < 7 {
+		return ErrInvalidPeriod
+	}
+	parsed, err := Parse(s[:7])
+	if err != nil {
+		return err
+	}
+	*p = parsed
+	return nil
+}
+
+// GormDataType stores periods in a date column.
+func (Period) GormDataType() string {
+	return "date"
+}
@@ -0,0 +1,41 @@
+package billing
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDate(t *testing.T) {
+	dhaka, err := time.LoadLocation("Asia/Dhaka")
+	if err != nil {
+		t.Skip("no time zone data")
+	}
+	jan1 := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		in      string
+		want    time.Time
+		wantErr bool
+	}{
+		{in: "2026-01-01", want: jan1},
+		{in: "2026-01-01T00:00:00Z", want: jan1},
+		{in: "2026-01-01T00:00:00+06:00", want: jan1}, // Dhaka midnight, 18:00 UTC the day before
+		{in: "2025-12-31T18:00:00.000Z", want: jan1},  // the same instant in UTC
+		{in: "2026-01-01T00:00:00.000", want: jan1},   // local wall clock, no offset
+		{in: "2026-01-01T23:30:00-05:00", want: time.Date(2026, time.January, 2, 0, 0, 0, 0, time.UTC)},
+		{in: "01/01/2026", wantErr: true},
+		{in: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseDate(tt.in, dhaka)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseDate(%q) = %v, want an error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil || !got.Equal(tt.want) || got.Location() != time.UTC {
+			t.Errorf("ParseDate(%q) = %v, %v; want %v", tt.in, got, err, tt.want)
+		}
+	}
+}
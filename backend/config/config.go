@@ -16,6 +16,29 @@ type Config struct {
 	DBPort     string
 	JWTSecret  string
 	Env        string
+
+	TenantPortalURL string // magic links point here
+
+	SMSDriver        string // http or log
+	SMSGatewayURL    string
+	SMSAPIKey        string
+	SMSSenderID      string
+	SMSLogFile       string
+	SMSWebhookSecret string // delivery reports must carry it
+
+	PushDriver         string // fcm or stub
+	FCMProjectID       string
+	FCMCredentialsFile string // service account key; empty uses the default credentials
+
+	MailDriver          string // smtp or log
+	SMTPHost            string
+	SMTPPort            string
+	SMTPUsername        string
+	SMTPPassword        string
+	MailFrom            string
+	MailLogDir          string
+	EmailUnsubscribeURL string // public unsubscribe endpoint, reachable from emails
+	EmailPreferencesURL string // the app's email settings page
 }
 
 func LoadConfig() (*Config, error) {
@@ -31,6 +54,29 @@ func LoadConfig() (*Config, error) {
 		DBPort:     getEnv("DB_PORT", "5432"),
 		JWTSecret:  getEnv("JWT_SECRET", "default_secret_change_me_in_prod"),
 		Env:        getEnv("ENV", "development"),
+
+		TenantPortalURL: getEnv("TENANT_PORTAL_URL", "http://localhost:3000/portal/login"),
+
+		SMSDriver:        getEnv("SMS_DRIVER", "log"),
+		SMSGatewayURL:    getEnv("SMS_GATEWAY_URL", ""),
+		SMSAPIKey:        getEnv("SMS_API_KEY", ""),
+		SMSSenderID:      getEnv("SMS_SENDER_ID", ""),
+		SMSLogFile:       getEnv("SMS_LOG_FILE", ""),
+		SMSWebhookSecret: getEnv("SMS_WEBHOOK_SECRET", ""),
+
+		PushDriver:         getEnv("PUSH_DRIVER", "stub"),
+		FCMProjectID:       getEnv("FCM_PROJECT_ID", ""),
+		FCMCredentialsFile: getEnv("FCM_CREDENTIALS_FILE", ""),
+
+		MailDriver:          getEnv("MAIL_DRIVER", "log"),
+		SMTPHost:            getEnv("SMTP_HOST", ""),
+		SMTPPort:            getEnv("SMTP_PORT", "587"),
+		SMTPUsername:        getEnv("SMTP_USERNAME", ""),
+		SMTPPassword:        getEnv("SMTP_PASSWORD", ""),
+		MailFrom:            getEnv("MAIL_FROM", ""),
+		MailLogDir:          getEnv("MAIL_LOG_DIR", ""),
+		EmailUnsubscribeURL: getEnv("EMAIL_UNSUBSCRIBE_URL", "http://localhost:8080/api/email/unsubscribe"),
+		EmailPreferencesURL: getEnv("EMAIL_PREFERENCES_URL", "http://localhost:3000/settings/email"),
 	}
 
 	if config.DBHost == "" || config.DBUser == "" {
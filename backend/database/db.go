@@ -3,8 +3,9 @@ package database
 import (
 	"fmt"
 	"log"
+	"rented-backend/audit"
 	"rented-backend/config"
-	"rented-backend/models"
+	"time"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -12,8 +13,11 @@ import (
 
 var DB *gorm.DB
 
-func InitDB(cfg *config.Config) {
-	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable TimeZone=Asia/Dhaka",
+// Connect opens the database and registers the audit callbacks. It does
+// not touch the schema; see MigrateUp.
+func Connect(cfg *config.Config) (*gorm.DB, error) {
+	// Sessions run in UTC; each account's own time zone is applied in Go
+	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable TimeZone=UTC",
 		cfg.DBHost,
 		cfg.DBUser,
 		cfg.DBPassword,
@@ -21,26 +25,43 @@ func InitDB(cfg *config.Config) {
 		cfg.DBPort,
 	)
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	// Constraint violations come back as gorm.ErrDuplicatedKey and
+	// gorm.ErrForeignKeyViolated; timestamps are set in UTC
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		TranslateError: true,
+		NowFunc:        func() time.Time { return time.Now().UTC() },
+	})
 	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
 	sqlDB, err := db.DB()
 	if err != nil {
-		log.Fatalf("Failed to get underlying sql.DB: %v", err)
+		return nil, fmt.Errorf("failed to get underlying sql.DB: %w", err)
 	}
 
 	// Set connection pool settings
 	sqlDB.SetMaxIdleConns(10)
 	sqlDB.SetMaxOpenConns(100)
 
-	// Auto Migration
-	err = db.AutoMigrate(&models.User{}, &models.House{}, &models.Flat{}, &models.Tenant{}, &models.RentPayment{})
+	// Record every mutation of tracked entities
+	if err := audit.RegisterCallbacks(db); err != nil {
+		return nil, fmt.Errorf("failed to register audit callbacks: %w", err)
+	}
+	return db, nil
+}
+
+// InitDB connects and refuses to continue unless every migration has been
+// applied.
+func InitDB(cfg *config.Config) {
+	db, err := Connect(cfg)
 	if err != nil {
-		log.Fatalf("Failed to migrate database: %v", err)
+		log.Fatal(err)
+	}
+	if err := CheckSchema(db); err != nil {
+		log.Fatalf("Refusing to start: %v", err)
 	}
 
 	DB = db
-	fmt.Println("Database connected and migrated successfully")
+	fmt.Println("Database connected successfully")
 }
@@ -0,0 +1,159 @@
+package database
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// testDB opens a fresh schema in the Postgres database named by
+// MIGRATION_TEST_DSN, or skips the test when it is not set.
+func testDB(t *testing.T) *gorm.DB {
+	dsn := os.Getenv("MIGRATION_TEST_DSN")
+	if dsn == "" {
+		t.Skip("MIGRATION_TEST_DSN not set")
+	}
+	admin, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema := fmt.Sprintf("migrate_test_%d", time.Now().UnixNano())
+	if err := admin.Exec("CREATE SCHEMA " + schema).Error; err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { admin.Exec("DROP SCHEMA " + schema + " CASCADE") })
+
+	db, err := gorm.Open(postgres.Open(dsn+" search_path="+schema), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// TestUpgradeFromBaseline migrates a database the first release set up
+// and checks nothing it held is lost, then rolls the charge catalogue
+// back and forward again.
+func TestUpgradeFromBaseline(t *testing.T) {
+	db := testDB(t)
+	migrations, err := Migrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The first release ran AutoMigrate and kept no schema_migrations
+	baseline := []string{
+		migrations[0].Up,
+		`INSERT INTO users (id, email) VALUES ('00000000-0000-0000-0000-000000000001', 'owner@example.com')`,
+		`INSERT INTO houses (id, user_id, name) VALUES ('00000000-0000-0000-0000-000000000002', '00000000-0000-0000-0000-000000000001', 'Green View')`,
+		`INSERT INTO flats (id, house_id, number, basic_rent, gas_bill, utility_bill, water_charges)
+		VALUES ('00000000-0000-0000-0000-000000000003', '00000000-0000-0000-0000-000000000002', 'A1', 15000.50, 1000, 0, 300)`,
+		`INSERT INTO tenants (id, user_id, house_id, flat_id, name, phone, is_active, join_date, advance_amount)
+		VALUES ('00000000-0000-0000-0000-000000000004', '00000000-0000-0000-0000-000000000001', '00000000-0000-0000-0000-000000000002',
+		'00000000-0000-0000-0000-000000000003', 'Rahim', '01700000000', true, '2026-01-01', 30000)`,
+		`INSERT INTO rent_payments (id, tenant_id, month, year, basic_rent, gas_bill, electricity_bill, utility_bill, water_charges, total_paid, is_advance, payment_date)
+		VALUES ('00000000-0000-0000-0000-000000000005', '00000000-0000-0000-0000-000000000004', 'January', 2026, 15000.50, 1000, 812.25, 0, 300, 17112.75, false, '2026-01-05')`,
+		`INSERT INTO rent_payments (id, tenant_id, month, year, basic_rent, gas_bill, electricity_bill, utility_bill, water_charges, total_paid, is_advance, payment_date)
+		VALUES ('00000000-0000-0000-0000-000000000006', '00000000-0000-0000-0000-000000000004', 'Advance', 2026, 0, 0, 0, 0, 0, 30000, true, '2026-01-01')`,
+	}
+	for _, stmt := range baseline {
+		if err := db.Exec(stmt).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := MigrateUp(db); err != nil {
+		t.Fatal(err)
+	}
+	checkUpgraded(t, db)
+
+	// Roll back to before the charge catalogue and forward again
+	if _, err := MigrateDown(db, len(migrations)-2); err != nil {
+		t.Fatal(err)
+	}
+	var basicRent float64
+	if err := db.Raw("SELECT basic_rent FROM flats").Scan(&basicRent).Error; err != nil {
+		t.Fatal(err)
+	}
+	if basicRent != 15000.50 {
+		t.Errorf("basic_rent after rollback = %v, want 15000.50", basicRent)
+	}
+	if _, err := MigrateUp(db); err != nil {
+		t.Fatal(err)
+	}
+	checkUpgraded(t, db)
+}
+
+func checkUpgraded(t *testing.T, db *gorm.DB) {
+	t.Helper()
+
+	if db.Migrator().HasColumn("flats", "basic_rent") || db.Migrator().HasColumn("rent_payments", "gas_bill") {
+		t.Error("fixed charge columns were not dropped")
+	}
+
+	var flatCharges []struct {
+		Code   string
+		Amount int64
+	}
+	err := db.Raw(`SELECT ct.code, fc.amount FROM flat_charges fc JOIN charge_types ct ON ct.id = fc.charge_type_id ORDER BY ct.code`).
+		Scan(&flatCharges).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := fmt.Sprint([]struct {
+		Code   string
+		Amount int64
+	}{{"basic_rent", 1500050}, {"gas", 100000}, {"water", 30000}})
+	if got := fmt.Sprint(flatCharges); got != want {
+		t.Errorf("flat charges = %s, want %s", got, want)
+	}
+
+	var items []struct {
+		Code   string
+		Amount int64
+	}
+	err = db.Raw(`SELECT code, amount FROM payment_items WHERE rent_payment_id = '00000000-0000-0000-0000-000000000005' ORDER BY code`).
+		Scan(&items).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = fmt.Sprint([]struct {
+		Code   string
+		Amount int64
+	}{{"basic_rent", 1500050}, {"electricity", 81225}, {"gas", 100000}, {"water", 30000}})
+	if got := fmt.Sprint(items); got != want {
+		t.Errorf("payment items = %s, want %s", got, want)
+	}
+
+	var payments []struct {
+		IsAdvance bool
+		Period    *time.Time
+		TotalPaid int64
+	}
+	if err := db.Raw(`SELECT is_advance, period, total_paid FROM rent_payments ORDER BY is_advance`).Scan(&payments).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(payments) != 2 || payments[0].Period == nil || payments[0].Period.Format("2006-01-02") != "2026-01-01" ||
+		payments[0].TotalPaid != 1711275 || !payments[1].IsAdvance || payments[1].Period != nil {
+		t.Errorf("payments = %+v", payments)
+	}
+
+	var members int
+	if err := db.Raw(`SELECT members FROM tenants`).Scan(&members).Error; err != nil {
+		t.Fatal(err)
+	}
+	if members != 1 {
+		t.Errorf("members = %d, want 1", members)
+	}
+
+	var chargeTypes int64
+	if err := db.Raw(`SELECT count(*) FROM charge_types`).Scan(&chargeTypes).Error; err != nil {
+		t.Fatal(err)
+	}
+	if chargeTypes != 7 {
+		t.Errorf("charge types = %d, want the 7 defaults", chargeTypes)
+	}
+}
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS rent_payments;
+DROP TABLE IF EXISTS tenants;
+DROP TABLE IF EXISTS flats;
+DROP TABLE IF EXISTS houses;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,77 @@
+-- The schema of the first release, as its AutoMigrate created it. Tables
+-- and indexes are only created when missing, so databases set up by that
+-- release adopt it as is; later migrations bring them up to date.
+
+CREATE TABLE IF NOT EXISTS users (
+    id uuid,
+    email text NOT NULL,
+    password text,
+    name text,
+    google_id text,
+    created_at timestamptz,
+    updated_at timestamptz,
+    PRIMARY KEY (id),
+    CONSTRAINT uni_users_email UNIQUE (email)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_google_id ON users (google_id);
+
+CREATE TABLE IF NOT EXISTS houses (
+    id uuid,
+    user_id uuid NOT NULL,
+    name text,
+    created_at timestamptz,
+    updated_at timestamptz,
+    PRIMARY KEY (id)
+);
+
+CREATE TABLE IF NOT EXISTS flats (
+    id uuid,
+    house_id uuid NOT NULL,
+    number text,
+    basic_rent decimal,
+    gas_bill decimal,
+    utility_bill decimal,
+    water_charges decimal,
+    created_at timestamptz,
+    updated_at timestamptz,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_houses_flats FOREIGN KEY (house_id) REFERENCES houses (id)
+);
+
+CREATE TABLE IF NOT EXISTS tenants (
+    id uuid,
+    user_id uuid,
+    house_id uuid,
+    flat_id uuid,
+    name text,
+    phone text,
+    n_id_number text,
+    n_id_front_url text,
+    n_id_back_url text,
+    is_active boolean DEFAULT true,
+    join_date timestamptz,
+    advance_amount decimal,
+    created_at timestamptz,
+    updated_at timestamptz,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_tenants_flat FOREIGN KEY (flat_id) REFERENCES flats (id)
+);
+
+CREATE TABLE IF NOT EXISTS rent_payments (
+    id uuid,
+    tenant_id uuid,
+    month text,
+    year bigint,
+    basic_rent decimal,
+    gas_bill decimal,
+    electricity_bill decimal,
+    utility_bill decimal,
+    water_charges decimal,
+    total_paid decimal,
+    is_advance boolean DEFAULT false,
+    payment_date timestamptz,
+    created_at timestamptz,
+    updated_at timestamptz,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_rent_payments_tenant_id ON rent_payments (tenant_id);
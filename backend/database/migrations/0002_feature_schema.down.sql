@@ -0,0 +1,33 @@
+DROP TABLE IF EXISTS import_batches;
+DROP TABLE IF EXISTS report_emails;
+DROP TABLE IF EXISTS email_preferences;
+DROP TABLE IF EXISTS notifications;
+DROP TABLE IF EXISTS notification_preferences;
+DROP TABLE IF EXISTS device_tokens;
+DROP TABLE IF EXISTS sms_messages;
+DROP TABLE IF EXISTS message_templates;
+DROP TABLE IF EXISTS reminder_settings;
+DROP TABLE IF EXISTS payment_proofs;
+DROP TABLE IF EXISTS tenant_login_codes;
+DROP TABLE IF EXISTS vendors;
+DROP TABLE IF EXISTS ticket_comments;
+DROP TABLE IF EXISTS ticket_photos;
+DROP TABLE IF EXISTS maintenance_tickets;
+DROP TABLE IF EXISTS expenses;
+DROP TABLE IF EXISTS rent_policies;
+DROP TABLE IF EXISTS payment_items;
+DROP TABLE IF EXISTS flat_charges;
+DROP TABLE IF EXISTS charge_types;
+DROP TABLE IF EXISTS shared_bill_shares;
+DROP TABLE IF EXISTS shared_bills;
+DROP TABLE IF EXISTS electricity_tariffs;
+DROP TABLE IF EXISTS meter_readings;
+DROP TABLE IF EXISTS meters;
+DROP TABLE IF EXISTS charges;
+DROP TABLE IF EXISTS audit_logs;
+
+ALTER TABLE rent_payments DROP COLUMN import_batch_id, DROP COLUMN transaction_id, DROP COLUMN method;
+ALTER TABLE tenants DROP COLUMN import_batch_id, DROP COLUMN language, DROP COLUMN sms_opt_out,
+    DROP COLUMN lease_end_date, DROP COLUMN leave_date, DROP COLUMN members;
+ALTER TABLE flats DROP COLUMN import_batch_id, DROP COLUMN size;
+ALTER TABLE houses DROP COLUMN import_batch_id;
@@ -0,0 +1,455 @@
+-- Catches a baseline database up with the features added since: new
+-- columns on the original tables, then every table they brought. Columns
+-- and tables that already exist are left alone.
+
+ALTER TABLE houses ADD COLUMN IF NOT EXISTS import_batch_id uuid;
+CREATE INDEX IF NOT EXISTS idx_houses_import_batch_id ON houses (import_batch_id);
+
+ALTER TABLE flats ADD COLUMN IF NOT EXISTS size decimal;
+ALTER TABLE flats ADD COLUMN IF NOT EXISTS import_batch_id uuid;
+CREATE INDEX IF NOT EXISTS idx_flats_import_batch_id ON flats (import_batch_id);
+
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS members bigint DEFAULT 1;
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS leave_date timestamptz;
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS lease_end_date timestamptz;
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS sms_opt_out boolean;
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS language text;
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS import_batch_id uuid;
+CREATE INDEX IF NOT EXISTS idx_tenants_import_batch_id ON tenants (import_batch_id);
+
+ALTER TABLE rent_payments ADD COLUMN IF NOT EXISTS method text;
+ALTER TABLE rent_payments ADD COLUMN IF NOT EXISTS transaction_id text;
+ALTER TABLE rent_payments ADD COLUMN IF NOT EXISTS import_batch_id uuid;
+CREATE INDEX IF NOT EXISTS idx_rent_payments_import_batch_id ON rent_payments (import_batch_id);
+
+CREATE TABLE IF NOT EXISTS audit_logs (
+    id uuid,
+    account_id uuid,
+    actor_id uuid,
+    actor_type text,
+    ip text,
+    entity text,
+    entity_id text,
+    action text,
+    before jsonb,
+    after jsonb,
+    created_at timestamptz,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_created_at ON audit_logs (created_at);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_entity_id ON audit_logs (entity_id);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_entity ON audit_logs (entity);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_actor_id ON audit_logs (actor_id);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_account_id ON audit_logs (account_id);
+
+CREATE TABLE IF NOT EXISTS charges (
+    id uuid,
+    tenant_id uuid,
+    flat_id uuid,
+    month text,
+    year bigint,
+    kind text,
+    charge_type_id uuid,
+    description text,
+    amount decimal,
+    source_type text,
+    source_id uuid,
+    waived_at timestamptz,
+    waived_by uuid,
+    waive_reason text,
+    created_at timestamptz,
+    updated_at timestamptz,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_charges_source_id ON charges (source_id);
+CREATE INDEX IF NOT EXISTS idx_charges_flat_id ON charges (flat_id);
+CREATE INDEX IF NOT EXISTS idx_charges_tenant_id ON charges (tenant_id);
+
+CREATE TABLE IF NOT EXISTS meters (
+    id uuid,
+    flat_id uuid NOT NULL,
+    number text,
+    initial_reading decimal,
+    is_active boolean DEFAULT true,
+    created_at timestamptz,
+    updated_at timestamptz,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_meters_flat_id ON meters (flat_id);
+
+CREATE TABLE IF NOT EXISTS meter_readings (
+    id uuid,
+    meter_id uuid NOT NULL,
+    flat_id uuid,
+    tenant_id uuid,
+    month text,
+    year bigint,
+    previous_units decimal,
+    current_units decimal,
+    units decimal,
+    energy_charge decimal,
+    meter_rent decimal,
+    vat decimal,
+    amount decimal,
+    is_flagged boolean,
+    flag_reason text,
+    reading_date timestamptz,
+    created_at timestamptz,
+    updated_at timestamptz,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_meter_readings_flat_id ON meter_readings (flat_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_meter_period ON meter_readings (meter_id,month,year);
+
+CREATE TABLE IF NOT EXISTS electricity_tariffs (
+    id uuid,
+    user_id uuid,
+    slabs jsonb,
+    meter_rent decimal,
+    vat_rate decimal,
+    created_at timestamptz,
+    updated_at timestamptz,
+    PRIMARY KEY (id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_electricity_tariffs_user_id ON electricity_tariffs (user_id);
+
+CREATE TABLE IF NOT EXISTS shared_bills (
+    id uuid,
+    house_id uuid NOT NULL,
+    kind text,
+    charge_type_id uuid,
+    month text,
+    year bigint,
+    total_amount decimal,
+    split_method text,
+    description text,
+    created_at timestamptz,
+    updated_at timestamptz,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_shared_bills_house_id ON shared_bills (house_id);
+
+CREATE TABLE IF NOT EXISTS shared_bill_shares (
+    id uuid,
+    shared_bill_id uuid NOT NULL,
+    flat_id uuid,
+    flat_number text,
+    tenant_id uuid,
+    tenant_name text,
+    basis decimal,
+    percent decimal,
+    amount decimal,
+    charge_id uuid,
+    created_at timestamptz,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_shared_bills_shares FOREIGN KEY (shared_bill_id) REFERENCES shared_bills (id)
+);
+CREATE INDEX IF NOT EXISTS idx_shared_bill_shares_shared_bill_id ON shared_bill_shares (shared_bill_id);
+
+CREATE TABLE IF NOT EXISTS charge_types (
+    id uuid,
+    user_id uuid NOT NULL,
+    code text NOT NULL,
+    name text,
+    recurrence text,
+    calculation text,
+    taxable boolean,
+    tax_rate decimal,
+    is_active boolean DEFAULT true,
+    created_at timestamptz,
+    updated_at timestamptz,
+    PRIMARY KEY (id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_charge_type_code ON charge_types (user_id,code);
+
+CREATE TABLE IF NOT EXISTS flat_charges (
+    id uuid,
+    flat_id uuid NOT NULL,
+    charge_type_id uuid NOT NULL,
+    amount decimal,
+    created_at timestamptz,
+    updated_at timestamptz,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_flats_charges FOREIGN KEY (flat_id) REFERENCES flats (id),
+    CONSTRAINT fk_flat_charges_charge_type FOREIGN KEY (charge_type_id) REFERENCES charge_types (id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_flat_charge_type ON flat_charges (flat_id,charge_type_id);
+
+CREATE TABLE IF NOT EXISTS payment_items (
+    id uuid,
+    rent_payment_id uuid NOT NULL,
+    charge_type_id uuid,
+    code text,
+    amount decimal,
+    created_at timestamptz,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_rent_payments_items FOREIGN KEY (rent_payment_id) REFERENCES rent_payments (id)
+);
+CREATE INDEX IF NOT EXISTS idx_payment_items_rent_payment_id ON payment_items (rent_payment_id);
+
+CREATE TABLE IF NOT EXISTS rent_policies (
+    id uuid,
+    house_id uuid,
+    due_day bigint,
+    grace_days bigint,
+    late_fee_type text,
+    late_fee_amount decimal,
+    late_fee_cap decimal,
+    proration_mode text DEFAULT 'calendar_days',
+    proration_day bigint,
+    created_at timestamptz,
+    updated_at timestamptz,
+    PRIMARY KEY (id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_rent_policies_house_id ON rent_policies (house_id);
+
+CREATE TABLE IF NOT EXISTS expenses (
+    id uuid,
+    user_id uuid,
+    house_id uuid,
+    flat_id uuid,
+    category text,
+    vendor_id uuid,
+    vendor text,
+    amount decimal,
+    date timestamptz,
+    description text,
+    receipt_url text,
+    recurrence text DEFAULT 'none',
+    next_date timestamptz,
+    parent_id uuid,
+    created_at timestamptz,
+    updated_at timestamptz,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_expenses_date ON expenses (date);
+CREATE INDEX IF NOT EXISTS idx_expenses_vendor_id ON expenses (vendor_id);
+CREATE INDEX IF NOT EXISTS idx_expenses_house_id ON expenses (house_id);
+CREATE INDEX IF NOT EXISTS idx_expenses_user_id ON expenses (user_id);
+
+CREATE TABLE IF NOT EXISTS maintenance_tickets (
+    id uuid,
+    user_id uuid,
+    house_id uuid,
+    flat_id uuid,
+    tenant_id uuid,
+    title text,
+    description text,
+    priority text DEFAULT 'medium',
+    status text DEFAULT 'open',
+    vendor_id uuid,
+    assignee_name text,
+    assignee_phone text,
+    cost decimal,
+    cost_charged_to text,
+    expense_id uuid,
+    charge_id uuid,
+    resolved_at timestamptz,
+    created_at timestamptz,
+    updated_at timestamptz,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_maintenance_tickets_vendor_id ON maintenance_tickets (vendor_id);
+CREATE INDEX IF NOT EXISTS idx_maintenance_tickets_status ON maintenance_tickets (status);
+CREATE INDEX IF NOT EXISTS idx_maintenance_tickets_house_id ON maintenance_tickets (house_id);
+CREATE INDEX IF NOT EXISTS idx_maintenance_tickets_user_id ON maintenance_tickets (user_id);
+
+CREATE TABLE IF NOT EXISTS ticket_photos (
+    id uuid,
+    ticket_id uuid,
+    url text,
+    created_at timestamptz,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_maintenance_tickets_photos FOREIGN KEY (ticket_id) REFERENCES maintenance_tickets (id)
+);
+CREATE INDEX IF NOT EXISTS idx_ticket_photos_ticket_id ON ticket_photos (ticket_id);
+
+CREATE TABLE IF NOT EXISTS ticket_comments (
+    id uuid,
+    ticket_id uuid,
+    author_id uuid,
+    author_type text DEFAULT 'user',
+    body text,
+    created_at timestamptz,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_maintenance_tickets_comments FOREIGN KEY (ticket_id) REFERENCES maintenance_tickets (id)
+);
+CREATE INDEX IF NOT EXISTS idx_ticket_comments_ticket_id ON ticket_comments (ticket_id);
+
+CREATE TABLE IF NOT EXISTS vendors (
+    id uuid,
+    user_id uuid,
+    name text,
+    trade text,
+    phone text,
+    payment_details text,
+    notes text,
+    rating bigint,
+    is_active boolean DEFAULT true,
+    created_at timestamptz,
+    updated_at timestamptz,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_vendors_user_id ON vendors (user_id);
+
+CREATE TABLE IF NOT EXISTS tenant_login_codes (
+    id uuid,
+    phone text,
+    kind text,
+    secret_hash text,
+    attempts bigint,
+    expires_at timestamptz,
+    used_at timestamptz,
+    created_at timestamptz,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_tenant_login_codes_secret_hash ON tenant_login_codes (secret_hash);
+CREATE INDEX IF NOT EXISTS idx_tenant_login_codes_phone ON tenant_login_codes (phone);
+
+CREATE TABLE IF NOT EXISTS payment_proofs (
+    id uuid,
+    user_id uuid,
+    tenant_id uuid,
+    month text,
+    year bigint,
+    amount decimal,
+    method text,
+    transaction_id text,
+    screenshot_url text,
+    note text,
+    submitted_by text,
+    status text DEFAULT 'pending',
+    reviewed_by uuid,
+    reviewed_at timestamptz,
+    reject_reason text,
+    rent_payment_id uuid,
+    created_at timestamptz,
+    updated_at timestamptz,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_payment_proofs_tenant FOREIGN KEY (tenant_id) REFERENCES tenants (id)
+);
+CREATE INDEX IF NOT EXISTS idx_payment_proofs_status ON payment_proofs (status);
+CREATE INDEX IF NOT EXISTS idx_payment_proofs_tenant_id ON payment_proofs (tenant_id);
+CREATE INDEX IF NOT EXISTS idx_payment_proofs_user_id ON payment_proofs (user_id);
+
+CREATE TABLE IF NOT EXISTS reminder_settings (
+    id uuid,
+    user_id uuid,
+    enabled boolean,
+    days_before bigint,
+    on_due_date boolean,
+    overdue_days text,
+    language text,
+    created_at timestamptz,
+    updated_at timestamptz,
+    PRIMARY KEY (id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_reminder_settings_user_id ON reminder_settings (user_id);
+
+CREATE TABLE IF NOT EXISTS message_templates (
+    id uuid,
+    user_id uuid,
+    kind text,
+    language text,
+    body text,
+    created_at timestamptz,
+    updated_at timestamptz,
+    PRIMARY KEY (id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_message_template ON message_templates (user_id,kind,language);
+
+CREATE TABLE IF NOT EXISTS sms_messages (
+    id uuid,
+    user_id uuid,
+    tenant_id uuid,
+    kind text,
+    phone text,
+    body text,
+    status text,
+    provider_id text,
+    error text,
+    dedupe_key text,
+    created_at timestamptz,
+    updated_at timestamptz,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_sms_messages_dedupe_key ON sms_messages (dedupe_key);
+CREATE INDEX IF NOT EXISTS idx_sms_messages_provider_id ON sms_messages (provider_id);
+CREATE INDEX IF NOT EXISTS idx_sms_messages_status ON sms_messages (status);
+CREATE INDEX IF NOT EXISTS idx_sms_messages_tenant_id ON sms_messages (tenant_id);
+CREATE INDEX IF NOT EXISTS idx_sms_messages_user_id ON sms_messages (user_id);
+
+CREATE TABLE IF NOT EXISTS device_tokens (
+    id uuid,
+    user_id uuid,
+    token text,
+    platform text,
+    created_at timestamptz,
+    updated_at timestamptz,
+    PRIMARY KEY (id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_device_tokens_token ON device_tokens (token);
+CREATE INDEX IF NOT EXISTS idx_device_tokens_user_id ON device_tokens (user_id);
+
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    id uuid,
+    user_id uuid,
+    event text,
+    push boolean,
+    updated_at timestamptz,
+    PRIMARY KEY (id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_notification_pref ON notification_preferences (user_id,event);
+
+CREATE TABLE IF NOT EXISTS notifications (
+    id uuid,
+    user_id uuid,
+    event text,
+    title text,
+    body text,
+    data text,
+    push_status text,
+    dedupe_key text,
+    read_at timestamptz,
+    created_at timestamptz,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_notifications_dedupe_key ON notifications (dedupe_key);
+CREATE INDEX IF NOT EXISTS idx_notifications_user_id ON notifications (user_id);
+
+CREATE TABLE IF NOT EXISTS email_preferences (
+    id uuid,
+    user_id uuid,
+    monthly_summary boolean,
+    unsubscribe_token text,
+    created_at timestamptz,
+    updated_at timestamptz,
+    PRIMARY KEY (id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_email_preferences_unsubscribe_token ON email_preferences (unsubscribe_token);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_email_preferences_user_id ON email_preferences (user_id);
+
+CREATE TABLE IF NOT EXISTS report_emails (
+    id uuid,
+    user_id uuid,
+    period text,
+    "to" text,
+    status text,
+    error text,
+    created_at timestamptz,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_report_emails_user_id ON report_emails (user_id);
+
+CREATE TABLE IF NOT EXISTS import_batches (
+    id uuid,
+    user_id uuid,
+    kind text,
+    filename text,
+    rows bigint,
+    status text,
+    rolled_back_at timestamptz,
+    created_at timestamptz,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_import_batches_user_id ON import_batches (user_id);
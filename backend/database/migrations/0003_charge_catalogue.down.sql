@@ -0,0 +1,46 @@
+-- Puts the default fixed charges back into their columns. Charge types and
+-- any charges without a column of their own stay in the catalogue.
+
+ALTER TABLE flats ADD COLUMN basic_rent decimal, ADD COLUMN gas_bill decimal, ADD COLUMN utility_bill decimal,
+    ADD COLUMN water_charges decimal;
+ALTER TABLE rent_payments ADD COLUMN basic_rent decimal, ADD COLUMN gas_bill decimal, ADD COLUMN electricity_bill decimal,
+    ADD COLUMN utility_bill decimal, ADD COLUMN water_charges decimal;
+
+UPDATE flats SET
+    basic_rent = c.basic_rent,
+    gas_bill = c.gas_bill,
+    utility_bill = c.utility_bill,
+    water_charges = c.water_charges
+FROM (
+    SELECT fc.flat_id,
+        sum(fc.amount) FILTER (WHERE ct.code = 'basic_rent') AS basic_rent,
+        sum(fc.amount) FILTER (WHERE ct.code = 'gas') AS gas_bill,
+        sum(fc.amount) FILTER (WHERE ct.code = 'utility') AS utility_bill,
+        sum(fc.amount) FILTER (WHERE ct.code = 'water') AS water_charges
+    FROM flat_charges fc
+    JOIN charge_types ct ON ct.id = fc.charge_type_id
+    GROUP BY fc.flat_id
+) c
+WHERE c.flat_id = flats.id;
+
+UPDATE rent_payments SET
+    basic_rent = i.basic_rent,
+    gas_bill = i.gas_bill,
+    electricity_bill = i.electricity_bill,
+    utility_bill = i.utility_bill,
+    water_charges = i.water_charges
+FROM (
+    SELECT rent_payment_id,
+        sum(amount) FILTER (WHERE code = 'basic_rent') AS basic_rent,
+        sum(amount) FILTER (WHERE code = 'gas') AS gas_bill,
+        sum(amount) FILTER (WHERE code = 'electricity') AS electricity_bill,
+        sum(amount) FILTER (WHERE code = 'utility') AS utility_bill,
+        sum(amount) FILTER (WHERE code = 'water') AS water_charges
+    FROM payment_items
+    GROUP BY rent_payment_id
+) i
+WHERE i.rent_payment_id = rent_payments.id;
+
+DELETE FROM flat_charges USING charge_types ct
+WHERE ct.id = flat_charges.charge_type_id AND ct.code IN ('basic_rent', 'gas', 'utility', 'water');
+DELETE FROM payment_items WHERE code IN ('basic_rent', 'gas', 'electricity', 'utility', 'water');
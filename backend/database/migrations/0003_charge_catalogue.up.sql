@@ -0,0 +1,100 @@
+-- Moves the fixed charge columns of flats and rent_payments into the
+-- charge catalogue. Every landlord gets the default charge types, flat
+-- amounts become flat_charges and payment amounts become payment_items,
+-- then the old columns are dropped. Databases whose columns are already
+-- gone only get the default types they are missing.
+
+INSERT INTO charge_types (id, user_id, code, name, recurrence, calculation, taxable, tax_rate, is_active, created_at, updated_at)
+SELECT gen_random_uuid(), u.id, d.code, d.name, d.recurrence, d.calculation, false, 0, true, now(), now()
+FROM users u
+CROSS JOIN (VALUES
+    ('basic_rent', 'Basic Rent', 'recurring', 'fixed'),
+    ('gas', 'Gas Bill', 'recurring', 'fixed'),
+    ('electricity', 'Electricity Bill', 'recurring', 'metered'),
+    ('utility', 'Utility Bill', 'recurring', 'fixed'),
+    ('water', 'Water Charges', 'recurring', 'fixed'),
+    ('late_fee', 'Late Fee', 'one_off', 'fixed'),
+    ('maintenance', 'Maintenance', 'one_off', 'fixed')
+) AS d (code, name, recurrence, calculation)
+WHERE NOT EXISTS (SELECT 1 FROM charge_types ct WHERE ct.user_id = u.id AND ct.code = d.code);
+
+DO $$
+DECLARE
+    expected decimal;
+    moved decimal;
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM information_schema.columns
+        WHERE table_schema = current_schema() AND table_name = 'flats' AND column_name = 'basic_rent'
+    ) THEN
+        RETURN;
+    END IF;
+
+    -- Flat columns become flat charge assignments
+    SELECT coalesce(sum(coalesce(basic_rent, 0) + coalesce(gas_bill, 0) + coalesce(utility_bill, 0) + coalesce(water_charges, 0)), 0)
+    INTO expected FROM flats;
+
+    WITH inserted AS (
+        INSERT INTO flat_charges (id, flat_id, charge_type_id, amount, created_at, updated_at)
+        SELECT gen_random_uuid(), f.id, ct.id, a.amount, now(), now()
+        FROM flats f
+        JOIN houses h ON h.id = f.house_id
+        CROSS JOIN LATERAL (VALUES
+            ('basic_rent', f.basic_rent),
+            ('gas', f.gas_bill),
+            ('utility', f.utility_bill),
+            ('water', f.water_charges)
+        ) AS a (code, amount)
+        JOIN charge_types ct ON ct.user_id = h.user_id AND ct.code = a.code
+        WHERE a.amount <> 0
+        RETURNING amount
+    )
+    SELECT coalesce(sum(amount), 0) INTO moved FROM inserted;
+
+    -- A house whose landlord no longer exists has no charge types to use
+    IF moved <> expected THEN
+        RAISE EXCEPTION 'flat charges of % could not be moved, only % of them; check houses.user_id', expected, moved;
+    END IF;
+
+    -- Payment columns become payment items. Payments of a missing tenant
+    -- keep their items without a charge type rather than losing them.
+    SELECT coalesce(sum(coalesce(basic_rent, 0) + coalesce(gas_bill, 0) + coalesce(electricity_bill, 0) + coalesce(utility_bill, 0) + coalesce(water_charges, 0)), 0)
+    INTO expected FROM rent_payments WHERE is_advance = false;
+
+    WITH inserted AS (
+        INSERT INTO payment_items (id, rent_payment_id, charge_type_id, code, amount, created_at)
+        SELECT gen_random_uuid(), p.id, ct.id, a.code, a.amount, now()
+        FROM rent_payments p
+        LEFT JOIN tenants t ON t.id = p.tenant_id
+        CROSS JOIN LATERAL (VALUES
+            ('basic_rent', p.basic_rent),
+            ('gas', p.gas_bill),
+            ('electricity', p.electricity_bill),
+            ('utility', p.utility_bill),
+            ('water', p.water_charges)
+        ) AS a (code, amount)
+        LEFT JOIN charge_types ct ON ct.user_id = t.user_id AND ct.code = a.code
+        WHERE p.is_advance = false AND a.amount <> 0
+        RETURNING amount
+    )
+    SELECT coalesce(sum(amount), 0) INTO moved FROM inserted;
+
+    IF moved <> expected THEN
+        RAISE EXCEPTION 'payment amounts of % could not be moved, only % of them', expected, moved;
+    END IF;
+
+    ALTER TABLE flats DROP COLUMN basic_rent, DROP COLUMN gas_bill, DROP COLUMN utility_bill, DROP COLUMN water_charges;
+    ALTER TABLE rent_payments DROP COLUMN basic_rent, DROP COLUMN gas_bill, DROP COLUMN electricity_bill,
+        DROP COLUMN utility_bill, DROP COLUMN water_charges;
+END $$;
+
+-- Posted charges and shared bills point at the type matching their kind
+UPDATE charges SET charge_type_id = ct.id
+FROM tenants t, charge_types ct
+WHERE t.id = charges.tenant_id AND ct.user_id = t.user_id AND ct.code = charges.kind
+AND (charges.charge_type_id IS NULL OR charges.charge_type_id = '00000000-0000-0000-0000-000000000000');
+
+UPDATE shared_bills SET charge_type_id = ct.id
+FROM houses h, charge_types ct
+WHERE h.id = shared_bills.house_id AND ct.user_id = h.user_id AND ct.code = shared_bills.kind
+AND (shared_bills.charge_type_id IS NULL OR shared_bills.charge_type_id = '00000000-0000-0000-0000-000000000000');
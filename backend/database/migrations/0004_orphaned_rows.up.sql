@@ -0,0 +1,123 @@
+-- Rows whose references point at a row that does not exist. Foreign keys
+-- added in 0005 cannot be created while any are left, so fix or delete
+-- them first:
+--
+--   SELECT table_name, column_name, count(*) FROM orphaned_rows GROUP BY 1, 2;
+
+CREATE VIEW orphaned_rows (table_name, row_id, column_name, missing_id) AS
+SELECT 'houses'::text, c.id, 'user_id'::text, c.user_id FROM houses c
+WHERE c.user_id IS NOT NULL AND NOT EXISTS (SELECT 1 FROM users p WHERE p.id = c.user_id)
+UNION ALL
+SELECT 'tenants'::text, c.id, 'user_id'::text, c.user_id FROM tenants c
+WHERE c.user_id IS NOT NULL AND NOT EXISTS (SELECT 1 FROM users p WHERE p.id = c.user_id)
+UNION ALL
+SELECT 'charge_types'::text, c.id, 'user_id'::text, c.user_id FROM charge_types c
+WHERE c.user_id IS NOT NULL AND NOT EXISTS (SELECT 1 FROM users p WHERE p.id = c.user_id)
+UNION ALL
+SELECT 'vendors'::text, c.id, 'user_id'::text, c.user_id FROM vendors c
+WHERE c.user_id IS NOT NULL AND NOT EXISTS (SELECT 1 FROM users p WHERE p.id = c.user_id)
+UNION ALL
+SELECT 'expenses'::text, c.id, 'user_id'::text, c.user_id FROM expenses c
+WHERE c.user_id IS NOT NULL AND NOT EXISTS (SELECT 1 FROM users p WHERE p.id = c.user_id)
+UNION ALL
+SELECT 'maintenance_tickets'::text, c.id, 'user_id'::text, c.user_id FROM maintenance_tickets c
+WHERE c.user_id IS NOT NULL AND NOT EXISTS (SELECT 1 FROM users p WHERE p.id = c.user_id)
+UNION ALL
+SELECT 'payment_proofs'::text, c.id, 'user_id'::text, c.user_id FROM payment_proofs c
+WHERE c.user_id IS NOT NULL AND NOT EXISTS (SELECT 1 FROM users p WHERE p.id = c.user_id)
+UNION ALL
+SELECT 'import_batches'::text, c.id, 'user_id'::text, c.user_id FROM import_batches c
+WHERE c.user_id IS NOT NULL AND NOT EXISTS (SELECT 1 FROM users p WHERE p.id = c.user_id)
+UNION ALL
+SELECT 'flats'::text, c.id, 'house_id'::text, c.house_id FROM flats c
+WHERE c.house_id IS NOT NULL AND NOT EXISTS (SELECT 1 FROM houses p WHERE p.id = c.house_id)
+UNION ALL
+SELECT 'rent_policies'::text, c.id, 'house_id'::text, c.house_id FROM rent_policies c
+WHERE c.house_id IS NOT NULL AND NOT EXISTS (SELECT 1 FROM houses p WHERE p.id = c.house_id)
+UNION ALL
+SELECT 'tenants'::text, c.id, 'house_id'::text, c.house_id FROM tenants c
+WHERE c.house_id IS NOT NULL AND NOT EXISTS (SELECT 1 FROM houses p WHERE p.id = c.house_id)
+UNION ALL
+SELECT 'shared_bills'::text, c.id, 'house_id'::text, c.house_id FROM shared_bills c
+WHERE c.house_id IS NOT NULL AND NOT EXISTS (SELECT 1 FROM houses p WHERE p.id = c.house_id)
+UNION ALL
+SELECT 'expenses'::text, c.id, 'house_id'::text, c.house_id FROM expenses c
+WHERE c.house_id IS NOT NULL AND NOT EXISTS (SELECT 1 FROM houses p WHERE p.id = c.house_id)
+UNION ALL
+SELECT 'maintenance_tickets'::text, c.id, 'house_id'::text, c.house_id FROM maintenance_tickets c
+WHERE c.house_id IS NOT NULL AND NOT EXISTS (SELECT 1 FROM houses p WHERE p.id = c.house_id)
+UNION ALL
+SELECT 'flat_charges'::text, c.id, 'flat_id'::text, c.flat_id FROM flat_charges c
+WHERE c.flat_id IS NOT NULL AND NOT EXISTS (SELECT 1 FROM flats p WHERE p.id = c.flat_id)
+UNION ALL
+SELECT 'meters'::text, c.id, 'flat_id'::text, c.flat_id FROM meters c
+WHERE c.flat_id IS NOT NULL AND NOT EXISTS (SELECT 1 FROM flats p WHERE p.id = c.flat_id)
+UNION ALL
+SELECT 'tenants'::text, c.id, 'flat_id'::text, c.flat_id FROM tenants c
+WHERE c.flat_id IS NOT NULL AND NOT EXISTS (SELECT 1 FROM flats p WHERE p.id = c.flat_id)
+UNION ALL
+SELECT 'charges'::text, c.id, 'flat_id'::text, c.flat_id FROM charges c
+WHERE c.flat_id IS NOT NULL AND NOT EXISTS (SELECT 1 FROM flats p WHERE p.id = c.flat_id)
+UNION ALL
+SELECT 'expenses'::text, c.id, 'flat_id'::text, c.flat_id FROM expenses c
+WHERE c.flat_id IS NOT NULL AND NOT EXISTS (SELECT 1 FROM flats p WHERE p.id = c.flat_id)
+UNION ALL
+SELECT 'maintenance_tickets'::text, c.id, 'flat_id'::text, c.flat_id FROM maintenance_tickets c
+WHERE c.flat_id IS NOT NULL AND NOT EXISTS (SELECT 1 FROM flats p WHERE p.id = c.flat_id)
+UNION ALL
+SELECT 'rent_payments'::text, c.id, 'tenant_id'::text, c.tenant_id FROM rent_payments c
+WHERE c.tenant_id IS NOT NULL AND NOT EXISTS (SELECT 1 FROM tenants p WHERE p.id = c.tenant_id)
+UNION ALL
+SELECT 'charges'::text, c.id, 'tenant_id'::text, c.tenant_id FROM charges c
+WHERE c.tenant_id IS NOT NULL AND NOT EXISTS (SELECT 1 FROM tenants p WHERE p.id = c.tenant_id)
+UNION ALL
+SELECT 'payment_proofs'::text, c.id, 'tenant_id'::text, c.tenant_id FROM payment_proofs c
+WHERE c.tenant_id IS NOT NULL AND NOT EXISTS (SELECT 1 FROM tenants p WHERE p.id = c.tenant_id)
+UNION ALL
+SELECT 'maintenance_tickets'::text, c.id, 'tenant_id'::text, c.tenant_id FROM maintenance_tickets c
+WHERE c.tenant_id IS NOT NULL AND NOT EXISTS (SELECT 1 FROM tenants p WHERE p.id = c.tenant_id)
+UNION ALL
+SELECT 'sms_messages'::text, c.id, 'tenant_id'::text, c.tenant_id FROM sms_messages c
+WHERE c.tenant_id IS NOT NULL AND NOT EXISTS (SELECT 1 FROM tenants p WHERE p.id = c.tenant_id)
+UNION ALL
+SELECT 'payment_items'::text, c.id, 'rent_payment_id'::text, c.rent_payment_id FROM payment_items c
+WHERE c.rent_payment_id IS NOT NULL AND NOT EXISTS (SELECT 1 FROM rent_payments p WHERE p.id = c.rent_payment_id)
+UNION ALL
+SELECT 'payment_proofs'::text, c.id, 'rent_payment_id'::text, c.rent_payment_id FROM payment_proofs c
+WHERE c.rent_payment_id IS NOT NULL AND NOT EXISTS (SELECT 1 FROM rent_payments p WHERE p.id = c.rent_payment_id)
+UNION ALL
+SELECT 'flat_charges'::text, c.id, 'charge_type_id'::text, c.charge_type_id FROM flat_charges c
+WHERE c.charge_type_id IS NOT NULL AND NOT EXISTS (SELECT 1 FROM charge_types p WHERE p.id = c.charge_type_id)
+UNION ALL
+SELECT 'meter_readings'::text, c.id, 'meter_id'::text, c.meter_id FROM meter_readings c
+WHERE c.meter_id IS NOT NULL AND NOT EXISTS (SELECT 1 FROM meters p WHERE p.id = c.meter_id)
+UNION ALL
+SELECT 'shared_bill_shares'::text, c.id, 'shared_bill_id'::text, c.shared_bill_id FROM shared_bill_shares c
+WHERE c.shared_bill_id IS NOT NULL AND NOT EXISTS (SELECT 1 FROM shared_bills p WHERE p.id = c.shared_bill_id)
+UNION ALL
+SELECT 'ticket_photos'::text, c.id, 'ticket_id'::text, c.ticket_id FROM ticket_photos c
+WHERE c.ticket_id IS NOT NULL AND NOT EXISTS (SELECT 1 FROM maintenance_tickets p WHERE p.id = c.ticket_id)
+UNION ALL
+SELECT 'ticket_comments'::text, c.id, 'ticket_id'::text, c.ticket_id FROM ticket_comments c
+WHERE c.ticket_id IS NOT NULL AND NOT EXISTS (SELECT 1 FROM maintenance_tickets p WHERE p.id = c.ticket_id)
+UNION ALL
+SELECT 'expenses'::text, c.id, 'vendor_id'::text, c.vendor_id FROM expenses c
+WHERE c.vendor_id IS NOT NULL AND NOT EXISTS (SELECT 1 FROM vendors p WHERE p.id = c.vendor_id)
+UNION ALL
+SELECT 'maintenance_tickets'::text, c.id, 'vendor_id'::text, c.vendor_id FROM maintenance_tickets c
+WHERE c.vendor_id IS NOT NULL AND NOT EXISTS (SELECT 1 FROM vendors p WHERE p.id = c.vendor_id)
+UNION ALL
+SELECT 'expenses'::text, c.id, 'parent_id'::text, c.parent_id FROM expenses c
+WHERE c.parent_id IS NOT NULL AND NOT EXISTS (SELECT 1 FROM expenses p WHERE p.id = c.parent_id)
+UNION ALL
+SELECT 'houses'::text, c.id, 'import_batch_id'::text, c.import_batch_id FROM houses c
+WHERE c.import_batch_id IS NOT NULL AND NOT EXISTS (SELECT 1 FROM import_batches p WHERE p.id = c.import_batch_id)
+UNION ALL
+SELECT 'flats'::text, c.id, 'import_batch_id'::text, c.import_batch_id FROM flats c
+WHERE c.import_batch_id IS NOT NULL AND NOT EXISTS (SELECT 1 FROM import_batches p WHERE p.id = c.import_batch_id)
+UNION ALL
+SELECT 'tenants'::text, c.id, 'import_batch_id'::text, c.import_batch_id FROM tenants c
+WHERE c.import_batch_id IS NOT NULL AND NOT EXISTS (SELECT 1 FROM import_batches p WHERE p.id = c.import_batch_id)
+UNION ALL
+SELECT 'rent_payments'::text, c.id, 'import_batch_id'::text, c.import_batch_id FROM rent_payments c
+WHERE c.import_batch_id IS NOT NULL AND NOT EXISTS (SELECT 1 FROM import_batches p WHERE p.id = c.import_batch_id);
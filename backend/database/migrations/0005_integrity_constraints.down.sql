@@ -0,0 +1,80 @@
+DROP INDEX IF EXISTS idx_expenses_flat_id;
+DROP INDEX IF EXISTS idx_maintenance_tickets_flat_id;
+DROP INDEX IF EXISTS idx_maintenance_tickets_tenant_id;
+DROP INDEX IF EXISTS idx_charges_period;
+CREATE INDEX IF NOT EXISTS idx_charges_tenant_id ON charges (tenant_id);
+DROP INDEX IF EXISTS idx_rent_payments_payment_date;
+DROP INDEX IF EXISTS idx_rent_payments_period;
+CREATE INDEX IF NOT EXISTS idx_rent_payments_tenant_id ON rent_payments (tenant_id);
+DROP INDEX IF EXISTS idx_houses_user_id;
+DROP INDEX IF EXISTS idx_tenants_flat_id;
+DROP INDEX IF EXISTS idx_tenants_house_id;
+DROP INDEX IF EXISTS idx_tenants_user_id;
+
+ALTER TABLE rent_policies DROP CONSTRAINT IF EXISTS rent_policies_late_fee_check;
+ALTER TABLE meters DROP CONSTRAINT IF EXISTS meters_initial_reading_check;
+ALTER TABLE maintenance_tickets DROP CONSTRAINT IF EXISTS maintenance_tickets_cost_check;
+ALTER TABLE shared_bill_shares DROP CONSTRAINT IF EXISTS shared_bill_shares_amount_check;
+ALTER TABLE shared_bills DROP CONSTRAINT IF EXISTS shared_bills_total_amount_check;
+ALTER TABLE payment_proofs DROP CONSTRAINT IF EXISTS payment_proofs_amount_check;
+ALTER TABLE expenses DROP CONSTRAINT IF EXISTS expenses_amount_check;
+ALTER TABLE charge_types DROP CONSTRAINT IF EXISTS charge_types_tax_rate_check;
+ALTER TABLE flat_charges DROP CONSTRAINT IF EXISTS flat_charges_amount_check;
+ALTER TABLE payment_items DROP CONSTRAINT IF EXISTS payment_items_amount_check;
+ALTER TABLE rent_payments DROP CONSTRAINT IF EXISTS rent_payments_total_paid_check;
+ALTER TABLE tenants DROP CONSTRAINT IF EXISTS tenants_advance_amount_check;
+ALTER TABLE tenants DROP CONSTRAINT IF EXISTS tenants_members_check;
+ALTER TABLE flats DROP CONSTRAINT IF EXISTS flats_size_check;
+
+DROP INDEX IF EXISTS idx_flats_house_number;
+
+ALTER TABLE rent_payments DROP CONSTRAINT IF EXISTS rent_payments_import_batch_id_fkey;
+ALTER TABLE tenants DROP CONSTRAINT IF EXISTS tenants_import_batch_id_fkey;
+ALTER TABLE flats DROP CONSTRAINT IF EXISTS flats_import_batch_id_fkey;
+ALTER TABLE houses DROP CONSTRAINT IF EXISTS houses_import_batch_id_fkey;
+ALTER TABLE expenses DROP CONSTRAINT IF EXISTS expenses_parent_id_fkey;
+ALTER TABLE maintenance_tickets DROP CONSTRAINT IF EXISTS maintenance_tickets_vendor_id_fkey;
+ALTER TABLE expenses DROP CONSTRAINT IF EXISTS expenses_vendor_id_fkey;
+ALTER TABLE ticket_comments DROP CONSTRAINT IF EXISTS ticket_comments_ticket_id_fkey;
+ALTER TABLE ticket_photos DROP CONSTRAINT IF EXISTS ticket_photos_ticket_id_fkey;
+ALTER TABLE shared_bill_shares DROP CONSTRAINT IF EXISTS shared_bill_shares_shared_bill_id_fkey;
+ALTER TABLE meter_readings DROP CONSTRAINT IF EXISTS meter_readings_meter_id_fkey;
+ALTER TABLE flat_charges DROP CONSTRAINT IF EXISTS flat_charges_charge_type_id_fkey;
+ALTER TABLE payment_proofs DROP CONSTRAINT IF EXISTS payment_proofs_rent_payment_id_fkey;
+ALTER TABLE payment_items DROP CONSTRAINT IF EXISTS payment_items_rent_payment_id_fkey;
+ALTER TABLE sms_messages DROP CONSTRAINT IF EXISTS sms_messages_tenant_id_fkey;
+ALTER TABLE maintenance_tickets DROP CONSTRAINT IF EXISTS maintenance_tickets_tenant_id_fkey;
+ALTER TABLE payment_proofs DROP CONSTRAINT IF EXISTS payment_proofs_tenant_id_fkey;
+ALTER TABLE charges DROP CONSTRAINT IF EXISTS charges_tenant_id_fkey;
+ALTER TABLE rent_payments DROP CONSTRAINT IF EXISTS rent_payments_tenant_id_fkey;
+ALTER TABLE maintenance_tickets DROP CONSTRAINT IF EXISTS maintenance_tickets_flat_id_fkey;
+ALTER TABLE expenses DROP CONSTRAINT IF EXISTS expenses_flat_id_fkey;
+ALTER TABLE charges DROP CONSTRAINT IF EXISTS charges_flat_id_fkey;
+ALTER TABLE tenants DROP CONSTRAINT IF EXISTS tenants_flat_id_fkey;
+ALTER TABLE meters DROP CONSTRAINT IF EXISTS meters_flat_id_fkey;
+ALTER TABLE flat_charges DROP CONSTRAINT IF EXISTS flat_charges_flat_id_fkey;
+ALTER TABLE maintenance_tickets DROP CONSTRAINT IF EXISTS maintenance_tickets_house_id_fkey;
+ALTER TABLE expenses DROP CONSTRAINT IF EXISTS expenses_house_id_fkey;
+ALTER TABLE shared_bills DROP CONSTRAINT IF EXISTS shared_bills_house_id_fkey;
+ALTER TABLE tenants DROP CONSTRAINT IF EXISTS tenants_house_id_fkey;
+ALTER TABLE rent_policies DROP CONSTRAINT IF EXISTS rent_policies_house_id_fkey;
+ALTER TABLE flats DROP CONSTRAINT IF EXISTS flats_house_id_fkey;
+ALTER TABLE import_batches DROP CONSTRAINT IF EXISTS import_batches_user_id_fkey;
+ALTER TABLE payment_proofs DROP CONSTRAINT IF EXISTS payment_proofs_user_id_fkey;
+ALTER TABLE maintenance_tickets DROP CONSTRAINT IF EXISTS maintenance_tickets_user_id_fkey;
+ALTER TABLE expenses DROP CONSTRAINT IF EXISTS expenses_user_id_fkey;
+ALTER TABLE vendors DROP CONSTRAINT IF EXISTS vendors_user_id_fkey;
+ALTER TABLE charge_types DROP CONSTRAINT IF EXISTS charge_types_user_id_fkey;
+ALTER TABLE tenants DROP CONSTRAINT IF EXISTS tenants_user_id_fkey;
+ALTER TABLE houses DROP CONSTRAINT IF EXISTS houses_user_id_fkey;
+
+-- The constraints AutoMigrate created
+ALTER TABLE flats ADD CONSTRAINT fk_houses_flats FOREIGN KEY (house_id) REFERENCES houses (id);
+ALTER TABLE tenants ADD CONSTRAINT fk_tenants_flat FOREIGN KEY (flat_id) REFERENCES flats (id);
+ALTER TABLE payment_items ADD CONSTRAINT fk_rent_payments_items FOREIGN KEY (rent_payment_id) REFERENCES rent_payments (id);
+ALTER TABLE flat_charges ADD CONSTRAINT fk_flats_charges FOREIGN KEY (flat_id) REFERENCES flats (id);
+ALTER TABLE flat_charges ADD CONSTRAINT fk_flat_charges_charge_type FOREIGN KEY (charge_type_id) REFERENCES charge_types (id);
+ALTER TABLE shared_bill_shares ADD CONSTRAINT fk_shared_bills_shares FOREIGN KEY (shared_bill_id) REFERENCES shared_bills (id);
+ALTER TABLE ticket_photos ADD CONSTRAINT fk_maintenance_tickets_photos FOREIGN KEY (ticket_id) REFERENCES maintenance_tickets (id);
+ALTER TABLE ticket_comments ADD CONSTRAINT fk_maintenance_tickets_comments FOREIGN KEY (ticket_id) REFERENCES maintenance_tickets (id);
+ALTER TABLE payment_proofs ADD CONSTRAINT fk_payment_proofs_tenant FOREIGN KEY (tenant_id) REFERENCES tenants (id);
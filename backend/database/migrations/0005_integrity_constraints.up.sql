@@ -0,0 +1,108 @@
+-- Relational integrity: foreign keys, unique and check constraints, and
+-- the indexes behind the dashboard and dues queries.
+--
+-- References a landlord owns outright (flats of a house, items of a
+-- payment) cascade on delete, optional ones are cleared, and anything
+-- carrying money or history blocks the delete.
+
+DO $$
+DECLARE
+    summary text;
+BEGIN
+    SELECT string_agg(format('%s.%s: %s', table_name, column_name, n), ', ')
+    INTO summary
+    FROM (
+        SELECT table_name, column_name, count(*) AS n
+        FROM orphaned_rows
+        GROUP BY table_name, column_name
+        ORDER BY table_name, column_name
+    ) counts;
+
+    IF summary IS NOT NULL THEN
+        RAISE EXCEPTION 'orphaned rows must be fixed first (see the orphaned_rows view): %', summary;
+    END IF;
+END $$;
+
+-- Foreign keys
+ALTER TABLE flats DROP CONSTRAINT IF EXISTS fk_houses_flats;
+ALTER TABLE tenants DROP CONSTRAINT IF EXISTS fk_tenants_flat;
+ALTER TABLE payment_items DROP CONSTRAINT IF EXISTS fk_rent_payments_items;
+ALTER TABLE flat_charges DROP CONSTRAINT IF EXISTS fk_flats_charges;
+ALTER TABLE flat_charges DROP CONSTRAINT IF EXISTS fk_flat_charges_charge_type;
+ALTER TABLE shared_bill_shares DROP CONSTRAINT IF EXISTS fk_shared_bills_shares;
+ALTER TABLE ticket_photos DROP CONSTRAINT IF EXISTS fk_maintenance_tickets_photos;
+ALTER TABLE ticket_comments DROP CONSTRAINT IF EXISTS fk_maintenance_tickets_comments;
+ALTER TABLE payment_proofs DROP CONSTRAINT IF EXISTS fk_payment_proofs_tenant;
+ALTER TABLE houses ADD CONSTRAINT houses_user_id_fkey FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE;
+ALTER TABLE tenants ADD CONSTRAINT tenants_user_id_fkey FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE;
+ALTER TABLE charge_types ADD CONSTRAINT charge_types_user_id_fkey FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE;
+ALTER TABLE vendors ADD CONSTRAINT vendors_user_id_fkey FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE;
+ALTER TABLE expenses ADD CONSTRAINT expenses_user_id_fkey FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE;
+ALTER TABLE maintenance_tickets ADD CONSTRAINT maintenance_tickets_user_id_fkey FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE;
+ALTER TABLE payment_proofs ADD CONSTRAINT payment_proofs_user_id_fkey FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE;
+ALTER TABLE import_batches ADD CONSTRAINT import_batches_user_id_fkey FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE;
+ALTER TABLE flats ADD CONSTRAINT flats_house_id_fkey FOREIGN KEY (house_id) REFERENCES houses (id) ON DELETE CASCADE;
+ALTER TABLE rent_policies ADD CONSTRAINT rent_policies_house_id_fkey FOREIGN KEY (house_id) REFERENCES houses (id) ON DELETE CASCADE;
+ALTER TABLE tenants ADD CONSTRAINT tenants_house_id_fkey FOREIGN KEY (house_id) REFERENCES houses (id) ON DELETE NO ACTION;
+ALTER TABLE shared_bills ADD CONSTRAINT shared_bills_house_id_fkey FOREIGN KEY (house_id) REFERENCES houses (id) ON DELETE NO ACTION;
+ALTER TABLE expenses ADD CONSTRAINT expenses_house_id_fkey FOREIGN KEY (house_id) REFERENCES houses (id) ON DELETE NO ACTION;
+ALTER TABLE maintenance_tickets ADD CONSTRAINT maintenance_tickets_house_id_fkey FOREIGN KEY (house_id) REFERENCES houses (id) ON DELETE NO ACTION;
+ALTER TABLE flat_charges ADD CONSTRAINT flat_charges_flat_id_fkey FOREIGN KEY (flat_id) REFERENCES flats (id) ON DELETE CASCADE;
+ALTER TABLE meters ADD CONSTRAINT meters_flat_id_fkey FOREIGN KEY (flat_id) REFERENCES flats (id) ON DELETE CASCADE;
+ALTER TABLE tenants ADD CONSTRAINT tenants_flat_id_fkey FOREIGN KEY (flat_id) REFERENCES flats (id) ON DELETE NO ACTION;
+ALTER TABLE charges ADD CONSTRAINT charges_flat_id_fkey FOREIGN KEY (flat_id) REFERENCES flats (id) ON DELETE NO ACTION;
+ALTER TABLE expenses ADD CONSTRAINT expenses_flat_id_fkey FOREIGN KEY (flat_id) REFERENCES flats (id) ON DELETE SET NULL;
+ALTER TABLE maintenance_tickets ADD CONSTRAINT maintenance_tickets_flat_id_fkey FOREIGN KEY (flat_id) REFERENCES flats (id) ON DELETE SET NULL;
+ALTER TABLE rent_payments ADD CONSTRAINT rent_payments_tenant_id_fkey FOREIGN KEY (tenant_id) REFERENCES tenants (id) ON DELETE NO ACTION;
+ALTER TABLE charges ADD CONSTRAINT charges_tenant_id_fkey FOREIGN KEY (tenant_id) REFERENCES tenants (id) ON DELETE NO ACTION;
+ALTER TABLE payment_proofs ADD CONSTRAINT payment_proofs_tenant_id_fkey FOREIGN KEY (tenant_id) REFERENCES tenants (id) ON DELETE NO ACTION;
+ALTER TABLE maintenance_tickets ADD CONSTRAINT maintenance_tickets_tenant_id_fkey FOREIGN KEY (tenant_id) REFERENCES tenants (id) ON DELETE SET NULL;
+ALTER TABLE sms_messages ADD CONSTRAINT sms_messages_tenant_id_fkey FOREIGN KEY (tenant_id) REFERENCES tenants (id) ON DELETE SET NULL;
+ALTER TABLE payment_items ADD CONSTRAINT payment_items_rent_payment_id_fkey FOREIGN KEY (rent_payment_id) REFERENCES rent_payments (id) ON DELETE CASCADE;
+ALTER TABLE payment_proofs ADD CONSTRAINT payment_proofs_rent_payment_id_fkey FOREIGN KEY (rent_payment_id) REFERENCES rent_payments (id) ON DELETE SET NULL;
+ALTER TABLE flat_charges ADD CONSTRAINT flat_charges_charge_type_id_fkey FOREIGN KEY (charge_type_id) REFERENCES charge_types (id) ON DELETE NO ACTION;
+ALTER TABLE meter_readings ADD CONSTRAINT meter_readings_meter_id_fkey FOREIGN KEY (meter_id) REFERENCES meters (id) ON DELETE NO ACTION;
+ALTER TABLE shared_bill_shares ADD CONSTRAINT shared_bill_shares_shared_bill_id_fkey FOREIGN KEY (shared_bill_id) REFERENCES shared_bills (id) ON DELETE CASCADE;
+ALTER TABLE ticket_photos ADD CONSTRAINT ticket_photos_ticket_id_fkey FOREIGN KEY (ticket_id) REFERENCES maintenance_tickets (id) ON DELETE CASCADE;
+ALTER TABLE ticket_comments ADD CONSTRAINT ticket_comments_ticket_id_fkey FOREIGN KEY (ticket_id) REFERENCES maintenance_tickets (id) ON DELETE CASCADE;
+ALTER TABLE expenses ADD CONSTRAINT expenses_vendor_id_fkey FOREIGN KEY (vendor_id) REFERENCES vendors (id) ON DELETE SET NULL;
+ALTER TABLE maintenance_tickets ADD CONSTRAINT maintenance_tickets_vendor_id_fkey FOREIGN KEY (vendor_id) REFERENCES vendors (id) ON DELETE SET NULL;
+ALTER TABLE expenses ADD CONSTRAINT expenses_parent_id_fkey FOREIGN KEY (parent_id) REFERENCES expenses (id) ON DELETE SET NULL;
+ALTER TABLE houses ADD CONSTRAINT houses_import_batch_id_fkey FOREIGN KEY (import_batch_id) REFERENCES import_batches (id) ON DELETE SET NULL;
+ALTER TABLE flats ADD CONSTRAINT flats_import_batch_id_fkey FOREIGN KEY (import_batch_id) REFERENCES import_batches (id) ON DELETE SET NULL;
+ALTER TABLE tenants ADD CONSTRAINT tenants_import_batch_id_fkey FOREIGN KEY (import_batch_id) REFERENCES import_batches (id) ON DELETE SET NULL;
+ALTER TABLE rent_payments ADD CONSTRAINT rent_payments_import_batch_id_fkey FOREIGN KEY (import_batch_id) REFERENCES import_batches (id) ON DELETE SET NULL;
+
+-- Flat numbers are unique within a house
+CREATE UNIQUE INDEX idx_flats_house_number ON flats (house_id, number);
+
+-- Amounts are never negative
+ALTER TABLE flats ADD CONSTRAINT flats_size_check CHECK (size >= 0);
+ALTER TABLE tenants ADD CONSTRAINT tenants_members_check CHECK (members >= 1);
+ALTER TABLE tenants ADD CONSTRAINT tenants_advance_amount_check CHECK (advance_amount >= 0);
+ALTER TABLE rent_payments ADD CONSTRAINT rent_payments_total_paid_check CHECK (total_paid >= 0);
+ALTER TABLE payment_items ADD CONSTRAINT payment_items_amount_check CHECK (amount >= 0);
+ALTER TABLE flat_charges ADD CONSTRAINT flat_charges_amount_check CHECK (amount >= 0);
+ALTER TABLE charge_types ADD CONSTRAINT charge_types_tax_rate_check CHECK (tax_rate BETWEEN 0 AND 100);
+ALTER TABLE expenses ADD CONSTRAINT expenses_amount_check CHECK (amount >= 0);
+ALTER TABLE payment_proofs ADD CONSTRAINT payment_proofs_amount_check CHECK (amount > 0);
+ALTER TABLE shared_bills ADD CONSTRAINT shared_bills_total_amount_check CHECK (total_amount >= 0);
+ALTER TABLE shared_bill_shares ADD CONSTRAINT shared_bill_shares_amount_check CHECK (amount >= 0);
+ALTER TABLE maintenance_tickets ADD CONSTRAINT maintenance_tickets_cost_check CHECK (cost >= 0);
+ALTER TABLE meters ADD CONSTRAINT meters_initial_reading_check CHECK (initial_reading >= 0);
+ALTER TABLE rent_policies ADD CONSTRAINT rent_policies_late_fee_check CHECK (grace_days >= 0 AND late_fee_amount >= 0 AND late_fee_cap >= 0);
+
+-- Indexes for the dashboard and dues queries, and for the foreign keys
+-- that have none
+CREATE INDEX idx_tenants_user_id ON tenants (user_id);
+CREATE INDEX idx_tenants_house_id ON tenants (house_id);
+CREATE INDEX idx_tenants_flat_id ON tenants (flat_id);
+CREATE INDEX idx_houses_user_id ON houses (user_id);
+DROP INDEX IF EXISTS idx_rent_payments_tenant_id;
+CREATE INDEX idx_rent_payments_period ON rent_payments (tenant_id, year, month);
+CREATE INDEX idx_rent_payments_payment_date ON rent_payments (payment_date);
+DROP INDEX IF EXISTS idx_charges_tenant_id;
+CREATE INDEX idx_charges_period ON charges (tenant_id, year, month);
+CREATE INDEX idx_maintenance_tickets_tenant_id ON maintenance_tickets (tenant_id);
+CREATE INDEX idx_maintenance_tickets_flat_id ON maintenance_tickets (flat_id);
+CREATE INDEX idx_expenses_flat_id ON expenses (flat_id);
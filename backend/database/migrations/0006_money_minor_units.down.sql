@@ -0,0 +1,23 @@
+ALTER TABLE rent_policies DROP CONSTRAINT rent_policies_late_fee_check;
+
+ALTER TABLE payment_proofs ALTER COLUMN amount TYPE decimal USING amount / 100.0;
+ALTER TABLE maintenance_tickets ALTER COLUMN cost TYPE decimal USING cost / 100.0;
+ALTER TABLE expenses ALTER COLUMN amount TYPE decimal USING amount / 100.0;
+ALTER TABLE rent_policies ALTER COLUMN late_fee_cap TYPE decimal USING late_fee_cap / 100.0;
+ALTER TABLE rent_policies ALTER COLUMN late_fee_amount TYPE decimal USING late_fee_amount / 100.0;
+ALTER TABLE payment_items ALTER COLUMN amount TYPE decimal USING amount / 100.0;
+ALTER TABLE flat_charges ALTER COLUMN amount TYPE decimal USING amount / 100.0;
+ALTER TABLE shared_bill_shares ALTER COLUMN amount TYPE decimal USING amount / 100.0;
+ALTER TABLE shared_bills ALTER COLUMN total_amount TYPE decimal USING total_amount / 100.0;
+ALTER TABLE electricity_tariffs ALTER COLUMN meter_rent TYPE decimal USING meter_rent / 100.0;
+ALTER TABLE meter_readings ALTER COLUMN amount TYPE decimal USING amount / 100.0;
+ALTER TABLE meter_readings ALTER COLUMN vat TYPE decimal USING vat / 100.0;
+ALTER TABLE meter_readings ALTER COLUMN meter_rent TYPE decimal USING meter_rent / 100.0;
+ALTER TABLE meter_readings ALTER COLUMN energy_charge TYPE decimal USING energy_charge / 100.0;
+ALTER TABLE charges ALTER COLUMN amount TYPE decimal USING amount / 100.0;
+ALTER TABLE rent_payments ALTER COLUMN total_paid TYPE decimal USING total_paid / 100.0;
+ALTER TABLE tenants ALTER COLUMN advance_amount TYPE decimal USING advance_amount / 100.0;
+
+UPDATE rent_policies SET late_fee_amount = late_fee_percent WHERE late_fee_type = 'percent';
+ALTER TABLE rent_policies DROP COLUMN late_fee_percent;
+ALTER TABLE rent_policies ADD CONSTRAINT rent_policies_late_fee_check CHECK (grace_days >= 0 AND late_fee_amount >= 0 AND late_fee_cap >= 0);
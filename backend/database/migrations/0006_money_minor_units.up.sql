@@ -0,0 +1,33 @@
+-- Money as whole paisa. Every amount column becomes a bigint of minor
+-- units (1500.50 is stored as 150050); the API still reads and writes
+-- amounts in taka with two decimals. Values are rounded half away from
+-- zero to the paisa, which only changes amounts that were stored with
+-- more than two decimals.
+--
+-- late_fee_amount used to hold a percent for percent late fees. Those
+-- move to the new late_fee_percent column first so they are not scaled.
+
+ALTER TABLE rent_policies ADD COLUMN late_fee_percent decimal;
+UPDATE rent_policies SET late_fee_percent = late_fee_amount, late_fee_amount = 0 WHERE late_fee_type = 'percent';
+UPDATE rent_policies SET late_fee_percent = 0 WHERE late_fee_percent IS NULL;
+ALTER TABLE rent_policies DROP CONSTRAINT rent_policies_late_fee_check;
+
+ALTER TABLE tenants ALTER COLUMN advance_amount TYPE bigint USING round(advance_amount * 100);
+ALTER TABLE rent_payments ALTER COLUMN total_paid TYPE bigint USING round(total_paid * 100);
+ALTER TABLE charges ALTER COLUMN amount TYPE bigint USING round(amount * 100);
+ALTER TABLE meter_readings ALTER COLUMN energy_charge TYPE bigint USING round(energy_charge * 100);
+ALTER TABLE meter_readings ALTER COLUMN meter_rent TYPE bigint USING round(meter_rent * 100);
+ALTER TABLE meter_readings ALTER COLUMN vat TYPE bigint USING round(vat * 100);
+ALTER TABLE meter_readings ALTER COLUMN amount TYPE bigint USING round(amount * 100);
+ALTER TABLE electricity_tariffs ALTER COLUMN meter_rent TYPE bigint USING round(meter_rent * 100);
+ALTER TABLE shared_bills ALTER COLUMN total_amount TYPE bigint USING round(total_amount * 100);
+ALTER TABLE shared_bill_shares ALTER COLUMN amount TYPE bigint USING round(amount * 100);
+ALTER TABLE flat_charges ALTER COLUMN amount TYPE bigint USING round(amount * 100);
+ALTER TABLE payment_items ALTER COLUMN amount TYPE bigint USING round(amount * 100);
+ALTER TABLE rent_policies ALTER COLUMN late_fee_amount TYPE bigint USING round(late_fee_amount * 100);
+ALTER TABLE rent_policies ALTER COLUMN late_fee_cap TYPE bigint USING round(late_fee_cap * 100);
+ALTER TABLE expenses ALTER COLUMN amount TYPE bigint USING round(amount * 100);
+ALTER TABLE maintenance_tickets ALTER COLUMN cost TYPE bigint USING round(cost * 100);
+ALTER TABLE payment_proofs ALTER COLUMN amount TYPE bigint USING round(amount * 100);
+
+ALTER TABLE rent_policies ADD CONSTRAINT rent_policies_late_fee_check CHECK (grace_days >= 0 AND late_fee_amount >= 0 AND late_fee_percent BETWEEN 0 AND 100 AND late_fee_cap >= 0);
@@ -0,0 +1,33 @@
+DROP INDEX IF EXISTS idx_rent_payments_period;
+DROP INDEX IF EXISTS idx_charges_period;
+DROP INDEX IF EXISTS idx_meter_period;
+
+ALTER TABLE rent_payments DROP CONSTRAINT IF EXISTS rent_payments_period_check;
+ALTER TABLE charges DROP CONSTRAINT IF EXISTS charges_period_check;
+ALTER TABLE meter_readings DROP CONSTRAINT IF EXISTS meter_readings_period_check;
+ALTER TABLE payment_proofs DROP CONSTRAINT IF EXISTS payment_proofs_period_check;
+ALTER TABLE shared_bills DROP CONSTRAINT IF EXISTS shared_bills_period_check;
+ALTER TABLE rent_payments ALTER COLUMN is_advance DROP NOT NULL;
+
+ALTER TABLE rent_payments ADD COLUMN month text, ADD COLUMN year bigint;
+ALTER TABLE charges ADD COLUMN month text, ADD COLUMN year bigint;
+ALTER TABLE meter_readings ADD COLUMN month text, ADD COLUMN year bigint;
+ALTER TABLE payment_proofs ADD COLUMN month text, ADD COLUMN year bigint;
+ALTER TABLE shared_bills ADD COLUMN month text, ADD COLUMN year bigint;
+
+UPDATE rent_payments SET month = to_char(period, 'FMMonth'), year = extract(year from period) WHERE period IS NOT NULL;
+UPDATE rent_payments SET month = 'Advance', year = extract(year from payment_date) WHERE period IS NULL;
+UPDATE charges SET month = to_char(period, 'FMMonth'), year = extract(year from period);
+UPDATE meter_readings SET month = to_char(period, 'FMMonth'), year = extract(year from period);
+UPDATE payment_proofs SET month = to_char(period, 'FMMonth'), year = extract(year from period);
+UPDATE shared_bills SET month = to_char(period, 'FMMonth'), year = extract(year from period);
+
+ALTER TABLE rent_payments DROP COLUMN period;
+ALTER TABLE charges DROP COLUMN period;
+ALTER TABLE meter_readings DROP COLUMN period;
+ALTER TABLE payment_proofs DROP COLUMN period;
+ALTER TABLE shared_bills DROP COLUMN period;
+
+CREATE INDEX idx_rent_payments_period ON rent_payments (tenant_id, year, month);
+CREATE INDEX idx_charges_period ON charges (tenant_id, year, month);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_meter_period ON meter_readings (meter_id, month, year);
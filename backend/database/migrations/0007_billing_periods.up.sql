@@ -0,0 +1,111 @@
+-- Billing periods. The month name and year columns of payments, charges,
+-- meter readings, payment proofs and shared bills become one period date
+-- holding the first day of the month. Advance deposits belong to no
+-- period: their period is NULL instead of the month "Advance".
+--
+-- Month names are matched case-insensitively as full names, three letter
+-- abbreviations or numbers. The migration stops, listing the counts, if a
+-- row's month cannot be read or if two rows end up in the same period
+-- where only one is allowed.
+
+CREATE FUNCTION pg_temp.billing_period(month text, year bigint) RETURNS date AS $$
+    SELECT make_date(CAST(year AS int), names.num, 1)
+    FROM (VALUES
+        ('january', 1), ('jan', 1), ('1', 1), ('01', 1),
+        ('february', 2), ('feb', 2), ('2', 2), ('02', 2),
+        ('march', 3), ('mar', 3), ('3', 3), ('03', 3),
+        ('april', 4), ('apr', 4), ('4', 4), ('04', 4),
+        ('may', 5), ('5', 5), ('05', 5),
+        ('june', 6), ('jun', 6), ('6', 6), ('06', 6),
+        ('july', 7), ('jul', 7), ('7', 7), ('07', 7),
+        ('august', 8), ('aug', 8), ('8', 8), ('08', 8),
+        ('september', 9), ('sep', 9), ('sept', 9), ('9', 9), ('09', 9),
+        ('october', 10), ('oct', 10), ('10', 10),
+        ('november', 11), ('nov', 11), ('11', 11),
+        ('december', 12), ('dec', 12), ('12', 12)
+    ) AS names (name, num)
+    WHERE names.name = lower(trim(month)) AND year BETWEEN 1900 AND 9999
+$$ LANGUAGE sql IMMUTABLE;
+
+ALTER TABLE rent_payments ADD COLUMN period date;
+ALTER TABLE charges ADD COLUMN period date;
+ALTER TABLE meter_readings ADD COLUMN period date;
+ALTER TABLE payment_proofs ADD COLUMN period date;
+ALTER TABLE shared_bills ADD COLUMN period date;
+
+UPDATE rent_payments SET is_advance = true WHERE lower(trim(month)) = 'advance';
+UPDATE rent_payments SET is_advance = false WHERE is_advance IS NULL;
+UPDATE rent_payments SET period = pg_temp.billing_period(month, year) WHERE NOT is_advance;
+UPDATE charges SET period = pg_temp.billing_period(month, year);
+UPDATE meter_readings SET period = pg_temp.billing_period(month, year);
+UPDATE payment_proofs SET period = pg_temp.billing_period(month, year);
+UPDATE shared_bills SET period = pg_temp.billing_period(month, year);
+
+DO $$
+DECLARE
+    summary text;
+BEGIN
+    SELECT string_agg(format('%s: %s', problem, n), ', ')
+    INTO summary
+    FROM (
+        SELECT 'rent_payments with an unreadable month' AS problem, count(*) AS n
+        FROM rent_payments WHERE NOT is_advance AND period IS NULL
+        UNION ALL
+        SELECT 'charges with an unreadable month', count(*) FROM charges WHERE period IS NULL
+        UNION ALL
+        SELECT 'meter_readings with an unreadable month', count(*) FROM meter_readings WHERE period IS NULL
+        UNION ALL
+        SELECT 'payment_proofs with an unreadable month', count(*) FROM payment_proofs WHERE period IS NULL
+        UNION ALL
+        SELECT 'shared_bills with an unreadable month', count(*) FROM shared_bills WHERE period IS NULL
+        UNION ALL
+        SELECT 'duplicate charges', count(*) FROM (
+            SELECT 1 FROM charges
+            GROUP BY tenant_id, period, charge_type_id, source_type, source_id
+            HAVING count(*) > 1
+        ) duplicates
+        UNION ALL
+        SELECT 'duplicate meter_readings', count(*) FROM (
+            SELECT 1 FROM meter_readings
+            GROUP BY meter_id, period
+            HAVING count(*) > 1
+        ) duplicates
+    ) counts
+    WHERE n > 0;
+
+    IF summary IS NOT NULL THEN
+        RAISE EXCEPTION 'rows must be fixed before moving to billing periods: %', summary;
+    END IF;
+END $$;
+
+DROP FUNCTION pg_temp.billing_period(text, bigint);
+
+DROP INDEX IF EXISTS idx_rent_payments_period;
+DROP INDEX IF EXISTS idx_charges_period;
+DROP INDEX IF EXISTS idx_meter_period;
+
+ALTER TABLE rent_payments DROP COLUMN month, DROP COLUMN year;
+ALTER TABLE charges DROP COLUMN month, DROP COLUMN year;
+ALTER TABLE meter_readings DROP COLUMN month, DROP COLUMN year;
+ALTER TABLE payment_proofs DROP COLUMN month, DROP COLUMN year;
+ALTER TABLE shared_bills DROP COLUMN month, DROP COLUMN year;
+
+ALTER TABLE rent_payments ALTER COLUMN is_advance SET NOT NULL;
+ALTER TABLE charges ALTER COLUMN period SET NOT NULL;
+ALTER TABLE meter_readings ALTER COLUMN period SET NOT NULL;
+ALTER TABLE payment_proofs ALTER COLUMN period SET NOT NULL;
+ALTER TABLE shared_bills ALTER COLUMN period SET NOT NULL;
+
+-- A period is always the first day of a month, and only advances go without one
+ALTER TABLE rent_payments ADD CONSTRAINT rent_payments_period_check
+    CHECK ((period IS NULL) = is_advance AND (period IS NULL OR extract(day from period) = 1));
+ALTER TABLE charges ADD CONSTRAINT charges_period_check CHECK (extract(day from period) = 1);
+ALTER TABLE meter_readings ADD CONSTRAINT meter_readings_period_check CHECK (extract(day from period) = 1);
+ALTER TABLE payment_proofs ADD CONSTRAINT payment_proofs_period_check CHECK (extract(day from period) = 1);
+ALTER TABLE shared_bills ADD CONSTRAINT shared_bills_period_check CHECK (extract(day from period) = 1);
+
+CREATE INDEX idx_rent_payments_period ON rent_payments (tenant_id, period);
+-- One charge per tenant, period and charge type from each source; manual
+-- charges all share the nil source
+CREATE UNIQUE INDEX idx_charges_period ON charges (tenant_id, period, charge_type_id, source_type, source_id);
+CREATE UNIQUE INDEX idx_meter_period ON meter_readings (meter_id, period);
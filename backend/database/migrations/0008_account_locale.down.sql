@@ -0,0 +1,6 @@
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_locale_check;
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_currency_check;
+
+ALTER TABLE users DROP COLUMN locale;
+ALTER TABLE users DROP COLUMN currency;
+ALTER TABLE users DROP COLUMN timezone;
@@ -0,0 +1,11 @@
+-- Per-account time zone, currency and locale. Existing accounts keep the
+-- zone the server used to run in. Timestamps are already timestamptz, so
+-- moving the connection to UTC changes how they are read, not what is
+-- stored.
+
+ALTER TABLE users ADD COLUMN timezone text NOT NULL DEFAULT 'Asia/Dhaka';
+ALTER TABLE users ADD COLUMN currency text NOT NULL DEFAULT 'BDT';
+ALTER TABLE users ADD COLUMN locale text NOT NULL DEFAULT 'en';
+
+ALTER TABLE users ADD CONSTRAINT users_currency_check CHECK (currency ~ '^[A-Z]{3}$');
+ALTER TABLE users ADD CONSTRAINT users_locale_check CHECK (locale IN ('bn', 'en'));
@@ -0,0 +1,3 @@
+-- Merged items stay merged; the payment totals are unchanged.
+
+DROP INDEX IF EXISTS idx_payment_items_charge_type;
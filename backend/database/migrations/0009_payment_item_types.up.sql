@@ -0,0 +1,27 @@
+-- A payment lists each charge type at most once. Partial payments are
+-- still separate payments, so a tenant can pay a period's rent in several
+-- instalments; what was billed stays unique per tenant, charge type and
+-- period through idx_charges_period. Items repeating a type within one
+-- payment are merged first, keeping the payment's total.
+
+-- Items without a charge type hold NULL, not the nil UUID, so they never
+-- collide
+UPDATE payment_items SET charge_type_id = NULL WHERE charge_type_id = '00000000-0000-0000-0000-000000000000';
+
+CREATE TEMP TABLE merged_payment_items ON COMMIT DROP AS
+SELECT (array_agg(id ORDER BY created_at, id))[1] AS keep_id, rent_payment_id, charge_type_id, sum(amount) AS amount
+FROM payment_items
+WHERE charge_type_id IS NOT NULL
+GROUP BY rent_payment_id, charge_type_id
+HAVING count(*) > 1;
+
+UPDATE payment_items SET amount = m.amount
+FROM merged_payment_items m
+WHERE payment_items.id = m.keep_id;
+
+DELETE FROM payment_items USING merged_payment_items m
+WHERE payment_items.rent_payment_id = m.rent_payment_id
+AND payment_items.charge_type_id = m.charge_type_id
+AND payment_items.id <> m.keep_id;
+
+CREATE UNIQUE INDEX idx_payment_items_charge_type ON payment_items (rent_payment_id, charge_type_id);
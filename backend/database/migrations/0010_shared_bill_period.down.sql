@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_shared_bills_period;
@@ -0,0 +1,24 @@
+-- A house gets one shared bill of each kind per month. Existing duplicates
+-- already posted charges to tenants, so they are reported rather than
+-- merged: delete or correct the extra bills and their charges first.
+
+DO $$
+DECLARE
+    summary text;
+BEGIN
+    SELECT string_agg(format('house %s, %s %s: %s bills', house_id, kind, period, n), ', ')
+    INTO summary
+    FROM (
+        SELECT house_id, kind, period, count(*) AS n
+        FROM shared_bills
+        GROUP BY house_id, kind, period
+        HAVING count(*) > 1
+        ORDER BY house_id, kind, period
+    ) duplicates;
+
+    IF summary IS NOT NULL THEN
+        RAISE EXCEPTION 'duplicate shared bills must be fixed first: %', summary;
+    END IF;
+END $$;
+
+CREATE UNIQUE INDEX idx_shared_bills_period ON shared_bills (house_id, kind, period);
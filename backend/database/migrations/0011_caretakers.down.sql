@@ -0,0 +1,4 @@
+DELETE FROM users WHERE owner_id IS NOT NULL;
+
+DROP INDEX IF EXISTS idx_users_owner_id;
+ALTER TABLE users DROP COLUMN owner_id;
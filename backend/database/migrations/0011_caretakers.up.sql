@@ -0,0 +1,5 @@
+-- Caretakers sign in with a login of their own that works on an owner's
+-- account. Removing the owner removes their caretakers.
+
+ALTER TABLE users ADD COLUMN owner_id uuid REFERENCES users (id) ON DELETE CASCADE;
+CREATE INDEX idx_users_owner_id ON users (owner_id);
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_report_emails_period;
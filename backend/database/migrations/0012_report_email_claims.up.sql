@@ -0,0 +1,17 @@
+-- A monthly summary is claimed by inserting its log row before it is
+-- sent, so each landlord gets one row per period. Earlier retries left a
+-- row per attempt; keep the one that was sent, or else the latest.
+
+DELETE FROM report_emails
+WHERE id IN (
+    SELECT id FROM (
+        SELECT id, row_number() OVER (
+            PARTITION BY user_id, period
+            ORDER BY status = 'sent' DESC, created_at DESC
+        ) AS n
+        FROM report_emails
+    ) attempts
+    WHERE n > 1
+);
+
+CREATE UNIQUE INDEX idx_report_emails_period ON report_emails (user_id, period);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_sms_messages_dedupe;
+CREATE INDEX IF NOT EXISTS idx_sms_messages_dedupe_key ON sms_messages (dedupe_key);
@@ -0,0 +1,19 @@
+-- A reminder is claimed by inserting its message row before it is sent,
+-- so each tenant has at most one live row per dedupe key. Earlier failed
+-- attempts stay in the log with their key cleared, as retries now do.
+
+UPDATE sms_messages SET dedupe_key = ''
+WHERE id IN (
+    SELECT id FROM (
+        SELECT id, row_number() OVER (
+            PARTITION BY tenant_id, dedupe_key
+            ORDER BY status <> 'failed' DESC, created_at DESC
+        ) AS n
+        FROM sms_messages
+        WHERE dedupe_key <> ''
+    ) attempts
+    WHERE n > 1
+);
+
+DROP INDEX IF EXISTS idx_sms_messages_dedupe_key;
+CREATE UNIQUE INDEX idx_sms_messages_dedupe ON sms_messages (tenant_id, dedupe_key) WHERE dedupe_key <> '';
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_charges_tenant_id;
+DROP INDEX IF EXISTS idx_charges_period;
+CREATE UNIQUE INDEX idx_charges_period ON charges (tenant_id, period, charge_type_id, source_type, source_id);
@@ -0,0 +1,30 @@
+-- A tenant owes each charge type at most once a period, whatever posted
+-- it: a hand-entered gas charge and a shared gas bill for the same month
+-- would otherwise both be owed. Waived charges no longer count, and
+-- maintenance charges are billed per ticket, so several can fall in a
+-- month. Existing duplicates are reported rather than merged: waive or
+-- delete the extra charges first.
+
+DO $$
+DECLARE
+    summary text;
+BEGIN
+    SELECT string_agg(format('tenant %s, charge type %s, %s: %s charges', tenant_id, charge_type_id, period, n), ', ')
+    INTO summary
+    FROM (
+        SELECT tenant_id, charge_type_id, period, count(*) AS n
+        FROM charges
+        WHERE source_type <> 'maintenance_ticket' AND waived_at IS NULL
+        GROUP BY tenant_id, charge_type_id, period
+        HAVING count(*) > 1
+        ORDER BY tenant_id, charge_type_id, period
+    ) duplicates;
+
+    IF summary IS NOT NULL THEN
+        RAISE EXCEPTION 'duplicate charges must be fixed first: %', summary;
+    END IF;
+END $$;
+
+DROP INDEX IF EXISTS idx_charges_period;
+CREATE UNIQUE INDEX idx_charges_period ON charges (tenant_id, period, charge_type_id) WHERE source_type <> 'maintenance_ticket' AND waived_at IS NULL;
+CREATE INDEX idx_charges_tenant_id ON charges (tenant_id);
@@ -0,0 +1,60 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"rented-backend/money"
+	"strconv"
+)
+
+// flushEvery is how many rows the CSV writer buffers before sending them on.
+const flushEvery = 200
+
+type csvWriter struct {
+	w    *csv.Writer
+	rows int
+}
+
+func newCSVWriter(w io.Writer) *csvWriter {
+	// A byte order mark so Excel opens UTF-8 (e.g. Bangla) text correctly
+	_, _ = w.Write([]byte("\xEF\xBB\xBF"))
+	return &csvWriter{w: csv.NewWriter(w)}
+}
+
+func (c *csvWriter) WriteHeader(headers []string) error {
+	return c.w.Write(headers)
+}
+
+func (c *csvWriter) WriteRow(cells []any) error {
+	record := make([]string, len(cells))
+	for i, cell := range cells {
+		switch v := cell.(type) {
+		case string:
+			record[i] = v
+		case money.Amount:
+			record[i] = v.String()
+		case float64:
+			record[i] = strconv.FormatFloat(v, 'f', 2, 64)
+		case int:
+			record[i] = strconv.Itoa(v)
+		case nil:
+		default:
+			record[i] = fmt.Sprint(v)
+		}
+	}
+	if err := c.w.Write(record); err != nil {
+		return err
+	}
+	c.rows++
+	if c.rows%flushEvery == 0 {
+		c.w.Flush()
+		return c.w.Error()
+	}
+	return nil
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
@@ -0,0 +1,44 @@
+// Package export writes tabular data as CSV or XLSX, row by row, so large
+// exports are streamed to the client instead of built in memory.
+package export
+
+import (
+	"fmt"
+	"io"
+)
+
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+// Writer receives the header row first, then one row per record. Cell
+// values are strings, money.Amount, float64 (numbers; two decimals in CSV)
+// or ints.
+// Close must be called to finish the file.
+type Writer interface {
+	WriteHeader(headers []string) error
+	WriteRow(cells []any) error
+	Close() error
+}
+
+// New returns a writer for format writing to w. sheet names the XLSX
+// worksheet and is ignored for CSV.
+func New(format string, w io.Writer, sheet string) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w), nil
+	case FormatXLSX:
+		return newXLSXWriter(w, sheet)
+	default:
+		return nil, fmt.Errorf("format must be csv or xlsx")
+	}
+}
+
+// ContentType is the MIME type of files in format.
+func ContentType(format string) string {
+	if format == FormatXLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
@@ -0,0 +1,122 @@
+package export
+
+import (
+	"rented-backend/models"
+	"strings"
+)
+
+// labels holds column headers and the few translated cell values per
+// language, keyed by column or value key.
+var labels = map[string]map[string]string{
+	models.LanguageEnglish: {
+		"name":           "Name",
+		"phone":          "Phone",
+		"house":          "House",
+		"flat":           "Flat",
+		"members":        "Members",
+		"nid_number":     "NID number",
+		"status":         "Status",
+		"join_date":      "Join date",
+		"leave_date":     "Leave date",
+		"lease_end_date": "Lease end date",
+		"advance_amount": "Advance",
+		"advance_held":   "Advance held",
+		"total_paid":     "Total paid",
+		"due_amount":     "Due",
+		"payment_date":   "Payment date",
+		"tenant":         "Tenant",
+		"period":         "Period",
+		"amount":         "Amount",
+		"method":         "Method",
+		"transaction_id": "Transaction ID",
+		"type":           "Type",
+		"group":          "Name",
+		"days_0_30":      "0-30 days",
+		"days_31_60":     "31-60 days",
+		"days_61_90":     "61-90 days",
+		"days_90_plus":   "90+ days",
+		"total":          "Total",
+		"oldest_days":    "Oldest (days)",
+		"date":           "Date",
+		"category":       "Category",
+		"vendor":         "Vendor",
+		"description":    "Description",
+		"recurrence":     "Recurrence",
+		"active":         "Active",
+		"inactive":       "Inactive",
+		"advance":        "Advance",
+		"rent":           "Rent",
+	},
+	models.LanguageBangla: {
+		"name":           "নাম",
+		"phone":          "ফোন",
+		"house":          "বাড়ি",
+		"flat":           "ফ্ল্যাট",
+		"members":        "সদস্য",
+		"nid_number":     "এনআইডি নম্বর",
+		"status":         "অবস্থা",
+		"join_date":      "ওঠার তারিখ",
+		"leave_date":     "ছাড়ার তারিখ",
+		"lease_end_date": "চুক্তি শেষের তারিখ",
+		"advance_amount": "অগ্রিম",
+		"advance_held":   "জমা অগ্রিম",
+		"total_paid":     "মোট পরিশোধ",
+		"due_amount":     "বকেয়া",
+		"payment_date":   "পরিশোধের তারিখ",
+		"tenant":         "ভাড়াটিয়া",
+		"period":         "মাস",
+		"amount":         "পরিমাণ",
+		"method":         "মাধ্যম",
+		"transaction_id": "লেনদেন আইডি",
+		"type":           "ধরন",
+		"group":          "নাম",
+		"days_0_30":      "০-৩০ দিন",
+		"days_31_60":     "৩১-৬০ দিন",
+		"days_61_90":     "৬১-৯০ দিন",
+		"days_90_plus":   "৯০+ দিন",
+		"total":          "মোট",
+		"oldest_days":    "সবচেয়ে পুরোনো (দিন)",
+		"date":           "তারিখ",
+		"category":       "খাত",
+		"vendor":         "সরবরাহকারী",
+		"description":    "বিবরণ",
+		"recurrence":     "পুনরাবৃত্তি",
+		"active":         "সক্রিয়",
+		"inactive":       "নিষ্ক্রিয়",
+		"advance":        "অগ্রিম",
+		"rent":           "ভাড়া",
+	},
+}
+
+// Label returns the label for key in lang, falling back to English for
+// unknown languages and to the key itself.
+func Label(lang, key string) string {
+	if label, ok := labels[lang][key]; ok {
+		return label
+	}
+	if label, ok := labels[models.LanguageEnglish][key]; ok {
+		return label
+	}
+	return key
+}
+
+// Headers returns the labels for the column keys in lang.
+func Headers(lang string, keys []string) []string {
+	headers := make([]string, len(keys))
+	for i, key := range keys {
+		headers[i] = Label(lang, key)
+	}
+	return headers
+}
+
+// Language picks bn or en from an explicit lang value, else from an
+// Accept-Language header, defaulting to English.
+func Language(lang, acceptLanguage string) string {
+	if lang == models.LanguageBangla || lang == models.LanguageEnglish {
+		return lang
+	}
+	if strings.HasPrefix(strings.ToLower(strings.TrimSpace(acceptLanguage)), models.LanguageBangla) {
+		return models.LanguageBangla
+	}
+	return models.LanguageEnglish
+}
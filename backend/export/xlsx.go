@@ -0,0 +1,82 @@
+package export
+
+import (
+	"io"
+	"rented-backend/money"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// xlsxWriter uses excelize's stream writer, which spills rows to a temporary
+// file rather than holding the sheet in memory.
+type xlsxWriter struct {
+	out    io.Writer
+	file   *excelize.File
+	stream *excelize.StreamWriter
+	row    int
+	bold   int
+	amount int
+}
+
+func newXLSXWriter(w io.Writer, sheet string) (*xlsxWriter, error) {
+	f := excelize.NewFile()
+	if err := f.SetSheetName("Sheet1", sheet); err != nil {
+		return nil, err
+	}
+	stream, err := f.NewStreamWriter(sheet)
+	if err != nil {
+		return nil, err
+	}
+	bold, err := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	if err != nil {
+		return nil, err
+	}
+	// Built-in number format 4 is #,##0.00
+	amount, err := f.NewStyle(&excelize.Style{NumFmt: 4})
+	if err != nil {
+		return nil, err
+	}
+	return &xlsxWriter{out: w, file: f, stream: stream, row: 1, bold: bold, amount: amount}, nil
+}
+
+func (x *xlsxWriter) WriteHeader(headers []string) error {
+	if err := x.stream.SetColWidth(1, len(headers), 18); err != nil {
+		return err
+	}
+	cells := make([]any, len(headers))
+	for i, h := range headers {
+		cells[i] = excelize.Cell{StyleID: x.bold, Value: h}
+	}
+	if err := x.stream.SetPanes(&excelize.Panes{Freeze: true, YSplit: 1, TopLeftCell: "A2", ActivePane: "bottomLeft"}); err != nil {
+		return err
+	}
+	return x.writeRow(cells)
+}
+
+// WriteRow writes amounts as numeric cells with two decimals.
+func (x *xlsxWriter) WriteRow(cells []any) error {
+	for i, cell := range cells {
+		if v, ok := cell.(money.Amount); ok {
+			cells[i] = excelize.Cell{StyleID: x.amount, Value: v.Float64()}
+		}
+	}
+	return x.writeRow(cells)
+}
+
+func (x *xlsxWriter) writeRow(cells []any) error {
+	cell, err := excelize.CoordinatesToCellName(1, x.row)
+	if err != nil {
+		return err
+	}
+	x.row++
+	return x.stream.SetRow(cell, cells)
+}
+
+func (x *xlsxWriter) Close() error {
+	defer x.file.Close()
+	if err := x.stream.Flush(); err != nil {
+		return err
+	}
+	_, err := x.file.WriteTo(x.out)
+	return err
+}
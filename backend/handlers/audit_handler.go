@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"net/http"
+	"rented-backend/logger"
+	"rented-backend/repository"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type AuditHandler struct {
+	repo repository.AuditRepository
+}
+
+func NewAuditHandler(repo repository.AuditRepository) *AuditHandler {
+	return &AuditHandler{repo: repo}
+}
+
+// GetAuditLogs lists audit events for the caller's account.
+// Query params: entity, entity_id, actor_id, from, to (YYYY-MM-DD, inclusive), limit.
+func (h *AuditHandler) GetAuditLogs(c *gin.Context) {
+	userIDStr, _ := c.Get("userID")
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		logger.Log.Error("Failed to parse userID from context in GetAuditLogs", "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	filter := repository.AuditFilter{
+		Entity:   c.Query("entity"),
+		EntityID: c.Query("entity_id"),
+	}
+
+	if actorStr := c.Query("actor_id"); actorStr != "" {
+		actorID, err := uuid.Parse(actorStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid actor_id"})
+			return
+		}
+		filter.ActorID = &actorID
+	}
+
+	if fromStr := c.Query("from"); fromStr != "" {
+		from, err := time.Parse("2006-01-02", fromStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from date, expected YYYY-MM-DD"})
+			return
+		}
+		filter.From = &from
+	}
+
+	if toStr := c.Query("to"); toStr != "" {
+		to, err := time.Parse("2006-01-02", toStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to date, expected YYYY-MM-DD"})
+			return
+		}
+		// Make the end date inclusive
+		to = to.AddDate(0, 0, 1)
+		filter.To = &to
+	}
+
+	if limitStr := c.Query("limit"); limitStr != "" {
+		filter.Limit, _ = strconv.Atoi(limitStr)
+	}
+
+	logs, err := h.repo.List(userID, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch audit logs"})
+		return
+	}
+
+	c.JSON(http.StatusOK, logs)
+}
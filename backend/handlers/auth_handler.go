@@ -1,11 +1,14 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"rented-backend/logger"
 	"rented-backend/models"
+	"rented-backend/money"
 	"rented-backend/repository"
 	"time"
 
@@ -14,6 +17,7 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
 type AuthHandler struct {
@@ -142,10 +146,18 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	c.JSON(http.StatusOK, models.AuthResponse{User: *user, Token: token})
 }
 
+// generateToken signs a landlord token. sub is the account the token works
+// on and act the person signed in, which differ for a caretaker.
 func (h *AuthHandler) generateToken(user models.User) (string, error) {
+	accountID := user.ID
+	if user.OwnerID != nil {
+		accountID = *user.OwnerID
+	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"sub":   user.ID.String(),
+		"sub":   accountID.String(),
+		"act":   user.ID.String(),
 		"email": user.Email,
+		"scope": models.TokenScopeLandlord,
 		"exp":   time.Now().Add(time.Hour * 72).Unix(),
 	})
 
@@ -180,3 +192,147 @@ func (h *AuthHandler) GetProfile(c *gin.Context) {
 
 	c.JSON(http.StatusOK, user)
 }
+
+// UpdateSettings changes the account's time zone, currency and locale.
+func (h *AuthHandler) UpdateSettings(c *gin.Context) {
+	userIDStr, _ := c.Get("userID")
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		logger.Log.Error("Failed to parse userID from context in UpdateSettings", "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	if !ownerOnly(c) {
+		return
+	}
+
+	var req models.AccountSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	// Local would mean the server's zone, not the landlord's
+	if _, err := time.LoadLocation(req.Timezone); err != nil || req.Timezone == "Local" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "timezone must be an IANA time zone, e.g. Asia/Dhaka"})
+		return
+	}
+
+	user, err := h.userRepo.GetByID(userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+	user.Timezone = req.Timezone
+	user.Currency = money.Currency(req.Currency)
+	user.Locale = req.Locale
+
+	if err := h.userRepo.UpdateSettings(user); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save account settings"})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// AddCaretaker gives someone their own login to the account, e.g. a
+// caretaker who collects rent. Payments they record notify the owner.
+func (h *AuthHandler) AddCaretaker(c *gin.Context) {
+	userIDStr, _ := c.Get("userID")
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		logger.Log.Error("Failed to parse userID from context in AddCaretaker", "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+	if !ownerOnly(c) {
+		return
+	}
+
+	var req models.RegisterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to hash password"})
+		return
+	}
+
+	caretaker := models.User{
+		OwnerID:  &userID,
+		Email:    req.Email,
+		Password: string(hashedPassword),
+		Name:     req.Name,
+	}
+	if err := h.userRepo.CreateCaretaker(&caretaker); errors.Is(err, gorm.ErrDuplicatedKey) {
+		c.JSON(http.StatusConflict, gin.H{"error": "email is already registered"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add caretaker"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, caretaker)
+}
+
+func (h *AuthHandler) GetCaretakers(c *gin.Context) {
+	userIDStr, _ := c.Get("userID")
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		logger.Log.Error("Failed to parse userID from context in GetCaretakers", "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	caretakers, err := h.userRepo.GetCaretakers(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch caretakers"})
+		return
+	}
+
+	c.JSON(http.StatusOK, caretakers)
+}
+
+// RemoveCaretaker deletes a caretaker's login. Their tokens stop working
+// at once.
+func (h *AuthHandler) RemoveCaretaker(c *gin.Context) {
+	userIDStr, _ := c.Get("userID")
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		logger.Log.Error("Failed to parse userID from context in RemoveCaretaker", "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+	if !ownerOnly(c) {
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	if err := h.userRepo.DeleteCaretaker(id, userID); errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "caretaker not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove caretaker"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Caretaker removed"})
+}
+
+// ownerOnly rejects requests made by a caretaker and reports whether the
+// handler may go on.
+func ownerOnly(c *gin.Context) bool {
+	if _, ok := c.Get("caretakerID"); ok {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only the account owner can do this"})
+		return false
+	}
+	return true
+}
@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"rented-backend/logger"
+	"rented-backend/models"
+	"rented-backend/repository"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ChargeHandler struct {
+	repo           repository.ChargeRepository
+	tenantRepo     repository.TenantRepository
+	chargeTypeRepo repository.ChargeTypeRepository
+}
+
+func NewChargeHandler(repo repository.ChargeRepository, tenantRepo repository.TenantRepository, chargeTypeRepo repository.ChargeTypeRepository) *ChargeHandler {
+	return &ChargeHandler{repo: repo, tenantRepo: tenantRepo, chargeTypeRepo: chargeTypeRepo}
+}
+
+// CreateCharge posts a charge by hand, e.g. a one-off garage fee.
+func (h *ChargeHandler) CreateCharge(c *gin.Context) {
+	userIDStr, _ := c.Get("userID")
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		logger.Log.Error("Failed to parse userID from context in CreateCharge", "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	var req models.ChargeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Period.IsZero() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "period is required"})
+		return
+	}
+
+	tenant, err := h.tenantRepo.GetByID(req.TenantID, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "tenant not found"})
+		return
+	}
+
+	chargeType, err := h.chargeTypeRepo.GetByID(req.ChargeTypeID, userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid charge_type_id"})
+		return
+	}
+
+	charge := models.Charge{
+		ID:           uuid.New(),
+		TenantID:     tenant.ID,
+		FlatID:       tenant.FlatID,
+		Period:       req.Period,
+		Kind:         chargeType.Code,
+		ChargeTypeID: chargeType.ID,
+		Description:  req.Description,
+		Amount:       req.Amount,
+		SourceType:   models.ChargeSourceManual,
+	}
+	if charge.Description == "" {
+		charge.Description = chargeType.Name
+	}
+
+	if err := h.repo.Create(c.Request.Context(), &charge); errors.Is(err, gorm.ErrDuplicatedKey) {
+		c.JSON(http.StatusConflict, gin.H{"error": "the tenant already has a charge of this type for the period"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create charge"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, charge)
+}
+
+// DeleteCharge removes a charge posted by hand. Charges posted from meter
+// readings or shared bills are owned by their source.
+func (h *ChargeHandler) DeleteCharge(c *gin.Context) {
+	userIDStr, _ := c.Get("userID")
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		logger.Log.Error("Failed to parse userID from context in DeleteCharge", "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	charge, err := h.repo.GetByID(id, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "charge not found"})
+		return
+	}
+	if charge.SourceType != models.ChargeSourceManual {
+		c.JSON(http.StatusConflict, gin.H{"error": "only manually posted charges can be deleted"})
+		return
+	}
+
+	if err := h.repo.Delete(c.Request.Context(), charge); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+func (h *ChargeHandler) GetTenantCharges(c *gin.Context) {
+	tenantID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid tenant_id"})
+		return
+	}
+
+	userIDStr, _ := c.Get("userID")
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		logger.Log.Error("Failed to parse userID from context in GetTenantCharges", "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	if _, err := h.tenantRepo.GetByID(tenantID, userID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "tenant not found"})
+		return
+	}
+
+	charges, err := h.repo.GetByTenantID(tenantID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, charges)
+}
+
+type WaiveChargeRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+// WaiveCharge writes a charge off so it no longer counts towards dues. The
+// charge is kept for the record, along with who waived it and why.
+func (h *ChargeHandler) WaiveCharge(c *gin.Context) {
+	userIDStr, _ := c.Get("userID")
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		logger.Log.Error("Failed to parse userID from context in WaiveCharge", "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req WaiveChargeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	charge, err := h.repo.GetByID(id, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "charge not found"})
+		return
+	}
+	if charge.IsWaived() {
+		c.JSON(http.StatusConflict, gin.H{"error": "charge is already waived"})
+		return
+	}
+
+	now := time.Now()
+	charge.WaivedAt = &now
+	charge.WaivedBy = &userID
+	charge.WaiveReason = req.Reason
+
+	if err := h.repo.Update(c.Request.Context(), charge); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to waive charge"})
+		return
+	}
+
+	c.JSON(http.StatusOK, charge)
+}
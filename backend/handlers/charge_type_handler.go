@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"net/http"
+	"rented-backend/logger"
+	"rented-backend/models"
+	"rented-backend/repository"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type ChargeTypeHandler struct {
+	repo repository.ChargeTypeRepository
+}
+
+func NewChargeTypeHandler(repo repository.ChargeTypeRepository) *ChargeTypeHandler {
+	return &ChargeTypeHandler{repo: repo}
+}
+
+func (h *ChargeTypeHandler) GetChargeTypes(c *gin.Context) {
+	userIDStr, _ := c.Get("userID")
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		logger.Log.Error("Failed to parse userID from context in GetChargeTypes", "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	chargeTypes, err := h.repo.GetAll(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch charge types"})
+		return
+	}
+
+	c.JSON(http.StatusOK, chargeTypes)
+}
+
+func (h *ChargeTypeHandler) CreateChargeType(c *gin.Context) {
+	userIDStr, _ := c.Get("userID")
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		logger.Log.Error("Failed to parse userID from context in CreateChargeType", "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	var chargeType models.ChargeType
+	if err := c.ShouldBindJSON(&chargeType); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	chargeType.ID = uuid.New()
+	chargeType.UserID = userID
+	chargeType.Code = strings.ToLower(strings.TrimSpace(chargeType.Code))
+	chargeType.IsActive = true
+
+	if err := h.repo.Create(c.Request.Context(), &chargeType); err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Failed to create charge type, the code may already be in use"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, chargeType)
+}
+
+// UpdateChargeType changes everything but the code, which charges and
+// payment items refer to.
+func (h *ChargeTypeHandler) UpdateChargeType(c *gin.Context) {
+	userIDStr, _ := c.Get("userID")
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		logger.Log.Error("Failed to parse userID from context in UpdateChargeType", "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var input struct {
+		Name        string  `json:"name" binding:"required"`
+		Recurrence  string  `json:"recurrence" binding:"required,oneof=recurring one_off"`
+		Calculation string  `json:"calculation" binding:"required,oneof=fixed metered"`
+		Taxable     bool    `json:"taxable"`
+		TaxRate     float64 `json:"tax_rate" binding:"min=0"`
+		IsActive    bool    `json:"is_active"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	chargeType, err := h.repo.GetByID(id, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "charge type not found"})
+		return
+	}
+
+	chargeType.Name = input.Name
+	chargeType.Recurrence = input.Recurrence
+	chargeType.Calculation = input.Calculation
+	chargeType.Taxable = input.Taxable
+	chargeType.TaxRate = input.TaxRate
+	chargeType.IsActive = input.IsActive
+
+	if err := h.repo.Update(c.Request.Context(), chargeType); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update charge type"})
+		return
+	}
+
+	c.JSON(http.StatusOK, chargeType)
+}
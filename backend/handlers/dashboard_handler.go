@@ -1,51 +1,133 @@
 package handlers
 
 import (
+	"fmt"
 	"net/http"
+	"rented-backend/logger"
 	"rented-backend/repository"
+	"rented-backend/service"
+	"sort"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
 
 type DashboardHandler struct {
-	repo repository.RentRepository
+	houseRepo  repository.HouseRepository
+	dueService *service.DueService
 }
 
-func NewDashboardHandler(repo repository.RentRepository) *DashboardHandler {
-	return &DashboardHandler{repo: repo}
+func NewDashboardHandler(houseRepo repository.HouseRepository, dueService *service.DueService) *DashboardHandler {
+	return &DashboardHandler{houseRepo: houseRepo, dueService: dueService}
 }
 
 func (h *DashboardHandler) GetStats(c *gin.Context) {
-	// Assuming UserID is available via middleware (e.g., set in context)
-	// For now, if no auth middleware sets it, we might accept it as a param for testing or default
-	// But standard pattern is c.MustGet("userID")
-	// Let's assume passed as query param for simplicity or header if auth is not fully hooked up context-wise
-	// Or grab it from the header "X-User-ID" if using that pattern, or query.
-	// Reusing the pattern from other handlers? They usually take UserID as param or from context.
-	// Looking at previous code, TenantHandler used c.MustGet("userID")? Let's check or be safe.
-	// Actually, previous code (e.g. TenantHandler) took UserID as parameter in some methods?
-	// TenantRepository.GetAll takes userID.
-	// Let's assume client sends user_id as query param for this MVP "GetDashboardStats?user_id=..."
-	// Or better, standard Auth middleware.
-
-	userIDStr := c.Query("user_id")
-	if userIDStr == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "user_id is required"})
+	userIDStr, _ := c.Get("userID")
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		logger.Log.Error("Failed to parse userID from context in GetStats", "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	today, err := h.dueService.Today(userID, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	userID, err := uuid.Parse(userIDStr)
+	filter, err := parseDashboardFilter(c, today)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user_id"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := checkFilterHouse(h.houseRepo, userID, filter); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
 	}
 
-	stats, err := h.repo.GetDashboardStats(userID)
+	stats, dues, err := h.dueService.DashboardStats(userID, filter, today)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	// Top 5 by amount owed; the aging report has the full list
+	sort.SliceStable(dues.Dues, func(i, j int) bool {
+		return dues.Dues[i].DueAmount > dues.Dues[j].DueAmount
+	})
+	if len(dues.Dues) > 5 {
+		stats.TopDues = dues.Dues[:5]
+	} else {
+		stats.TopDues = dues.Dues
+	}
+
 	c.JSON(http.StatusOK, stats)
 }
+
+// parseDashboardFilter reads the period and house from the query string.
+// period is month (default), quarter, year or custom; date (YYYY-MM-DD,
+// default today) picks which month, quarter or year. A custom period takes
+// from and to as YYYY-MM, both inclusive.
+func parseDashboardFilter(c *gin.Context, today time.Time) (repository.DashboardFilter, error) {
+	filter := repository.DashboardFilter{}
+
+	if houseStr := c.Query("house_id"); houseStr != "" {
+		houseID, err := uuid.Parse(houseStr)
+		if err != nil {
+			return filter, fmt.Errorf("invalid house_id")
+		}
+		filter.HouseID = &houseID
+	}
+
+	anchor := today
+	if dateStr := c.Query("date"); dateStr != "" {
+		date, err := time.Parse("2006-01-02", dateStr)
+		if err != nil {
+			return filter, fmt.Errorf("invalid date, expected YYYY-MM-DD")
+		}
+		anchor = date
+	}
+	month := time.Date(anchor.Year(), anchor.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	switch c.DefaultQuery("period", "month") {
+	case "month":
+		filter.From, filter.To = month, month
+	case "quarter":
+		filter.From = month.AddDate(0, -int(month.Month()-1)%3, 0)
+		filter.To = filter.From.AddDate(0, 2, 0)
+	case "year":
+		filter.From = time.Date(month.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+		filter.To = time.Date(month.Year(), time.December, 1, 0, 0, 0, 0, time.UTC)
+	case "custom":
+		from, err := time.Parse("2006-01", c.Query("from"))
+		if err != nil {
+			return filter, fmt.Errorf("invalid from, expected YYYY-MM")
+		}
+		to, err := time.Parse("2006-01", c.Query("to"))
+		if err != nil {
+			return filter, fmt.Errorf("invalid to, expected YYYY-MM")
+		}
+		if to.Before(from) {
+			return filter, fmt.Errorf("to must not be before from")
+		}
+		filter.From, filter.To = from, to
+	default:
+		return filter, fmt.Errorf("period must be month, quarter, year or custom")
+	}
+
+	return filter, nil
+}
+
+// checkFilterHouse makes sure the filter's house, if any, belongs to the user.
+func checkFilterHouse(houseRepo repository.HouseRepository, userID uuid.UUID, filter repository.DashboardFilter) error {
+	if filter.HouseID == nil {
+		return nil
+	}
+	house, err := houseRepo.GetHouseByID(*filter.HouseID)
+	if err != nil || house.UserID != userID {
+		return fmt.Errorf("house not found")
+	}
+	return nil
+}
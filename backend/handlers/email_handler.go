@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"net/http"
+	"rented-backend/logger"
+	"rented-backend/models"
+	"rented-backend/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type EmailHandler struct {
+	repo repository.EmailRepository
+}
+
+func NewEmailHandler(repo repository.EmailRepository) *EmailHandler {
+	return &EmailHandler{repo: repo}
+}
+
+func (h *EmailHandler) GetPreferences(c *gin.Context) {
+	userIDStr, _ := c.Get("userID")
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		logger.Log.Error("Failed to parse userID from context in GetEmailPreferences", "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	prefs, err := h.repo.GetPreferences(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch email preferences"})
+		return
+	}
+
+	c.JSON(http.StatusOK, prefs)
+}
+
+func (h *EmailHandler) UpdatePreferences(c *gin.Context) {
+	userIDStr, _ := c.Get("userID")
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		logger.Log.Error("Failed to parse userID from context in UpdateEmailPreferences", "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	var req models.EmailPreferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	prefs, err := h.repo.GetPreferences(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch email preferences"})
+		return
+	}
+	prefs.MonthlySummary = *req.MonthlySummary
+	if err := h.repo.SavePreferences(prefs); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save email preferences"})
+		return
+	}
+
+	c.JSON(http.StatusOK, prefs)
+}
+
+// Unsubscribe turns off the monthly summary for the token in the email's
+// link. Public: GET from the link, POST from one-click mail clients.
+func (h *EmailHandler) Unsubscribe(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		c.String(http.StatusBadRequest, "This unsubscribe link is incomplete.")
+		return
+	}
+
+	prefs, err := h.repo.GetPreferencesByToken(token)
+	if err != nil {
+		c.String(http.StatusNotFound, "This unsubscribe link is not valid.")
+		return
+	}
+	prefs.MonthlySummary = false
+	if err := h.repo.SavePreferences(prefs); err != nil {
+		c.String(http.StatusInternalServerError, "Something went wrong, please try again.")
+		return
+	}
+
+	c.String(http.StatusOK, "You will no longer receive monthly summary emails. You can turn them back on in the app's settings.")
+}
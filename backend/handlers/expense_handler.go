@@ -0,0 +1,312 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"rented-backend/logger"
+	"rented-backend/models"
+	"rented-backend/repository"
+	"rented-backend/service"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type ExpenseHandler struct {
+	repo       repository.ExpenseRepository
+	houseRepo  repository.HouseRepository
+	vendorRepo repository.VendorRepository
+	s3Service  *service.S3Service
+}
+
+func NewExpenseHandler(repo repository.ExpenseRepository, houseRepo repository.HouseRepository, vendorRepo repository.VendorRepository, s3Service *service.S3Service) *ExpenseHandler {
+	return &ExpenseHandler{repo: repo, houseRepo: houseRepo, vendorRepo: vendorRepo, s3Service: s3Service}
+}
+
+func (h *ExpenseHandler) CreateExpense(c *gin.Context) {
+	userIDStr, _ := c.Get("userID")
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		logger.Log.Error("Failed to parse userID from context in CreateExpense", "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	var req models.ExpenseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.checkLocation(userID, req.HouseID, req.FlatID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.checkVendor(userID, &req); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	expense := models.Expense{
+		ID:          uuid.New(),
+		UserID:      userID,
+		HouseID:     req.HouseID,
+		FlatID:      req.FlatID,
+		Category:    req.Category,
+		VendorID:    req.VendorID,
+		Vendor:      req.Vendor,
+		Amount:      req.Amount,
+		Date:        req.Date,
+		Description: req.Description,
+		Recurrence:  req.Recurrence,
+	}
+	if expense.Recurrence == "" {
+		expense.Recurrence = models.ExpenseRepeatNone
+	}
+	if expense.Recurrence != models.ExpenseRepeatNone {
+		next := expense.NextOccurrence(expense.Date)
+		expense.NextDate = &next
+	}
+
+	if err := h.repo.Create(c.Request.Context(), &expense); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create expense"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, expense)
+}
+
+// GetExpenses lists expenses, newest first.
+// Query params: house_id, flat_id, category, from, to (YYYY-MM-DD, inclusive).
+func (h *ExpenseHandler) GetExpenses(c *gin.Context) {
+	userIDStr, _ := c.Get("userID")
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		logger.Log.Error("Failed to parse userID from context in GetExpenses", "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	filter := repository.ExpenseFilter{Category: c.Query("category")}
+
+	if houseStr := c.Query("house_id"); houseStr != "" {
+		houseID, err := uuid.Parse(houseStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid house_id"})
+			return
+		}
+		filter.HouseID = &houseID
+	}
+
+	if flatStr := c.Query("flat_id"); flatStr != "" {
+		flatID, err := uuid.Parse(flatStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid flat_id"})
+			return
+		}
+		filter.FlatID = &flatID
+	}
+
+	if fromStr := c.Query("from"); fromStr != "" {
+		from, err := time.Parse("2006-01-02", fromStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from date, expected YYYY-MM-DD"})
+			return
+		}
+		filter.From = &from
+	}
+
+	if toStr := c.Query("to"); toStr != "" {
+		to, err := time.Parse("2006-01-02", toStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to date, expected YYYY-MM-DD"})
+			return
+		}
+		// Make the end date inclusive
+		to = to.AddDate(0, 0, 1)
+		filter.To = &to
+	}
+
+	expenses, err := h.repo.List(userID, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch expenses"})
+		return
+	}
+
+	c.JSON(http.StatusOK, expenses)
+}
+
+func (h *ExpenseHandler) UpdateExpense(c *gin.Context) {
+	userIDStr, _ := c.Get("userID")
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		logger.Log.Error("Failed to parse userID from context in UpdateExpense", "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req models.ExpenseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	expense, err := h.repo.GetByID(id, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "expense not found"})
+		return
+	}
+	if err := h.checkLocation(userID, req.HouseID, req.FlatID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.checkVendor(userID, &req); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	expense.HouseID = req.HouseID
+	expense.FlatID = req.FlatID
+	expense.Category = req.Category
+	expense.VendorID = req.VendorID
+	expense.Vendor = req.Vendor
+	expense.Amount = req.Amount
+	expense.Date = req.Date
+	expense.Description = req.Description
+
+	// Changing the schedule restarts it from the expense's date
+	recurrence := req.Recurrence
+	if recurrence == "" {
+		recurrence = models.ExpenseRepeatNone
+	}
+	if recurrence != expense.Recurrence {
+		expense.Recurrence = recurrence
+		expense.NextDate = nil
+		if recurrence != models.ExpenseRepeatNone {
+			next := expense.NextOccurrence(expense.Date)
+			expense.NextDate = &next
+		}
+	}
+
+	if err := h.repo.Update(c.Request.Context(), expense); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update expense"})
+		return
+	}
+
+	c.JSON(http.StatusOK, expense)
+}
+
+func (h *ExpenseHandler) DeleteExpense(c *gin.Context) {
+	userIDStr, _ := c.Get("userID")
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		logger.Log.Error("Failed to parse userID from context in DeleteExpense", "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	expense, err := h.repo.GetByID(id, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "expense not found"})
+		return
+	}
+
+	if err := h.repo.Delete(c.Request.Context(), expense); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// UploadReceipt attaches a receipt image to an expense. Multipart field: receipt.
+func (h *ExpenseHandler) UploadReceipt(c *gin.Context) {
+	userIDStr, _ := c.Get("userID")
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		logger.Log.Error("Failed to parse userID from context in UploadReceipt", "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	expense, err := h.repo.GetByID(id, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "expense not found"})
+		return
+	}
+
+	file, err := c.FormFile("receipt")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "receipt file is required"})
+		return
+	}
+	if h.s3Service == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "file uploads are not configured"})
+		return
+	}
+
+	url, err := h.s3Service.UploadFile(file, "receipts", fmt.Sprintf("%s_%d", expense.ID, time.Now().Unix()))
+	if err != nil {
+		logger.Log.Error("Failed to upload receipt", "expenseID", expense.ID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload receipt"})
+		return
+	}
+
+	expense.ReceiptURL = url
+	if err := h.repo.Update(c.Request.Context(), expense); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update expense"})
+		return
+	}
+
+	c.JSON(http.StatusOK, expense)
+}
+
+// checkLocation makes sure the house, and the flat if given, belong to the user.
+func (h *ExpenseHandler) checkLocation(userID, houseID uuid.UUID, flatID *uuid.UUID) error {
+	house, err := h.houseRepo.GetHouseByID(houseID)
+	if err != nil || house.UserID != userID {
+		return fmt.Errorf("house not found")
+	}
+	if flatID != nil {
+		flat, err := h.houseRepo.GetFlatByID(*flatID)
+		if err != nil || flat.HouseID != houseID {
+			return fmt.Errorf("flat not found")
+		}
+	}
+	return nil
+}
+
+// checkVendor makes sure a linked vendor belongs to the user and fills in
+// the vendor name if none was given.
+func (h *ExpenseHandler) checkVendor(userID uuid.UUID, req *models.ExpenseRequest) error {
+	if req.VendorID == nil {
+		return nil
+	}
+	vendor, err := h.vendorRepo.GetByID(*req.VendorID, userID)
+	if err != nil {
+		return fmt.Errorf("vendor not found")
+	}
+	if req.Vendor == "" {
+		req.Vendor = vendor.Name
+	}
+	return nil
+}
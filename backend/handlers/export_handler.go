@@ -0,0 +1,340 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"rented-backend/billing"
+	"rented-backend/export"
+	"rented-backend/logger"
+	"rented-backend/models"
+	"rented-backend/repository"
+	"rented-backend/service"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const exportDateFormat = "2006-01-02"
+
+// ExportHandler serves spreadsheet exports. Every endpoint takes format
+// (csv or xlsx; default csv) and lang (bn or en; default the account's
+// locale) for the column headers. Default ranges end on the account's
+// current date.
+type ExportHandler struct {
+	tenantRepo  repository.TenantRepository
+	houseRepo   repository.HouseRepository
+	rentRepo    repository.RentRepository
+	expenseRepo repository.ExpenseRepository
+	userRepo    repository.UserRepository
+	dueService  *service.DueService
+}
+
+func NewExportHandler(tenantRepo repository.TenantRepository, houseRepo repository.HouseRepository, rentRepo repository.RentRepository, expenseRepo repository.ExpenseRepository, userRepo repository.UserRepository, dueService *service.DueService) *ExportHandler {
+	return &ExportHandler{tenantRepo: tenantRepo, houseRepo: houseRepo, rentRepo: rentRepo, expenseRepo: expenseRepo, userRepo: userRepo, dueService: dueService}
+}
+
+// ExportTenants exports the tenant list with the fields of TenantResponse.
+func (h *ExportHandler) ExportTenants(c *gin.Context) {
+	userIDStr, _ := c.Get("userID")
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		logger.Log.Error("Failed to parse userID from context in ExportTenants", "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+	opts, ok := h.exportOptions(c, userID)
+	if !ok {
+		return
+	}
+
+	tenants, err := h.tenantRepo.GetAll(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	dues, err := h.dueService.AllTenantDues(tenants, opts.today)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	w, ok := startExport(c, opts, "tenants")
+	if !ok {
+		return
+	}
+	columns := []string{"name", "phone", "house", "flat", "members", "nid_number", "status", "join_date", "leave_date", "lease_end_date", "advance_amount", "advance_held", "total_paid", "due_amount"}
+	err = writeExport(w, opts.lang, columns, func(row func([]any) error) error {
+		for _, t := range tenantResponses(h.houseRepo, tenants, dues) {
+			status := "inactive"
+			if t.IsActive {
+				status = "active"
+			}
+			err := row([]any{
+				t.Name, t.Phone, t.HouseName, t.FlatNumber, t.Members, t.NIDNumber,
+				export.Label(opts.lang, status),
+				t.JoinDate.Format(exportDateFormat), exportDate(t.LeaveDate), exportDate(t.LeaseEndDate),
+				t.AdvanceAmount, t.AdvanceHeld, t.TotalPaid, t.DueAmount,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Log.Error("Failed to export tenants", "userID", userID, "error", err)
+	}
+}
+
+// ExportPayments exports payments across all tenants by payment date.
+// Query params: from and to (YYYY-MM-DD, both inclusive; default the year
+// to date).
+func (h *ExportHandler) ExportPayments(c *gin.Context) {
+	userIDStr, _ := c.Get("userID")
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		logger.Log.Error("Failed to parse userID from context in ExportPayments", "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+	opts, ok := h.exportOptions(c, userID)
+	if !ok {
+		return
+	}
+	from, to, ok := exportRange(c, opts.today)
+	if !ok {
+		return
+	}
+
+	w, ok := startExport(c, opts, "payments")
+	if !ok {
+		return
+	}
+	columns := []string{"payment_date", "tenant", "house", "flat", "period", "type", "amount", "method", "transaction_id"}
+	err = writeExport(w, opts.lang, columns, func(row func([]any) error) error {
+		// Payment dates are timestamps, so the range runs between the
+		// account's local midnights
+		return h.rentRepo.StreamPayments(userID, billing.StartOf(from, opts.loc), billing.StartOf(to, opts.loc), func(p repository.PaymentExportRow) error {
+			kind := "rent"
+			if p.IsAdvance {
+				kind = "advance"
+			}
+			return row([]any{
+				p.PaymentDate.In(opts.loc).Format(exportDateFormat), p.TenantName, p.HouseName, p.FlatNumber,
+				p.Period.String(), export.Label(opts.lang, kind), p.TotalPaid, p.Method, p.TransactionID,
+			})
+		})
+	})
+	if err != nil {
+		logger.Log.Error("Failed to export payments", "userID", userID, "error", err)
+	}
+}
+
+// ExportAging exports the receivables aging report with a totals row.
+// Takes the group_by, sort and order params of GetAging.
+func (h *ExportHandler) ExportAging(c *gin.Context) {
+	userIDStr, _ := c.Get("userID")
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		logger.Log.Error("Failed to parse userID from context in ExportAging", "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+	opts, ok := h.exportOptions(c, userID)
+	if !ok {
+		return
+	}
+
+	groupBy := c.DefaultQuery("group_by", service.AgingByTenant)
+	if groupBy != service.AgingByTenant && groupBy != service.AgingByFlat && groupBy != service.AgingByHouse {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "group_by must be tenant, flat or house"})
+		return
+	}
+
+	houses, err := h.houseRepo.GetUserHouses(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	houseNames := map[uuid.UUID]string{}
+	for _, house := range houses {
+		houseNames[house.ID] = house.Name
+	}
+
+	report, err := h.dueService.Aging(userID, opts.today, groupBy, houseNames)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	service.SortAging(report.Rows, c.DefaultQuery("sort", "total"), c.DefaultQuery("order", "desc") != "asc")
+
+	w, ok := startExport(c, opts, "aging")
+	if !ok {
+		return
+	}
+	columns := []string{"house", "flat", "tenant", "oldest_days", "days_0_30", "days_31_60", "days_61_90", "days_90_plus", "total"}
+	err = writeExport(w, opts.lang, columns, func(row func([]any) error) error {
+		for _, r := range report.Rows {
+			err := row([]any{r.HouseName, r.FlatNumber, r.TenantName, r.OldestDays, r.Days0To30, r.Days31To60, r.Days61To90, r.Days90Plus, r.Total})
+			if err != nil {
+				return err
+			}
+		}
+		t := report.Totals
+		return row([]any{export.Label(opts.lang, "total"), "", "", "", t.Days0To30, t.Days31To60, t.Days61To90, t.Days90Plus, t.Total})
+	})
+	if err != nil {
+		logger.Log.Error("Failed to export aging report", "userID", userID, "error", err)
+	}
+}
+
+// ExportExpenses exports expenses by date. Query params: from and to
+// (YYYY-MM-DD, both inclusive; default the year to date), house_id,
+// category.
+func (h *ExportHandler) ExportExpenses(c *gin.Context) {
+	userIDStr, _ := c.Get("userID")
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		logger.Log.Error("Failed to parse userID from context in ExportExpenses", "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+	opts, ok := h.exportOptions(c, userID)
+	if !ok {
+		return
+	}
+	from, to, ok := exportRange(c, opts.today)
+	if !ok {
+		return
+	}
+
+	filter := repository.ExpenseFilter{From: &from, To: &to, Category: c.Query("category")}
+	if houseStr := c.Query("house_id"); houseStr != "" {
+		houseID, err := uuid.Parse(houseStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid house_id"})
+			return
+		}
+		filter.HouseID = &houseID
+	}
+
+	houses, err := h.houseRepo.GetUserHouses(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	houseNames := map[uuid.UUID]string{}
+	for _, house := range houses {
+		houseNames[house.ID] = house.Name
+	}
+
+	w, ok := startExport(c, opts, "expenses")
+	if !ok {
+		return
+	}
+	columns := []string{"date", "house", "category", "vendor", "description", "recurrence", "amount"}
+	err = writeExport(w, opts.lang, columns, func(row func([]any) error) error {
+		return h.expenseRepo.Stream(userID, filter, func(e models.Expense) error {
+			return row([]any{e.Date.Format(exportDateFormat), houseNames[e.HouseID], e.Category, e.Vendor, e.Description, e.Recurrence, e.Amount})
+		})
+	})
+	if err != nil {
+		logger.Log.Error("Failed to export expenses", "userID", userID, "error", err)
+	}
+}
+
+// exportRequest holds what every export needs besides its rows.
+type exportRequest struct {
+	format string
+	lang   string
+	today  time.Time
+	loc    *time.Location
+}
+
+// exportOptions reads the format and header language and works out the
+// account's current date. On failure it writes the error response and
+// returns false.
+func (h *ExportHandler) exportOptions(c *gin.Context, userID uuid.UUID) (exportRequest, bool) {
+	format := c.DefaultQuery("format", export.FormatCSV)
+	if format != export.FormatCSV && format != export.FormatXLSX {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be csv or xlsx"})
+		return exportRequest{}, false
+	}
+	user, err := h.userRepo.GetByID(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return exportRequest{}, false
+	}
+	lang := c.Query("lang")
+	if lang == "" {
+		lang = user.Locale
+	}
+	return exportRequest{
+		format: format,
+		lang:   export.Language(lang, c.GetHeader("Accept-Language")),
+		today:  user.Today(time.Now()),
+		loc:    user.Location(),
+	}, true
+}
+
+// exportRange reads from and to, both inclusive, and returns them as a
+// half-open range. The default is the year to today.
+func exportRange(c *gin.Context, today time.Time) (time.Time, time.Time, bool) {
+	from := time.Date(today.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+	to := today
+
+	var err error
+	if fromStr := c.Query("from"); fromStr != "" {
+		if from, err = time.Parse(exportDateFormat, fromStr); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from, expected YYYY-MM-DD"})
+			return from, to, false
+		}
+	}
+	if toStr := c.Query("to"); toStr != "" {
+		if to, err = time.Parse(exportDateFormat, toStr); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to, expected YYYY-MM-DD"})
+			return from, to, false
+		}
+	}
+	if to.Before(from) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to must not be before from"})
+		return from, to, false
+	}
+	return from, to.AddDate(0, 0, 1), true
+}
+
+// startExport sets the download headers and opens a writer on the
+// response. After this, errors can only be logged.
+func startExport(c *gin.Context, opts exportRequest, name string) (export.Writer, bool) {
+	w, err := export.New(opts.format, c.Writer, name)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	filename := fmt.Sprintf("%s-%s.%s", name, opts.today.Format("20060102"), opts.format)
+	c.Header("Content-Type", export.ContentType(opts.format))
+	c.Header("Content-Disposition", "attachment; filename=\""+filename+"\"")
+	c.Status(http.StatusOK)
+	return w, true
+}
+
+// writeExport writes the localised header row, then the rows produced by
+// fill, and finishes the file.
+func writeExport(w export.Writer, lang string, columns []string, fill func(row func([]any) error) error) error {
+	if err := w.WriteHeader(export.Headers(lang, columns)); err != nil {
+		return err
+	}
+	if err := fill(w.WriteRow); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+func exportDate(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(exportDateFormat)
+}
@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"rented-backend/logger"
 	"rented-backend/models"
@@ -8,14 +10,16 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type HouseHandler struct {
-	repo repository.HouseRepository
+	repo           repository.HouseRepository
+	chargeTypeRepo repository.ChargeTypeRepository
 }
 
-func NewHouseHandler(repo repository.HouseRepository) *HouseHandler {
-	return &HouseHandler{repo: repo}
+func NewHouseHandler(repo repository.HouseRepository, chargeTypeRepo repository.ChargeTypeRepository) *HouseHandler {
+	return &HouseHandler{repo: repo, chargeTypeRepo: chargeTypeRepo}
 }
 
 func (h *HouseHandler) CreateHouse(c *gin.Context) {
@@ -35,8 +39,9 @@ func (h *HouseHandler) CreateHouse(c *gin.Context) {
 	logger.Log.Debug("CreateHouse called", "userID", userID)
 	house.UserID = userID
 	house.ID = uuid.New()
+	house.ImportBatchID = nil
 
-	if err := h.repo.CreateHouse(&house); err != nil {
+	if err := h.repo.CreateHouse(c.Request.Context(), &house); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create house"})
 		return
 	}
@@ -64,18 +69,135 @@ func (h *HouseHandler) GetUserHouses(c *gin.Context) {
 }
 
 func (h *HouseHandler) CreateFlat(c *gin.Context) {
-	var flat models.Flat
-	if err := c.ShouldBindJSON(&flat); err != nil {
+	userIDStr, _ := c.Get("userID")
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		logger.Log.Error("Failed to parse userID from context in CreateFlat", "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	var req models.FlatRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	flat.ID = uuid.New()
+	house, err := h.repo.GetHouseByID(req.HouseID)
+	if err != nil || house.UserID != userID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "house not found"})
+		return
+	}
+
+	flat := models.Flat{
+		ID:      uuid.New(),
+		HouseID: req.HouseID,
+		Number:  req.Number,
+		Size:    req.Size,
+	}
+
+	flat.Charges, err = h.buildFlatCharges(userID, flat.ID, req.Charges)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	if err := h.repo.CreateFlat(&flat); err != nil {
+	if err := h.repo.CreateFlat(c.Request.Context(), &flat); errors.Is(err, gorm.ErrDuplicatedKey) {
+		c.JSON(http.StatusConflict, gin.H{"error": "the house already has a flat with this number"})
+		return
+	} else if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create flat"})
 		return
 	}
 
-	c.JSON(http.StatusCreated, flat)
+	created, err := h.repo.GetFlatByID(flat.ID)
+	if err != nil {
+		c.JSON(http.StatusCreated, flat)
+		return
+	}
+	c.JSON(http.StatusCreated, created)
+}
+
+// UpdateFlatCharges replaces the charge types assigned to a flat.
+func (h *HouseHandler) UpdateFlatCharges(c *gin.Context) {
+	userIDStr, _ := c.Get("userID")
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		logger.Log.Error("Failed to parse userID from context in UpdateFlatCharges", "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	flatID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var input struct {
+		Charges []models.FlatChargeInput `json:"charges" binding:"dive"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	flat, err := h.repo.GetFlatByID(flatID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "flat not found"})
+		return
+	}
+	house, err := h.repo.GetHouseByID(flat.HouseID)
+	if err != nil || house.UserID != userID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "flat not found"})
+		return
+	}
+
+	charges, err := h.buildFlatCharges(userID, flat.ID, input.Charges)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.repo.ReplaceFlatCharges(c.Request.Context(), flat.ID, charges); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update flat charges"})
+		return
+	}
+
+	updated, err := h.repo.GetFlatByID(flat.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, updated)
+}
+
+func (h *HouseHandler) buildFlatCharges(userID uuid.UUID, flatID uuid.UUID, inputs []models.FlatChargeInput) ([]models.FlatCharge, error) {
+	ids := make([]uuid.UUID, 0, len(inputs))
+	for _, in := range inputs {
+		ids = append(ids, in.ChargeTypeID)
+	}
+	chargeTypes, err := h.chargeTypeRepo.GetByIDs(userID, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	charges := make([]models.FlatCharge, 0, len(inputs))
+	seen := map[uuid.UUID]bool{}
+	for _, in := range inputs {
+		if _, ok := chargeTypes[in.ChargeTypeID]; !ok {
+			return nil, fmt.Errorf("unknown charge_type_id %s", in.ChargeTypeID)
+		}
+		if seen[in.ChargeTypeID] {
+			return nil, fmt.Errorf("charge_type_id %s assigned twice", in.ChargeTypeID)
+		}
+		seen[in.ChargeTypeID] = true
+		charges = append(charges, models.FlatCharge{
+			ID:           uuid.New(),
+			FlatID:       flatID,
+			ChargeTypeID: in.ChargeTypeID,
+			Amount:       in.Amount,
+		})
+	}
+	return charges, nil
 }
@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"rented-backend/export"
+	"rented-backend/logger"
+	"rented-backend/repository"
+	"rented-backend/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ImportHandler serves bulk imports of houses, flats, tenants and past
+// payments from CSV or XLSX files.
+type ImportHandler struct {
+	repo          repository.ImportRepository
+	importService *service.ImportService
+}
+
+func NewImportHandler(repo repository.ImportRepository, importService *service.ImportService) *ImportHandler {
+	return &ImportHandler{repo: repo, importService: importService}
+}
+
+// GetTemplate downloads an empty file with the kind's columns, including
+// one column per charge code for flats and payments.
+func (h *ImportHandler) GetTemplate(c *gin.Context) {
+	userIDStr, _ := c.Get("userID")
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		logger.Log.Error("Failed to parse userID from context in GetTemplate", "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+	format := c.DefaultQuery("format", export.FormatCSV)
+	if format != export.FormatCSV && format != export.FormatXLSX {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be csv or xlsx"})
+		return
+	}
+
+	kind := c.Param("kind")
+	columns, err := h.importService.TemplateColumns(userID, kind)
+	if errors.Is(err, service.ErrImportKind) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	w, err := export.New(format, c.Writer, kind)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Header("Content-Type", export.ContentType(format))
+	c.Header("Content-Disposition", "attachment; filename=\""+kind+"-template."+format+"\"")
+	c.Status(http.StatusOK)
+	if err := w.WriteHeader(columns); err != nil {
+		logger.Log.Error("Failed to write import template", "error", err)
+		return
+	}
+	if err := w.Close(); err != nil {
+		logger.Log.Error("Failed to write import template", "error", err)
+	}
+}
+
+// Import validates the uploaded file and, unless dry_run=true, commits it.
+// A file with any invalid row is rejected as a whole with 422 and the
+// row-level errors.
+func (h *ImportHandler) Import(c *gin.Context) {
+	userIDStr, _ := c.Get("userID")
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		logger.Log.Error("Failed to parse userID from context in Import", "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "could not read the file"})
+		return
+	}
+	defer file.Close()
+
+	dryRun := c.Query("dry_run") == "true"
+	result, err := h.importService.Import(c.Request.Context(), userID, c.Param("kind"), fileHeader.Filename, file, dryRun)
+	if errors.Is(err, service.ErrImportKind) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		logger.Log.Error("Failed to import file", "userID", userID, "kind", c.Param("kind"), "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import file"})
+		return
+	}
+
+	switch {
+	case !result.Valid:
+		c.JSON(http.StatusUnprocessableEntity, result)
+	case dryRun:
+		c.JSON(http.StatusOK, result)
+	default:
+		c.JSON(http.StatusCreated, result)
+	}
+}
+
+func (h *ImportHandler) GetImports(c *gin.Context) {
+	userIDStr, _ := c.Get("userID")
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		logger.Log.Error("Failed to parse userID from context in GetImports", "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	batches, err := h.repo.ListBatches(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, batches)
+}
+
+// RollbackImport deletes everything the import created. It is refused
+// once other records depend on the imported rows.
+func (h *ImportHandler) RollbackImport(c *gin.Context) {
+	userIDStr, _ := c.Get("userID")
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		logger.Log.Error("Failed to parse userID from context in RollbackImport", "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid import id"})
+		return
+	}
+
+	batch, err := h.repo.GetBatch(id, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "import not found"})
+		return
+	}
+
+	err = h.repo.Rollback(c.Request.Context(), batch)
+	var inUse *repository.ImportInUseError
+	if errors.Is(err, repository.ErrImportRolledBack) || errors.As(err, &inUse) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		logger.Log.Error("Failed to roll back import", "importID", id, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to roll back import"})
+		return
+	}
+	c.JSON(http.StatusOK, batch)
+}
@@ -0,0 +1,454 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"rented-backend/billing"
+	"rented-backend/logger"
+	"rented-backend/models"
+	"rented-backend/repository"
+	"rented-backend/service"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type MaintenanceHandler struct {
+	repo           repository.MaintenanceRepository
+	houseRepo      repository.HouseRepository
+	tenantRepo     repository.TenantRepository
+	chargeTypeRepo repository.ChargeTypeRepository
+	vendorRepo     repository.VendorRepository
+	userRepo       repository.UserRepository
+	s3Service      *service.S3Service
+}
+
+func NewMaintenanceHandler(repo repository.MaintenanceRepository, houseRepo repository.HouseRepository, tenantRepo repository.TenantRepository, chargeTypeRepo repository.ChargeTypeRepository, vendorRepo repository.VendorRepository, userRepo repository.UserRepository, s3Service *service.S3Service) *MaintenanceHandler {
+	return &MaintenanceHandler{repo: repo, houseRepo: houseRepo, tenantRepo: tenantRepo, chargeTypeRepo: chargeTypeRepo, vendorRepo: vendorRepo, userRepo: userRepo, s3Service: s3Service}
+}
+
+// CreateTicket opens a ticket. A ticket raised for a tenant takes the
+// tenant's house and flat unless given.
+func (h *MaintenanceHandler) CreateTicket(c *gin.Context) {
+	userIDStr, _ := c.Get("userID")
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		logger.Log.Error("Failed to parse userID from context in CreateTicket", "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	var req models.TicketRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.TenantID != nil {
+		tenant, err := h.tenantRepo.GetByID(*req.TenantID, userID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "tenant not found"})
+			return
+		}
+		if req.HouseID == uuid.Nil {
+			req.HouseID = tenant.HouseID
+		}
+		if req.FlatID == nil {
+			req.FlatID = &tenant.FlatID
+		}
+	}
+
+	house, err := h.houseRepo.GetHouseByID(req.HouseID)
+	if err != nil || house.UserID != userID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "house not found"})
+		return
+	}
+	if req.FlatID != nil {
+		flat, err := h.houseRepo.GetFlatByID(*req.FlatID)
+		if err != nil || flat.HouseID != house.ID {
+			c.JSON(http.StatusNotFound, gin.H{"error": "flat not found"})
+			return
+		}
+	}
+
+	ticket := models.MaintenanceTicket{
+		ID:            uuid.New(),
+		UserID:        userID,
+		HouseID:       house.ID,
+		FlatID:        req.FlatID,
+		TenantID:      req.TenantID,
+		Title:         req.Title,
+		Description:   req.Description,
+		Priority:      req.Priority,
+		Status:        models.TicketOpen,
+		AssigneeName:  req.AssigneeName,
+		AssigneePhone: req.AssigneePhone,
+	}
+	if req.VendorID != nil {
+		if err := h.assignVendor(userID, &ticket, *req.VendorID, req.AssigneeName); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if ticket.Priority == "" {
+		ticket.Priority = models.TicketPriorityMedium
+	}
+	if ticket.AssigneeName != "" {
+		ticket.Status = models.TicketAssigned
+	}
+
+	if err := h.repo.Create(c.Request.Context(), &ticket); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create ticket"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, ticket)
+}
+
+// GetTickets lists tickets, newest first.
+// Query params: house_id, tenant_id, status, priority.
+func (h *MaintenanceHandler) GetTickets(c *gin.Context) {
+	userIDStr, _ := c.Get("userID")
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		logger.Log.Error("Failed to parse userID from context in GetTickets", "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	filter := repository.TicketFilter{Status: c.Query("status"), Priority: c.Query("priority")}
+	if houseStr := c.Query("house_id"); houseStr != "" {
+		houseID, err := uuid.Parse(houseStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid house_id"})
+			return
+		}
+		filter.HouseID = &houseID
+	}
+	if tenantStr := c.Query("tenant_id"); tenantStr != "" {
+		tenantID, err := uuid.Parse(tenantStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid tenant_id"})
+			return
+		}
+		filter.TenantID = &tenantID
+	}
+
+	tickets, err := h.repo.List(userID, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tickets"})
+		return
+	}
+
+	c.JSON(http.StatusOK, tickets)
+}
+
+func (h *MaintenanceHandler) GetTicket(c *gin.Context) {
+	userIDStr, _ := c.Get("userID")
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		logger.Log.Error("Failed to parse userID from context in GetTicket", "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	ticket, err := h.repo.GetByID(id, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "ticket not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, ticket)
+}
+
+// UpdateTicketStatus moves a ticket along its workflow. Assigning a ticket
+// requires someone to assign it to.
+func (h *MaintenanceHandler) UpdateTicketStatus(c *gin.Context) {
+	userIDStr, _ := c.Get("userID")
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		logger.Log.Error("Failed to parse userID from context in UpdateTicketStatus", "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req models.TicketStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ticket, err := h.repo.GetByID(id, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "ticket not found"})
+		return
+	}
+	if !models.CanTransition(ticket.Status, req.Status) {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("cannot move a ticket from %s to %s", ticket.Status, req.Status)})
+		return
+	}
+
+	if req.AssigneeName != "" {
+		ticket.AssigneeName = req.AssigneeName
+		ticket.AssigneePhone = req.AssigneePhone
+	}
+	if req.VendorID != nil {
+		if err := h.assignVendor(userID, ticket, *req.VendorID, req.AssigneeName); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if req.Status == models.TicketAssigned && ticket.AssigneeName == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "assignee_name is required to assign a ticket"})
+		return
+	}
+
+	ticket.Status = req.Status
+	ticket.ResolvedAt = nil
+	if req.Status == models.TicketResolved {
+		now := time.Now()
+		ticket.ResolvedAt = &now
+	}
+
+	if err := h.repo.Update(c.Request.Context(), ticket); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update ticket"})
+		return
+	}
+
+	c.JSON(http.StatusOK, ticket)
+}
+
+type TicketCommentRequest struct {
+	Body string `json:"body" binding:"required"`
+}
+
+func (h *MaintenanceHandler) AddComment(c *gin.Context) {
+	userIDStr, _ := c.Get("userID")
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		logger.Log.Error("Failed to parse userID from context in AddComment", "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req TicketCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ticket, err := h.repo.GetByID(id, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "ticket not found"})
+		return
+	}
+
+	comment := models.TicketComment{
+		ID:         uuid.New(),
+		TicketID:   ticket.ID,
+		AuthorID:   userID,
+		AuthorType: models.CommentByUser,
+		Body:       req.Body,
+	}
+	if err := h.repo.AddComment(c.Request.Context(), &comment); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add comment"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, comment)
+}
+
+// UploadPhoto attaches a photo to a ticket. Multipart field: photo.
+func (h *MaintenanceHandler) UploadPhoto(c *gin.Context) {
+	userIDStr, _ := c.Get("userID")
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		logger.Log.Error("Failed to parse userID from context in UploadPhoto", "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	ticket, err := h.repo.GetByID(id, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "ticket not found"})
+		return
+	}
+
+	file, err := c.FormFile("photo")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "photo file is required"})
+		return
+	}
+	if h.s3Service == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "file uploads are not configured"})
+		return
+	}
+
+	photo := models.TicketPhoto{ID: uuid.New(), TicketID: ticket.ID}
+	photo.URL, err = h.s3Service.UploadFile(file, "tickets", fmt.Sprintf("%s_%s", ticket.ID, photo.ID))
+	if err != nil {
+		logger.Log.Error("Failed to upload ticket photo", "ticketID", ticket.ID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload photo"})
+		return
+	}
+
+	if err := h.repo.AddPhoto(c.Request.Context(), &photo); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save photo"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, photo)
+}
+
+// SetTicketCost records what the work cost and who pays for it: the
+// landlord, as a repairs expense on the house, or the tenant, as a
+// maintenance charge on their ledger. A cost can only be settled once.
+func (h *MaintenanceHandler) SetTicketCost(c *gin.Context) {
+	userIDStr, _ := c.Get("userID")
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		logger.Log.Error("Failed to parse userID from context in SetTicketCost", "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req models.TicketCostRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ticket, err := h.repo.GetByID(id, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "ticket not found"})
+		return
+	}
+	if ticket.ExpenseID != nil || ticket.ChargeID != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": repository.ErrCostSettled.Error()})
+		return
+	}
+
+	// The cost is booked on the landlord's current date
+	user, err := h.userRepo.GetByID(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	today := user.Today(time.Now())
+	ticket.Cost = req.Amount
+	ticket.CostChargedTo = req.ChargeTo
+
+	var expense *models.Expense
+	var charge *models.Charge
+	switch req.ChargeTo {
+	case models.CostToLandlord:
+		expense = &models.Expense{
+			ID:          uuid.New(),
+			UserID:      userID,
+			HouseID:     ticket.HouseID,
+			FlatID:      ticket.FlatID,
+			Category:    models.ExpenseRepairs,
+			VendorID:    ticket.VendorID,
+			Vendor:      ticket.AssigneeName,
+			Amount:      req.Amount,
+			Date:        today,
+			Description: ticket.Title,
+			Recurrence:  models.ExpenseRepeatNone,
+		}
+		ticket.ExpenseID = &expense.ID
+
+	case models.CostToTenant:
+		if ticket.TenantID == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "ticket has no tenant to charge"})
+			return
+		}
+		tenant, err := h.tenantRepo.GetByID(*ticket.TenantID, userID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "tenant not found"})
+			return
+		}
+		chargeType, err := h.chargeTypeRepo.GetByCode(userID, models.ChargeKindMaintenance)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "maintenance charge type missing"})
+			return
+		}
+
+		period := req.Period
+		if period.IsZero() {
+			period = billing.Of(today)
+		}
+
+		charge = &models.Charge{
+			ID:           uuid.New(),
+			TenantID:     tenant.ID,
+			FlatID:       tenant.FlatID,
+			Period:       period,
+			Kind:         chargeType.Code,
+			ChargeTypeID: chargeType.ID,
+			Description:  fmt.Sprintf("Maintenance: %s", ticket.Title),
+			Amount:       req.Amount,
+			SourceType:   models.ChargeSourceMaintenance,
+			SourceID:     ticket.ID,
+		}
+		ticket.ChargeID = &charge.ID
+	}
+
+	err = h.repo.SettleCost(c.Request.Context(), ticket, expense, charge)
+	switch {
+	case errors.Is(err, repository.ErrCostSettled):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record ticket cost"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"ticket": ticket, "expense": expense, "charge": charge})
+}
+
+// assignVendor links the ticket to one of the user's vendors. The vendor's
+// name and phone are used unless an assignee was named in the request.
+func (h *MaintenanceHandler) assignVendor(userID uuid.UUID, ticket *models.MaintenanceTicket, vendorID uuid.UUID, assigneeName string) error {
+	vendor, err := h.vendorRepo.GetByID(vendorID, userID)
+	if err != nil {
+		return fmt.Errorf("vendor not found")
+	}
+	ticket.VendorID = &vendor.ID
+	if assigneeName == "" {
+		ticket.AssigneeName = vendor.Name
+		ticket.AssigneePhone = vendor.Phone
+	}
+	return nil
+}
@@ -0,0 +1,405 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"rented-backend/logger"
+	"rented-backend/models"
+	"rented-backend/repository"
+	"rented-backend/service"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type MeterHandler struct {
+	repo           repository.MeterRepository
+	houseRepo      repository.HouseRepository
+	tenantRepo     repository.TenantRepository
+	chargeTypeRepo repository.ChargeTypeRepository
+}
+
+func NewMeterHandler(repo repository.MeterRepository, houseRepo repository.HouseRepository, tenantRepo repository.TenantRepository, chargeTypeRepo repository.ChargeTypeRepository) *MeterHandler {
+	return &MeterHandler{repo: repo, houseRepo: houseRepo, tenantRepo: tenantRepo, chargeTypeRepo: chargeTypeRepo}
+}
+
+func (h *MeterHandler) CreateMeter(c *gin.Context) {
+	userIDStr, _ := c.Get("userID")
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		logger.Log.Error("Failed to parse userID from context in CreateMeter", "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	var meter models.Meter
+	if err := c.ShouldBindJSON(&meter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	flat, err := h.houseRepo.GetFlatByID(meter.FlatID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "flat not found"})
+		return
+	}
+	house, err := h.houseRepo.GetHouseByID(flat.HouseID)
+	if err != nil || house.UserID != userID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "flat not found"})
+		return
+	}
+
+	meter.ID = uuid.New()
+	meter.IsActive = true
+
+	if err := h.repo.CreateMeter(c.Request.Context(), &meter); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create meter"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, meter)
+}
+
+func (h *MeterHandler) GetMeters(c *gin.Context) {
+	userIDStr, _ := c.Get("userID")
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		logger.Log.Error("Failed to parse userID from context in GetMeters", "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	flatID, err := uuid.Parse(c.Query("flat_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid flat_id"})
+		return
+	}
+
+	meters, err := h.repo.GetFlatMeters(flatID, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch meters"})
+		return
+	}
+
+	c.JSON(http.StatusOK, meters)
+}
+
+// CreateReading records a monthly reading, bills it against the landlord's
+// tariff and posts the bill as an electricity charge for the flat's tenant.
+// A reading lower than the previous one is stored flagged and not billed.
+func (h *MeterHandler) CreateReading(c *gin.Context) {
+	userIDStr, _ := c.Get("userID")
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		logger.Log.Error("Failed to parse userID from context in CreateReading", "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	meterID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req models.MeterReadingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Period.IsZero() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "period is required"})
+		return
+	}
+
+	meter, err := h.repo.GetMeterByID(meterID, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "meter not found"})
+		return
+	}
+
+	previous, err := h.previousUnits(meter, req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	reading := models.MeterReading{
+		ID:            uuid.New(),
+		MeterID:       meter.ID,
+		FlatID:        meter.FlatID,
+		Period:        req.Period,
+		PreviousUnits: previous,
+		CurrentUnits:  req.CurrentUnits,
+		ReadingDate:   req.ReadingDate,
+	}
+	if reading.ReadingDate.IsZero() {
+		reading.ReadingDate = time.Now()
+	}
+
+	charge, err := h.bill(userID, meter, &reading)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.repo.CreateReading(c.Request.Context(), &reading, charge); errors.Is(err, gorm.ErrDuplicatedKey) {
+		c.JSON(http.StatusConflict, gin.H{"error": "a reading for this month already exists, or the tenant was already billed electricity for it"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record reading"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"reading": reading, "charge": charge})
+}
+
+// ReplaceReading corrects a flagged reading, for example one entered with a
+// typo, and bills the corrected figures. Readings that were billed cannot
+// be replaced.
+func (h *MeterHandler) ReplaceReading(c *gin.Context) {
+	userIDStr, _ := c.Get("userID")
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		logger.Log.Error("Failed to parse userID from context in ReplaceReading", "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	meterID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	readingID, err := uuid.Parse(c.Param("readingId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid reading id"})
+		return
+	}
+
+	var req models.MeterReadingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	meter, err := h.repo.GetMeterByID(meterID, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "meter not found"})
+		return
+	}
+	reading, err := h.repo.GetReading(readingID, meter.ID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "reading not found"})
+		return
+	}
+	if !reading.IsFlagged {
+		c.JSON(http.StatusConflict, gin.H{"error": repository.ErrReadingNotFlagged.Error()})
+		return
+	}
+	if !req.Period.IsZero() && req.Period != reading.Period {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "the period of a reading cannot be changed"})
+		return
+	}
+
+	previous, err := h.previousUnits(meter, req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	reading.PreviousUnits = previous
+	reading.CurrentUnits = req.CurrentUnits
+	reading.IsFlagged = false
+	reading.FlagReason = ""
+	if !req.ReadingDate.IsZero() {
+		reading.ReadingDate = req.ReadingDate
+	}
+
+	charge, err := h.bill(userID, meter, reading)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = h.repo.ReplaceFlaggedReading(c.Request.Context(), reading, charge)
+	switch {
+	case errors.Is(err, repository.ErrReadingNotFlagged):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		c.JSON(http.StatusConflict, gin.H{"error": "the tenant was already billed electricity for this month"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to replace reading"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"reading": reading, "charge": charge})
+}
+
+// previousUnits is the reading a new one is measured from: the one given in
+// the request, else the latest reading that was not flagged, else the
+// meter's initial reading.
+func (h *MeterHandler) previousUnits(meter *models.Meter, req models.MeterReadingRequest) (float64, error) {
+	if req.PreviousUnits != nil {
+		return *req.PreviousUnits, nil
+	}
+	last, err := h.repo.GetLatestReading(meter.ID)
+	if err != nil {
+		return 0, err
+	}
+	if last == nil {
+		return meter.InitialReading, nil
+	}
+	return last.CurrentUnits, nil
+}
+
+// bill flags a reading that went backwards, or bills it against the
+// landlord's tariff and returns the electricity charge for the tenant who
+// lived in the flat for most of the reading's period, if it was let.
+func (h *MeterHandler) bill(userID uuid.UUID, meter *models.Meter, reading *models.MeterReading) (*models.Charge, error) {
+	if reading.CurrentUnits < reading.PreviousUnits {
+		reading.IsFlagged = true
+		reading.FlagReason = fmt.Sprintf("reading went backwards from %.2f to %.2f", reading.PreviousUnits, reading.CurrentUnits)
+		logger.Log.Warn("Backwards meter reading flagged", "meterID", meter.ID, "previous", reading.PreviousUnits, "current", reading.CurrentUnits)
+		return nil, nil
+	}
+
+	tariff, err := h.repo.GetTariff(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	bill := service.CalculateElectricityBill(reading.CurrentUnits-reading.PreviousUnits, *tariff)
+	reading.Units = bill.Units
+	reading.EnergyCharge = bill.EnergyCharge
+	reading.MeterRent = bill.MeterRent
+	reading.VAT = bill.VAT
+	reading.Amount = bill.Total
+
+	occupants, err := h.tenantRepo.GetOccupantsByFlatID(meter.FlatID, reading.Period)
+	if err != nil {
+		return nil, err
+	}
+	if len(occupants) == 0 {
+		return nil, nil
+	}
+	tenant := occupants[0]
+	for _, t := range occupants[1:] {
+		if daysOccupied(t, reading.Period) >= daysOccupied(tenant, reading.Period) {
+			tenant = t
+		}
+	}
+	reading.TenantID = tenant.ID
+	charge := &models.Charge{
+		ID:          uuid.New(),
+		TenantID:    tenant.ID,
+		FlatID:      meter.FlatID,
+		Period:      reading.Period,
+		Kind:        models.ChargeKindElectricity,
+		Description: fmt.Sprintf("Electricity, meter %s: %.2f units", meter.Number, bill.Units),
+		Amount:      bill.Total,
+		SourceType:  models.ChargeSourceMeterReading,
+		SourceID:    reading.ID,
+	}
+	if chargeType, err := h.chargeTypeRepo.GetByCode(userID, models.ChargeKindElectricity); err == nil {
+		charge.ChargeTypeID = chargeType.ID
+	}
+	return charge, nil
+}
+
+func (h *MeterHandler) GetReadings(c *gin.Context) {
+	userIDStr, _ := c.Get("userID")
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		logger.Log.Error("Failed to parse userID from context in GetReadings", "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	meterID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	if _, err := h.repo.GetMeterByID(meterID, userID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "meter not found"})
+		return
+	}
+
+	readings, err := h.repo.GetReadings(meterID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch readings"})
+		return
+	}
+
+	c.JSON(http.StatusOK, readings)
+}
+
+func (h *MeterHandler) GetTariff(c *gin.Context) {
+	userIDStr, _ := c.Get("userID")
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		logger.Log.Error("Failed to parse userID from context in GetTariff", "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	tariff, err := h.repo.GetTariff(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, tariff)
+}
+
+func (h *MeterHandler) UpdateTariff(c *gin.Context) {
+	userIDStr, _ := c.Get("userID")
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		logger.Log.Error("Failed to parse userID from context in UpdateTariff", "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	var input models.ElectricityTariff
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(input.Slabs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "at least one slab is required"})
+		return
+	}
+	for i, slab := range input.Slabs {
+		last := i == len(input.Slabs)-1
+		if slab.Rate < 0 || (!last && slab.UpTo <= 0) || (i > 0 && slab.UpTo > 0 && slab.UpTo <= input.Slabs[i-1].UpTo) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "slabs must have ascending up_to limits and non-negative rates"})
+			return
+		}
+	}
+
+	tariff, err := h.repo.GetTariff(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if tariff.ID == uuid.Nil {
+		tariff.ID = uuid.New()
+	}
+	tariff.Slabs = input.Slabs
+	tariff.MeterRent = input.MeterRent
+	tariff.VATRate = input.VATRate
+
+	if err := h.repo.SaveTariff(c.Request.Context(), tariff); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save tariff"})
+		return
+	}
+
+	c.JSON(http.StatusOK, tariff)
+}
@@ -0,0 +1,313 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"rented-backend/audit"
+	"rented-backend/logger"
+	"rented-backend/models"
+	"rented-backend/repository"
+	"rented-backend/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// PaymentProofHandler is the landlord's side of tenant-submitted payments:
+// the approval queue, and entering a payment on a tenant's behalf, e.g. by
+// a caretaker.
+type PaymentProofHandler struct {
+	repo          repository.PaymentProofRepository
+	tenantRepo    repository.TenantRepository
+	userRepo      repository.UserRepository
+	proofService  *service.PaymentProofService
+	notifications *service.NotificationService
+	s3Service     *service.S3Service
+}
+
+func NewPaymentProofHandler(repo repository.PaymentProofRepository, tenantRepo repository.TenantRepository, userRepo repository.UserRepository, proofService *service.PaymentProofService, notifications *service.NotificationService, s3Service *service.S3Service) *PaymentProofHandler {
+	return &PaymentProofHandler{repo: repo, tenantRepo: tenantRepo, userRepo: userRepo, proofService: proofService, notifications: notifications, s3Service: s3Service}
+}
+
+// SubmitPaymentProof queues a payment for a tenant. Multipart form with the
+// fields of models.PaymentProofRequest, including tenant_id, and an
+// optional screenshot file. Payments entered by a caretaker notify the
+// landlord.
+func (h *PaymentProofHandler) SubmitPaymentProof(c *gin.Context) {
+	userIDStr, _ := c.Get("userID")
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		logger.Log.Error("Failed to parse userID from context in SubmitPaymentProof", "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	var req models.PaymentProofRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tenant, err := h.tenantRepo.GetByID(req.TenantID, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "tenant not found"})
+		return
+	}
+
+	caretaker := caretakerName(c.Request.Context(), h.userRepo)
+	submittedBy := "landlord"
+	if caretaker != "" {
+		submittedBy = caretaker
+	}
+	proof := submitPaymentProof(c, h.repo, h.s3Service, tenant, req, submittedBy)
+	if proof == nil || caretaker == "" {
+		return
+	}
+
+	h.notifications.NotifyQuietly(c.Request.Context(), userID, service.Alert{
+		Event: models.EventCaretakerPayment,
+		Title: "Payment recorded",
+		Body:  fmt.Sprintf("%s recorded %s from %s (flat %s) for %s.", caretaker, proof.Amount, tenant.Name, tenant.Flat.Number, proof.Period.Label()),
+		Data:  map[string]string{"proof_id": proof.ID.String(), "tenant_id": tenant.ID.String()},
+	})
+}
+
+// caretakerName returns the name of the caretaker signed in for the
+// request, or "" when the account owner made it.
+func caretakerName(ctx context.Context, userRepo repository.UserRepository) string {
+	actor := audit.ActorFromContext(ctx)
+	if actor.Type != audit.ActorCaretaker {
+		return ""
+	}
+	if caretaker, err := userRepo.GetCaretaker(actor.ID, actor.AccountID); err == nil && caretaker.Name != "" {
+		return caretaker.Name
+	}
+	return "A caretaker"
+}
+
+// GetPaymentProofs is the approval queue, oldest first.
+// Query params: status (default pending; all for every status), house_id, tenant_id.
+func (h *PaymentProofHandler) GetPaymentProofs(c *gin.Context) {
+	userIDStr, _ := c.Get("userID")
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		logger.Log.Error("Failed to parse userID from context in GetPaymentProofs", "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	filter := repository.ProofFilter{Status: c.DefaultQuery("status", models.ProofPending)}
+	switch filter.Status {
+	case "all":
+		filter.Status = ""
+	case models.ProofPending, models.ProofConfirmed, models.ProofRejected:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be pending, confirmed, rejected or all"})
+		return
+	}
+
+	if houseStr := c.Query("house_id"); houseStr != "" {
+		houseID, err := uuid.Parse(houseStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid house_id"})
+			return
+		}
+		filter.HouseID = &houseID
+	}
+	if tenantStr := c.Query("tenant_id"); tenantStr != "" {
+		tenantID, err := uuid.Parse(tenantStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid tenant_id"})
+			return
+		}
+		filter.TenantID = &tenantID
+	}
+
+	proofs, err := h.repo.List(userID, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch payments"})
+		return
+	}
+
+	c.JSON(http.StatusOK, proofs)
+}
+
+// ConfirmPaymentProof records the submitted payment in the ledger.
+func (h *PaymentProofHandler) ConfirmPaymentProof(c *gin.Context) {
+	userIDStr, _ := c.Get("userID")
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		logger.Log.Error("Failed to parse userID from context in ConfirmPaymentProof", "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	var req models.ConfirmProofRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	proof, ok := h.pendingProof(c, userID)
+	if !ok {
+		return
+	}
+
+	payment, err := h.proofService.Confirm(c.Request.Context(), userID, proof, req.Items)
+	switch {
+	case errors.Is(err, service.ErrInvalidItems):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, repository.ErrProofReviewed):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		logger.Log.Error("Failed to confirm payment proof", "proofID", proof.ID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to confirm payment"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"proof": proof, "payment": payment, "receipt_number": service.ReceiptNumber(*payment)})
+}
+
+func (h *PaymentProofHandler) RejectPaymentProof(c *gin.Context) {
+	userIDStr, _ := c.Get("userID")
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		logger.Log.Error("Failed to parse userID from context in RejectPaymentProof", "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	var req models.RejectProofRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	proof, ok := h.pendingProof(c, userID)
+	if !ok {
+		return
+	}
+
+	err = h.proofService.Reject(c.Request.Context(), userID, proof, req.Reason)
+	switch {
+	case errors.Is(err, repository.ErrProofReviewed):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reject payment"})
+		return
+	}
+
+	c.JSON(http.StatusOK, proof)
+}
+
+// pendingProof loads the proof named by the id param and checks it is
+// still awaiting review.
+func (h *PaymentProofHandler) pendingProof(c *gin.Context, userID uuid.UUID) (*models.PaymentProof, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return nil, false
+	}
+
+	proof, err := h.repo.GetByID(id, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "payment not found"})
+		return nil, false
+	}
+	if proof.Status != models.ProofPending {
+		c.JSON(http.StatusConflict, gin.H{"error": repository.ErrProofReviewed.Error()})
+		return nil, false
+	}
+	return proof, true
+}
+
+// submitPaymentProof validates and stores a pending payment for the
+// tenant, uploading the screenshot if one was sent. It returns nil after
+// writing an error response.
+func submitPaymentProof(c *gin.Context, repo repository.PaymentProofRepository, s3Service *service.S3Service, tenant *models.Tenant, req models.PaymentProofRequest, submittedBy string) *models.PaymentProof {
+	if req.Period.IsZero() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "period is required"})
+		return nil
+	}
+
+	if req.TransactionID != "" {
+		used, err := repo.TransactionUsed(tenant.UserID, req.Method, req.TransactionID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to submit payment"})
+			return nil
+		}
+		if used {
+			c.JSON(http.StatusConflict, gin.H{"error": "this transaction has already been submitted"})
+			return nil
+		}
+	}
+
+	proof := models.PaymentProof{
+		ID:            uuid.New(),
+		UserID:        tenant.UserID,
+		TenantID:      tenant.ID,
+		Period:        req.Period,
+		Amount:        req.Amount,
+		Method:        req.Method,
+		TransactionID: req.TransactionID,
+		Note:          req.Note,
+		SubmittedBy:   submittedBy,
+		Status:        models.ProofPending,
+	}
+
+	if file, err := c.FormFile("screenshot"); err == nil {
+		if s3Service == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "file uploads are not configured"})
+			return nil
+		}
+		proof.ScreenshotURL, err = s3Service.UploadFile(file, "payment-proofs", proof.ID.String())
+		if err != nil {
+			logger.Log.Error("Failed to upload payment screenshot", "tenantID", tenant.ID, "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload screenshot"})
+			return nil
+		}
+	}
+
+	if err := repo.Create(c.Request.Context(), &proof); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to submit payment"})
+		return nil
+	}
+
+	c.JSON(http.StatusCreated, proof)
+	return &proof
+}
+
+// writeReceipt sends the payment's receipt as json or pdf, picked by the
+// format query param.
+func writeReceipt(c *gin.Context, chargeTypeRepo repository.ChargeTypeRepository, payment *models.RentPayment, tenant *models.Tenant) {
+	chargeTypes, err := chargeTypeRepo.GetAll(tenant.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	names := map[string]string{}
+	for _, ct := range chargeTypes {
+		names[ct.Code] = ct.Name
+	}
+	receipt := service.BuildReceipt(*payment, *tenant, names)
+
+	switch c.DefaultQuery("format", "json") {
+	case "pdf":
+		c.Header("Content-Type", "application/pdf")
+		c.Header("Content-Disposition", "attachment; filename=\"receipt-"+receipt.Number+".pdf\"")
+		if err := service.WriteReceiptPDF(c.Writer, receipt); err != nil {
+			logger.Log.Error("Failed to write receipt", "paymentID", payment.ID, "error", err)
+		}
+	case "json":
+		c.JSON(http.StatusOK, receipt)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be json or pdf"})
+	}
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"net/http"
+	"rented-backend/logger"
+	"rented-backend/models"
+	"rented-backend/repository"
+	"rented-backend/service"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type PolicyHandler struct {
+	repo           repository.PolicyRepository
+	houseRepo      repository.HouseRepository
+	billingService *service.BillingService
+}
+
+func NewPolicyHandler(repo repository.PolicyRepository, houseRepo repository.HouseRepository, billingService *service.BillingService) *PolicyHandler {
+	return &PolicyHandler{repo: repo, houseRepo: houseRepo, billingService: billingService}
+}
+
+func (h *PolicyHandler) GetHousePolicy(c *gin.Context) {
+	userIDStr, _ := c.Get("userID")
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		logger.Log.Error("Failed to parse userID from context in GetHousePolicy", "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	houseID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid house id"})
+		return
+	}
+
+	house, err := h.houseRepo.GetHouseByID(houseID)
+	if err != nil || house.UserID != userID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "house not found"})
+		return
+	}
+
+	policy, err := h.repo.GetByHouseID(houseID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+func (h *PolicyHandler) UpdateHousePolicy(c *gin.Context) {
+	userIDStr, _ := c.Get("userID")
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		logger.Log.Error("Failed to parse userID from context in UpdateHousePolicy", "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	houseID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid house id"})
+		return
+	}
+
+	var input models.RentPolicy
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if input.ProrationMode == "" {
+		input.ProrationMode = models.ProrationCalendarDays
+	}
+	if input.ProrationMode == models.ProrationFreeAfterDay && input.ProrationDay < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "proration_day is required for free_after_day"})
+		return
+	}
+
+	house, err := h.houseRepo.GetHouseByID(houseID)
+	if err != nil || house.UserID != userID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "house not found"})
+		return
+	}
+
+	policy, err := h.repo.GetByHouseID(houseID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if policy.ID == uuid.Nil {
+		policy.ID = uuid.New()
+	}
+	policy.DueDay = input.DueDay
+	policy.GraceDays = input.GraceDays
+	policy.LateFeeType = input.LateFeeType
+	policy.LateFeeAmount = input.LateFeeAmount
+	policy.LateFeePercent = input.LateFeePercent
+	policy.LateFeeCap = input.LateFeeCap
+	policy.ProrationMode = input.ProrationMode
+	policy.ProrationDay = input.ProrationDay
+
+	if err := h.repo.Save(c.Request.Context(), policy); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save policy"})
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+// RunLateFees applies late fees for the landlord right away instead of
+// waiting for the daily job.
+func (h *PolicyHandler) RunLateFees(c *gin.Context) {
+	userIDStr, _ := c.Get("userID")
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		logger.Log.Error("Failed to parse userID from context in RunLateFees", "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	applied, err := h.billingService.ApplyLateFees(c.Request.Context(), userID, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"applied": applied})
+}
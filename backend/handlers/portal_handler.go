@@ -0,0 +1,400 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"rented-backend/logger"
+	"rented-backend/models"
+	"rented-backend/money"
+	"rented-backend/repository"
+	"rented-backend/service"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// PortalHandler serves the tenant portal. Every endpoint acts on the
+// logged-in tenant only; see TenantAuthMiddleware.
+type PortalHandler struct {
+	tenantRepo      repository.TenantRepository
+	rentRepo        repository.RentRepository
+	maintenanceRepo repository.MaintenanceRepository
+	proofRepo       repository.PaymentProofRepository
+	chargeTypeRepo  repository.ChargeTypeRepository
+	dueService      *service.DueService
+	notifications   *service.NotificationService
+	s3Service       *service.S3Service
+}
+
+func NewPortalHandler(tenantRepo repository.TenantRepository, rentRepo repository.RentRepository, maintenanceRepo repository.MaintenanceRepository, proofRepo repository.PaymentProofRepository, chargeTypeRepo repository.ChargeTypeRepository, dueService *service.DueService, notifications *service.NotificationService, s3Service *service.S3Service) *PortalHandler {
+	return &PortalHandler{
+		tenantRepo:      tenantRepo,
+		rentRepo:        rentRepo,
+		maintenanceRepo: maintenanceRepo,
+		proofRepo:       proofRepo,
+		chargeTypeRepo:  chargeTypeRepo,
+		dueService:      dueService,
+		notifications:   notifications,
+		s3Service:       s3Service,
+	}
+}
+
+type PortalTicketRequest struct {
+	Title       string `json:"title" binding:"required"`
+	Description string `json:"description"`
+	Priority    string `json:"priority" binding:"omitempty,oneof=low medium high urgent"`
+}
+
+func (h *PortalHandler) GetMe(c *gin.Context) {
+	tenant, ok := h.currentTenant(c, "GetMe")
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, tenant)
+}
+
+// PortalDues is the tenant's dues plus what they have submitted that the
+// landlord has not yet confirmed. Pending payments are not taken off dues.
+type PortalDues struct {
+	*service.TenantDues
+	PendingApproval money.Amount `json:"pending_approval"`
+}
+
+// UpdatePreferences lets the tenant opt out of SMS or change its language.
+func (h *PortalHandler) UpdatePreferences(c *gin.Context) {
+	tenant, ok := h.currentTenant(c, "UpdatePreferences")
+	if !ok {
+		return
+	}
+
+	var req models.TenantNotificationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	tenant.SMSOptOut = req.SMSOptOut
+	tenant.Language = req.Language
+
+	if err := h.tenantRepo.UpdateNotifications(c.Request.Context(), tenant); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update preferences"})
+		return
+	}
+
+	c.JSON(http.StatusOK, tenant)
+}
+
+func (h *PortalHandler) GetDues(c *gin.Context) {
+	tenant, ok := h.currentTenant(c, "GetDues")
+	if !ok {
+		return
+	}
+
+	today, err := h.dueService.Today(tenant.UserID, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate dues"})
+		return
+	}
+	dues, err := h.dueService.TenantDues(*tenant, today)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate dues"})
+		return
+	}
+	pending, err := h.proofRepo.PendingTotal(tenant.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate dues"})
+		return
+	}
+
+	c.JSON(http.StatusOK, PortalDues{TenantDues: dues, PendingApproval: pending})
+}
+
+// GetStatement takes the same from, to and format params as the landlord's
+// tenant statement.
+func (h *PortalHandler) GetStatement(c *gin.Context) {
+	tenant, ok := h.currentTenant(c, "GetStatement")
+	if !ok {
+		return
+	}
+
+	today, err := h.dueService.Today(tenant.UserID, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build statement"})
+		return
+	}
+	from, to, ok := statementRange(c, tenant, today)
+	if !ok {
+		return
+	}
+
+	statement, err := h.dueService.Statement(*tenant, from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build statement"})
+		return
+	}
+
+	writeStatement(c, tenant, statement)
+}
+
+// GetReceipts lists the payments the landlord has recorded, newest first.
+func (h *PortalHandler) GetReceipts(c *gin.Context) {
+	tenant, ok := h.currentTenant(c, "GetReceipts")
+	if !ok {
+		return
+	}
+
+	payments, err := h.rentRepo.GetByTenantID(tenant.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch receipts"})
+		return
+	}
+
+	c.JSON(http.StatusOK, payments)
+}
+
+// GetReceipt returns the receipt for one of the tenant's payments.
+// Query params: format (json or pdf).
+func (h *PortalHandler) GetReceipt(c *gin.Context) {
+	tenant, ok := h.currentTenant(c, "GetReceipt")
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	payment, err := h.rentRepo.GetByID(id)
+	if err != nil || payment.TenantID != tenant.ID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "receipt not found"})
+		return
+	}
+
+	writeReceipt(c, h.chargeTypeRepo, payment, tenant)
+}
+
+func (h *PortalHandler) CreateTicket(c *gin.Context) {
+	tenant, ok := h.currentTenant(c, "CreateTicket")
+	if !ok {
+		return
+	}
+
+	var req PortalTicketRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ticket := models.MaintenanceTicket{
+		ID:          uuid.New(),
+		UserID:      tenant.UserID,
+		HouseID:     tenant.HouseID,
+		FlatID:      &tenant.FlatID,
+		TenantID:    &tenant.ID,
+		Title:       req.Title,
+		Description: req.Description,
+		Priority:    req.Priority,
+		Status:      models.TicketOpen,
+	}
+	if ticket.Priority == "" {
+		ticket.Priority = models.TicketPriorityMedium
+	}
+
+	if err := h.maintenanceRepo.Create(c.Request.Context(), &ticket); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create ticket"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, ticket)
+}
+
+func (h *PortalHandler) GetTickets(c *gin.Context) {
+	tenant, ok := h.currentTenant(c, "GetTickets")
+	if !ok {
+		return
+	}
+
+	tickets, err := h.maintenanceRepo.List(tenant.UserID, repository.TicketFilter{TenantID: &tenant.ID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tickets"})
+		return
+	}
+
+	c.JSON(http.StatusOK, tickets)
+}
+
+func (h *PortalHandler) GetTicket(c *gin.Context) {
+	tenant, ok := h.currentTenant(c, "GetTicket")
+	if !ok {
+		return
+	}
+
+	ticket, ok := h.tenantTicket(c, tenant)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, ticket)
+}
+
+func (h *PortalHandler) AddTicketComment(c *gin.Context) {
+	tenant, ok := h.currentTenant(c, "AddTicketComment")
+	if !ok {
+		return
+	}
+
+	var req TicketCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ticket, ok := h.tenantTicket(c, tenant)
+	if !ok {
+		return
+	}
+
+	comment := models.TicketComment{
+		ID:         uuid.New(),
+		TicketID:   ticket.ID,
+		AuthorID:   tenant.ID,
+		AuthorType: models.CommentByTenant,
+		Body:       req.Body,
+	}
+	if err := h.maintenanceRepo.AddComment(c.Request.Context(), &comment); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add comment"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, comment)
+}
+
+// UploadTicketPhoto attaches a photo to one of the tenant's tickets.
+// Multipart field: photo.
+func (h *PortalHandler) UploadTicketPhoto(c *gin.Context) {
+	tenant, ok := h.currentTenant(c, "UploadTicketPhoto")
+	if !ok {
+		return
+	}
+
+	ticket, ok := h.tenantTicket(c, tenant)
+	if !ok {
+		return
+	}
+
+	file, err := c.FormFile("photo")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "photo file is required"})
+		return
+	}
+	if h.s3Service == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "file uploads are not configured"})
+		return
+	}
+
+	photo := models.TicketPhoto{ID: uuid.New(), TicketID: ticket.ID}
+	photo.URL, err = h.s3Service.UploadFile(file, "tickets", fmt.Sprintf("%s_%s", ticket.ID, photo.ID))
+	if err != nil {
+		logger.Log.Error("Failed to upload ticket photo", "ticketID", ticket.ID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload photo"})
+		return
+	}
+
+	if err := h.maintenanceRepo.AddPhoto(c.Request.Context(), &photo); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save photo"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, photo)
+}
+
+// SubmitPaymentProof records a payment the tenant says they made. It is
+// pending until the landlord reviews it and does not reduce dues until
+// then. Multipart form with the fields of models.PaymentProofRequest and an
+// optional screenshot file.
+func (h *PortalHandler) SubmitPaymentProof(c *gin.Context) {
+	tenant, ok := h.currentTenant(c, "SubmitPaymentProof")
+	if !ok {
+		return
+	}
+
+	var req models.PaymentProofRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	proof := submitPaymentProof(c, h.proofRepo, h.s3Service, tenant, req, "tenant")
+	if proof == nil {
+		return
+	}
+
+	h.notifications.NotifyQuietly(c.Request.Context(), tenant.UserID, service.Alert{
+		Event: models.EventPaymentProof,
+		Title: "Payment awaiting approval",
+		Body:  fmt.Sprintf("%s (flat %s) submitted %s for %s via %s.", tenant.Name, tenant.Flat.Number, proof.Amount, proof.Period.Label(), proof.Method),
+		Data:  map[string]string{"proof_id": proof.ID.String(), "tenant_id": tenant.ID.String()},
+	})
+}
+
+func (h *PortalHandler) GetPaymentProofs(c *gin.Context) {
+	tenant, ok := h.currentTenant(c, "GetPaymentProofs")
+	if !ok {
+		return
+	}
+
+	proofs, err := h.proofRepo.GetByTenantID(tenant.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch payments"})
+		return
+	}
+
+	c.JSON(http.StatusOK, proofs)
+}
+
+// currentTenant loads the logged-in tenant. A tenant who has been marked
+// inactive is logged out, however long their token has left. On failure it
+// writes the error response and returns false.
+func (h *PortalHandler) currentTenant(c *gin.Context, handler string) (*models.Tenant, bool) {
+	tenantIDStr, _ := c.Get("tenantID")
+	tenantID, err := uuid.Parse(fmt.Sprintf("%v", tenantIDStr))
+	if err != nil {
+		logger.Log.Error("Failed to parse tenantID from context in "+handler, "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid tenant id"})
+		return nil, false
+	}
+
+	accountIDStr, _ := c.Get("accountID")
+	accountID, err := uuid.Parse(fmt.Sprintf("%v", accountIDStr))
+	if err != nil {
+		logger.Log.Error("Failed to parse accountID from context in "+handler, "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid tenant id"})
+		return nil, false
+	}
+
+	tenant, err := h.tenantRepo.GetByID(tenantID, accountID)
+	if err != nil || !tenant.IsActive {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "tenant not found"})
+		return nil, false
+	}
+	return tenant, true
+}
+
+// tenantTicket loads the ticket named by the id param if it was raised for
+// the tenant.
+func (h *PortalHandler) tenantTicket(c *gin.Context, tenant *models.Tenant) (*models.MaintenanceTicket, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return nil, false
+	}
+
+	ticket, err := h.maintenanceRepo.GetByID(id, tenant.UserID)
+	if err != nil || ticket.TenantID == nil || *ticket.TenantID != tenant.ID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "ticket not found"})
+		return nil, false
+	}
+	return ticket, true
+}
@@ -0,0 +1,234 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"rented-backend/logger"
+	"rented-backend/models"
+	"rented-backend/repository"
+	"rented-backend/service"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type ReminderHandler struct {
+	repo            repository.ReminderRepository
+	messenger       *service.Messenger
+	reminderService *service.ReminderService
+	webhookSecret   string
+}
+
+func NewReminderHandler(repo repository.ReminderRepository, messenger *service.Messenger, reminderService *service.ReminderService, webhookSecret string) *ReminderHandler {
+	return &ReminderHandler{repo: repo, messenger: messenger, reminderService: reminderService, webhookSecret: webhookSecret}
+}
+
+func (h *ReminderHandler) GetSettings(c *gin.Context) {
+	userIDStr, _ := c.Get("userID")
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		logger.Log.Error("Failed to parse userID from context in GetSettings", "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	settings, err := h.repo.GetSettings(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+func (h *ReminderHandler) UpdateSettings(c *gin.Context) {
+	userIDStr, _ := c.Get("userID")
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		logger.Log.Error("Failed to parse userID from context in UpdateSettings", "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	var req models.ReminderSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	settings, err := h.repo.GetSettings(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if settings.ID == uuid.Nil {
+		settings.ID = uuid.New()
+	}
+
+	overdueDays := append([]int{}, req.OverdueDays...)
+	sort.Ints(overdueDays)
+	settings.Enabled = req.Enabled
+	settings.DaysBefore = req.DaysBefore
+	settings.OnDueDate = req.OnDueDate
+	settings.OverdueDays = overdueDays
+	settings.Language = req.Language
+
+	if err := h.repo.SaveSettings(c.Request.Context(), settings); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save reminder settings"})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// GetTemplates lists every message template in both languages, marking the
+// ones still on the built-in wording.
+func (h *ReminderHandler) GetTemplates(c *gin.Context) {
+	userIDStr, _ := c.Get("userID")
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		logger.Log.Error("Failed to parse userID from context in GetTemplates", "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	templates, err := h.messenger.Templates(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, templates)
+}
+
+// UpdateTemplate replaces the wording of one template. Path params: kind,
+// language.
+func (h *ReminderHandler) UpdateTemplate(c *gin.Context) {
+	userIDStr, _ := c.Get("userID")
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		logger.Log.Error("Failed to parse userID from context in UpdateTemplate", "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	kind, lang := c.Param("kind"), c.Param("language")
+	if _, ok := models.DefaultTemplates[kind][lang]; !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "unknown template"})
+		return
+	}
+
+	var req models.MessageTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	templates, err := h.repo.GetTemplates(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	template := models.MessageTemplate{ID: uuid.New(), UserID: userID, Kind: kind, Language: lang}
+	for _, t := range templates {
+		if t.Kind == kind && t.Language == lang {
+			template = t
+		}
+	}
+	template.Body = req.Body
+
+	if err := h.repo.SaveTemplate(c.Request.Context(), &template); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save template"})
+		return
+	}
+
+	c.JSON(http.StatusOK, template)
+}
+
+// GetMessages is the delivery log, newest first.
+// Query params: tenant_id, kind, status.
+func (h *ReminderHandler) GetMessages(c *gin.Context) {
+	userIDStr, _ := c.Get("userID")
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		logger.Log.Error("Failed to parse userID from context in GetMessages", "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	filter := repository.MessageFilter{Kind: c.Query("kind"), Status: c.Query("status")}
+	if tenantStr := c.Query("tenant_id"); tenantStr != "" {
+		tenantID, err := uuid.Parse(tenantStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid tenant_id"})
+			return
+		}
+		filter.TenantID = &tenantID
+	}
+
+	messages, err := h.repo.ListMessages(userID, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch messages"})
+		return
+	}
+
+	c.JSON(http.StatusOK, messages)
+}
+
+// RunReminders sends today's reminders now instead of waiting for the
+// scheduler. Reminders already sent are not repeated.
+func (h *ReminderHandler) RunReminders(c *gin.Context) {
+	userIDStr, _ := c.Get("userID")
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		logger.Log.Error("Failed to parse userID from context in RunReminders", "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	sent, err := h.reminderService.SendReminders(c.Request.Context(), userID, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"sent": sent})
+}
+
+// DeliveryReport is called by the SMS gateway. It must pass the shared
+// secret as the secret query param.
+func (h *ReminderHandler) DeliveryReport(c *gin.Context) {
+	if h.webhookSecret == "" || subtle.ConstantTimeCompare([]byte(c.Query("secret")), []byte(h.webhookSecret)) != 1 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid secret"})
+		return
+	}
+
+	var req models.DeliveryReport
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Gateways report in their own words; keep to ours
+	var status string
+	switch strings.ToLower(req.Status) {
+	case "delivered", "delivrd", "success":
+		status = models.SMSDelivered
+	case "failed", "undeliv", "undelivered", "rejectd", "rejected", "expired":
+		status = models.SMSFailed
+	default:
+		c.JSON(http.StatusOK, gin.H{"updated": 0})
+		return
+	}
+
+	updated, err := h.repo.UpdateDeliveryStatus(req.ProviderID, status)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"updated": updated})
+}
@@ -1,44 +1,127 @@
 package handlers
 
 import (
+	"fmt"
 	"net/http"
+	"rented-backend/logger"
 	"rented-backend/models"
 	"rented-backend/repository"
+	"rented-backend/service"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
 
 type RentHandler struct {
-	repo repository.RentRepository
+	repo           repository.RentRepository
+	chargeTypeRepo repository.ChargeTypeRepository
+	tenantRepo     repository.TenantRepository
+	userRepo       repository.UserRepository
+	notifications  *service.NotificationService
 }
 
-func NewRentHandler(repo repository.RentRepository) *RentHandler {
-	return &RentHandler{repo: repo}
+func NewRentHandler(repo repository.RentRepository, chargeTypeRepo repository.ChargeTypeRepository, tenantRepo repository.TenantRepository, userRepo repository.UserRepository, notifications *service.NotificationService) *RentHandler {
+	return &RentHandler{repo: repo, chargeTypeRepo: chargeTypeRepo, tenantRepo: tenantRepo, userRepo: userRepo, notifications: notifications}
 }
 
+// CreateRent records a payment in the ledger. A payment recorded by a
+// caretaker notifies the landlord.
 func (h *RentHandler) CreateRent(c *gin.Context) {
+	userIDStr, _ := c.Get("userID")
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		logger.Log.Error("Failed to parse userID from context in CreateRent", "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+
 	var rent models.RentPayment
 	if err := c.ShouldBindJSON(&rent); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	rent.ImportBatchID = nil
+
+	tenant, err := h.tenantRepo.GetByID(rent.TenantID, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "tenant not found"})
+		return
+	}
 
-	if err := h.repo.Create(&rent); err != nil {
+	// An advance is a deposit and belongs to no period
+	if rent.IsAdvance != rent.Period.IsZero() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "a payment needs a period and an advance must not have one"})
+		return
+	}
+
+	// Payments are itemised by the landlord's charge types
+	ids := make([]uuid.UUID, 0, len(rent.Items))
+	for _, item := range rent.Items {
+		ids = append(ids, item.ChargeTypeID)
+	}
+	chargeTypes, err := h.chargeTypeRepo.GetByIDs(userID, ids)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	seen := map[uuid.UUID]bool{}
+	for i, item := range rent.Items {
+		chargeType, ok := chargeTypes[item.ChargeTypeID]
+		if !ok || item.Amount < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "each item needs a valid charge_type_id and a non-negative amount"})
+			return
+		}
+		if seen[item.ChargeTypeID] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "each charge type can appear only once in a payment"})
+			return
+		}
+		seen[item.ChargeTypeID] = true
+		rent.Items[i].Code = chargeType.Code
+	}
+
+	if err := h.repo.Create(c.Request.Context(), &rent); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusCreated, rent)
+
+	caretaker := caretakerName(c.Request.Context(), h.userRepo)
+	if caretaker == "" {
+		return
+	}
+	period := rent.Period.Label()
+	if rent.IsAdvance {
+		period = "an advance"
+	}
+	h.notifications.NotifyQuietly(c.Request.Context(), userID, service.Alert{
+		Event: models.EventCaretakerPayment,
+		Title: "Payment recorded",
+		Body:  fmt.Sprintf("%s recorded %s from %s (flat %s) for %s.", caretaker, rent.TotalPaid, tenant.Name, tenant.Flat.Number, period),
+		Data:  map[string]string{"payment_id": rent.ID.String(), "tenant_id": tenant.ID.String()},
+	})
 }
 
 func (h *RentHandler) GetTenantRents(c *gin.Context) {
+	userIDStr, _ := c.Get("userID")
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		logger.Log.Error("Failed to parse userID from context in GetTenantRents", "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+
 	tenantID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid tenant_id"})
 		return
 	}
 
+	if _, err := h.tenantRepo.GetByID(tenantID, userID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "tenant not found"})
+		return
+	}
+
 	rents, err := h.repo.GetByTenantID(tenantID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -48,14 +131,62 @@ func (h *RentHandler) GetTenantRents(c *gin.Context) {
 	c.JSON(http.StatusOK, rents)
 }
 
+// GetReceipt returns a payment's receipt. Query params: format (json or pdf).
+func (h *RentHandler) GetReceipt(c *gin.Context) {
+	userIDStr, _ := c.Get("userID")
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		logger.Log.Error("Failed to parse userID from context in GetReceipt", "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	payment, err := h.repo.GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "payment not found"})
+		return
+	}
+	tenant, err := h.tenantRepo.GetByID(payment.TenantID, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "payment not found"})
+		return
+	}
+
+	writeReceipt(c, h.chargeTypeRepo, payment, tenant)
+}
+
 func (h *RentHandler) DeleteRent(c *gin.Context) {
+	userIDStr, _ := c.Get("userID")
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		logger.Log.Error("Failed to parse userID from context in DeleteRent", "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
 		return
 	}
 
-	if err := h.repo.Delete(id); err != nil {
+	payment, err := h.repo.GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "payment not found"})
+		return
+	}
+	if _, err := h.tenantRepo.GetByID(payment.TenantID, userID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "payment not found"})
+		return
+	}
+
+	if err := h.repo.Delete(c.Request.Context(), id); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
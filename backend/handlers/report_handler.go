@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"net/http"
+	"rented-backend/billing"
+	"rented-backend/logger"
+	"rented-backend/repository"
+	"rented-backend/service"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type ReportHandler struct {
+	dueService     *service.DueService
+	houseRepo      repository.HouseRepository
+	expenseRepo    repository.ExpenseRepository
+	monthlyReports *service.MonthlyReportService
+}
+
+func NewReportHandler(dueService *service.DueService, houseRepo repository.HouseRepository, expenseRepo repository.ExpenseRepository, monthlyReports *service.MonthlyReportService) *ReportHandler {
+	return &ReportHandler{dueService: dueService, houseRepo: houseRepo, expenseRepo: expenseRepo, monthlyReports: monthlyReports}
+}
+
+// GetAging returns the receivables aging report.
+// Query params: group_by (tenant, flat or house; default tenant),
+// sort (total, days_0_30, days_31_60, days_61_90, days_90_plus,
+// oldest_days or name; default total), order (asc or desc; default desc).
+func (h *ReportHandler) GetAging(c *gin.Context) {
+	userIDStr, _ := c.Get("userID")
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		logger.Log.Error("Failed to parse userID from context in GetAging", "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	groupBy := c.DefaultQuery("group_by", service.AgingByTenant)
+	if groupBy != service.AgingByTenant && groupBy != service.AgingByFlat && groupBy != service.AgingByHouse {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "group_by must be tenant, flat or house"})
+		return
+	}
+
+	houses, err := h.houseRepo.GetUserHouses(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	houseNames := map[uuid.UUID]string{}
+	for _, house := range houses {
+		houseNames[house.ID] = house.Name
+	}
+
+	today, err := h.dueService.Today(userID, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	report, err := h.dueService.Aging(userID, today, groupBy, houseNames)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	service.SortAging(report.Rows, c.DefaultQuery("sort", "total"), c.DefaultQuery("order", "desc") != "asc")
+
+	c.JSON(http.StatusOK, report)
+}
+
+// GetNOI returns net operating income per house per month. Takes the same
+// period and house_id query params as the dashboard.
+func (h *ReportHandler) GetNOI(c *gin.Context) {
+	userIDStr, _ := c.Get("userID")
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		logger.Log.Error("Failed to parse userID from context in GetNOI", "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	today, err := h.dueService.Today(userID, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	filter, err := parseDashboardFilter(c, today)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := checkFilterHouse(h.houseRepo, userID, filter); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	rows, err := h.expenseRepo.NetOperatingIncome(userID, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, rows)
+}
+
+// GetMonthlyReport returns the monthly summary that is emailed on the 1st.
+// Query params: month (YYYY-MM, default last month), format (json, csv or
+// pdf; default json).
+func (h *ReportHandler) GetMonthlyReport(c *gin.Context) {
+	userIDStr, _ := c.Get("userID")
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		logger.Log.Error("Failed to parse userID from context in GetMonthlyReport", "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	today, err := h.dueService.Today(userID, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	month := billing.Of(today).AddMonths(-1).Start()
+	if monthStr := c.Query("month"); monthStr != "" {
+		month, err = time.Parse("2006-01", monthStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid month, expected YYYY-MM"})
+			return
+		}
+	}
+
+	report, err := h.monthlyReports.Build(userID, month)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	filename := "summary-" + month.Format("2006-01")
+	switch c.DefaultQuery("format", "json") {
+	case "csv":
+		c.Header("Content-Type", "text/csv")
+		c.Header("Content-Disposition", "attachment; filename=\""+filename+".csv\"")
+		if err := service.WriteMonthlyReportCSV(c.Writer, report); err != nil {
+			logger.Log.Error("Failed to write monthly report", "userID", userID, "error", err)
+		}
+	case "pdf":
+		c.Header("Content-Type", "application/pdf")
+		c.Header("Content-Disposition", "attachment; filename=\""+filename+".pdf\"")
+		if err := service.WriteMonthlyReportPDF(c.Writer, report); err != nil {
+			logger.Log.Error("Failed to write monthly report", "userID", userID, "error", err)
+		}
+	case "json":
+		c.JSON(http.StatusOK, report)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be json, csv or pdf"})
+	}
+}
@@ -0,0 +1,233 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"rented-backend/billing"
+	"rented-backend/logger"
+	"rented-backend/models"
+	"rented-backend/repository"
+	"rented-backend/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type SharedBillHandler struct {
+	repo           repository.SharedBillRepository
+	houseRepo      repository.HouseRepository
+	tenantRepo     repository.TenantRepository
+	chargeTypeRepo repository.ChargeTypeRepository
+}
+
+func NewSharedBillHandler(repo repository.SharedBillRepository, houseRepo repository.HouseRepository, tenantRepo repository.TenantRepository, chargeTypeRepo repository.ChargeTypeRepository) *SharedBillHandler {
+	return &SharedBillHandler{repo: repo, houseRepo: houseRepo, tenantRepo: tenantRepo, chargeTypeRepo: chargeTypeRepo}
+}
+
+// CreateSharedBill splits a house-level bill across the flats occupied in
+// the billed month and posts each tenant's share as a charge for it. A flat
+// that changed hands that month is billed to whoever lived there longer.
+func (h *SharedBillHandler) CreateSharedBill(c *gin.Context) {
+	userIDStr, _ := c.Get("userID")
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		logger.Log.Error("Failed to parse userID from context in CreateSharedBill", "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	houseID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid house id"})
+		return
+	}
+
+	var req models.SharedBillRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Period.IsZero() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "period is required"})
+		return
+	}
+
+	house, err := h.houseRepo.GetHouseByID(houseID)
+	if err != nil || house.UserID != userID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "house not found"})
+		return
+	}
+
+	chargeType, err := h.chargeTypeRepo.GetByID(req.ChargeTypeID, userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid charge_type_id"})
+		return
+	}
+
+	tenants, err := h.tenantRepo.GetOccupantsByHouseID(houseID, req.Period)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// One share per occupied flat
+	occupants := map[uuid.UUID]models.Tenant{}
+	flats := []uuid.UUID{}
+	for _, t := range tenants {
+		current, seen := occupants[t.FlatID]
+		if !seen {
+			flats = append(flats, t.FlatID)
+		}
+		if !seen || daysOccupied(t, req.Period) >= daysOccupied(current, req.Period) {
+			occupants[t.FlatID] = t
+		}
+	}
+
+	participants := []service.SplitParticipant{}
+	for _, flatID := range flats {
+		t := occupants[flatID]
+
+		var basis float64
+		switch req.SplitMethod {
+		case models.SplitEqual:
+			basis = 1
+		case models.SplitHeadcount:
+			basis = float64(max(t.Members, 1))
+		case models.SplitSize:
+			basis = t.Flat.Size
+		case models.SplitCustom:
+			weight, ok := req.Weights[t.FlatID]
+			if !ok {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("missing weight for flat %s", t.Flat.Number)})
+				return
+			}
+			basis = weight
+		}
+		participants = append(participants, service.SplitParticipant{ID: t.FlatID, Basis: basis})
+	}
+
+	shares, err := service.SplitBill(req.TotalAmount, participants)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	bill := models.SharedBill{
+		ID:           uuid.New(),
+		HouseID:      houseID,
+		Kind:         chargeType.Code,
+		ChargeTypeID: chargeType.ID,
+		Period:       req.Period,
+		TotalAmount:  req.TotalAmount,
+		SplitMethod:  req.SplitMethod,
+		Description:  req.Description,
+	}
+
+	charges := []models.Charge{}
+	for _, s := range shares {
+		t := occupants[s.ID]
+		charge := models.Charge{
+			ID:           uuid.New(),
+			TenantID:     t.ID,
+			FlatID:       t.FlatID,
+			Period:       req.Period,
+			Kind:         chargeType.Code,
+			ChargeTypeID: chargeType.ID,
+			Description:  fmt.Sprintf("Shared %s: %.2f%% of %s (%s split)", chargeType.Name, s.Percent, req.TotalAmount, req.SplitMethod),
+			Amount:       s.Amount,
+			SourceType:   models.ChargeSourceSharedBill,
+			SourceID:     bill.ID,
+		}
+		charges = append(charges, charge)
+		bill.Shares = append(bill.Shares, models.SharedBillShare{
+			ID:           uuid.New(),
+			SharedBillID: bill.ID,
+			FlatID:       t.FlatID,
+			FlatNumber:   t.Flat.Number,
+			TenantID:     t.ID,
+			TenantName:   t.Name,
+			Basis:        s.Basis,
+			Percent:      s.Percent,
+			Amount:       s.Amount,
+			ChargeID:     charge.ID,
+		})
+	}
+
+	if err := h.repo.Create(c.Request.Context(), &bill, charges); errors.Is(err, gorm.ErrDuplicatedKey) {
+		c.JSON(http.StatusConflict, gin.H{"error": "a shared bill of this kind, or a charge of its type for one of the tenants, already exists for this month"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create shared bill"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, bill)
+}
+
+func (h *SharedBillHandler) GetHouseSharedBills(c *gin.Context) {
+	userIDStr, _ := c.Get("userID")
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		logger.Log.Error("Failed to parse userID from context in GetHouseSharedBills", "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	houseID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid house id"})
+		return
+	}
+
+	house, err := h.houseRepo.GetHouseByID(houseID)
+	if err != nil || house.UserID != userID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "house not found"})
+		return
+	}
+
+	bills, err := h.repo.GetByHouseID(houseID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch shared bills"})
+		return
+	}
+
+	c.JSON(http.StatusOK, bills)
+}
+
+func (h *SharedBillHandler) GetSharedBill(c *gin.Context) {
+	userIDStr, _ := c.Get("userID")
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		logger.Log.Error("Failed to parse userID from context in GetSharedBill", "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	bill, err := h.repo.GetByID(id, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "shared bill not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, bill)
+}
+
+// daysOccupied counts the days of the period the tenant lived in their flat.
+func daysOccupied(t models.Tenant, period billing.Period) int {
+	from, to := period.Start(), period.End()
+	if t.JoinDate.After(from) {
+		from = t.JoinDate
+	}
+	if t.LeaveDate != nil && t.LeaveDate.Before(to) {
+		to = t.LeaveDate.AddDate(0, 0, 1)
+	}
+	return int(to.Sub(from).Hours() / 24)
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"rented-backend/logger"
+	"rented-backend/models"
+	"rented-backend/service"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type TenantAuthHandler struct {
+	authService *service.TenantAuthService
+}
+
+func NewTenantAuthHandler(authService *service.TenantAuthService) *TenantAuthHandler {
+	return &TenantAuthHandler{authService: authService}
+}
+
+// codeSentResponse is the same whether or not the phone belongs to a
+// tenant.
+var codeSentResponse = gin.H{"message": "if the number belongs to a tenant, a message has been sent"}
+
+func (h *TenantAuthHandler) RequestOTP(c *gin.Context) {
+	var req models.TenantLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.authService.RequestOTP(c.Request.Context(), req.Phone); err != nil {
+		h.requestError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, codeSentResponse)
+}
+
+func (h *TenantAuthHandler) RequestLink(c *gin.Context) {
+	var req models.TenantLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.authService.RequestLink(c.Request.Context(), req.Phone); err != nil {
+		h.requestError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, codeSentResponse)
+}
+
+func (h *TenantAuthHandler) VerifyOTP(c *gin.Context) {
+	var req models.TenantOTPVerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tenant, err := h.authService.VerifyOTP(req.Phone, req.Code, req.TenantID)
+	h.login(c, tenant, err)
+}
+
+func (h *TenantAuthHandler) VerifyLink(c *gin.Context) {
+	var req models.TenantLinkVerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tenant, err := h.authService.VerifyLink(req.Token, req.TenantID)
+	h.login(c, tenant, err)
+}
+
+// login answers a verify request: a token for the tenant, or the list of
+// tenancies to choose from when the phone matches more than one.
+func (h *TenantAuthHandler) login(c *gin.Context, tenant *models.Tenant, err error) {
+	var choose *service.ChooseTenantError
+	switch {
+	case errors.As(err, &choose):
+		options := make([]gin.H, 0, len(choose.Tenants))
+		for _, t := range choose.Tenants {
+			options = append(options, gin.H{"tenant_id": t.ID, "name": t.Name, "flat_number": t.Flat.Number})
+		}
+		c.JSON(http.StatusConflict, gin.H{"error": choose.Error(), "tenants": options})
+		return
+	case errors.Is(err, service.ErrInvalidLoginCode), errors.Is(err, service.ErrTooManyAttempts):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		logger.Log.Error("Failed to verify tenant login", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to verify code"})
+		return
+	}
+
+	token, err := h.generateToken(*tenant)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.TenantAuthResponse{Tenant: *tenant, Token: token})
+}
+
+func (h *TenantAuthHandler) requestError(c *gin.Context, err error) {
+	logger.Log.Error("Failed to send tenant login code", "error", err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to send code"})
+}
+
+// generateToken issues a portal token. It is scoped to the tenant and
+// rejected by AuthMiddleware.
+func (h *TenantAuthHandler) generateToken(tenant models.Tenant) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub":   tenant.ID.String(),
+		"acct":  tenant.UserID.String(),
+		"scope": models.TokenScopeTenant,
+		"exp":   time.Now().Add(time.Hour * 24 * 7).Unix(),
+	})
+
+	jwtSecret := os.Getenv("JWT_SECRET")
+	if jwtSecret == "" {
+		jwtSecret = "default_secret" // fallback for development
+		log.Println("WARNING: JWT_SECRET not set, using default")
+	}
+
+	return token.SignedString([]byte(jwtSecret))
+}
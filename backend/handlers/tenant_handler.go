@@ -1,10 +1,13 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
+	"rented-backend/billing"
 	"rented-backend/logger"
 	"rented-backend/models"
+	"rented-backend/money"
 	"rented-backend/repository"
 	"rented-backend/service"
 	"strconv"
@@ -12,25 +15,28 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type TenantHandler struct {
-	repo      repository.TenantRepository
-	rentRepo  repository.RentRepository
-	houseRepo repository.HouseRepository
-	s3Service *service.S3Service
+	repo       repository.TenantRepository
+	rentRepo   repository.RentRepository
+	houseRepo  repository.HouseRepository
+	dueService *service.DueService
+	s3Service  *service.S3Service
 }
 
 type TenantResponse struct {
 	models.Tenant
-	DueAmount  float64 `json:"due_amount"`
-	TotalPaid  float64 `json:"total_paid"`
-	HouseName  string  `json:"house_name"`
-	FlatNumber string  `json:"flat_number"`
+	DueAmount   money.Amount `json:"due_amount"`
+	TotalPaid   money.Amount `json:"total_paid"`
+	AdvanceHeld money.Amount `json:"advance_held"`
+	HouseName   string       `json:"house_name"`
+	FlatNumber  string       `json:"flat_number"`
 }
 
-func NewTenantHandler(repo repository.TenantRepository, rentRepo repository.RentRepository, houseRepo repository.HouseRepository, s3Service *service.S3Service) *TenantHandler {
-	return &TenantHandler{repo: repo, rentRepo: rentRepo, houseRepo: houseRepo, s3Service: s3Service}
+func NewTenantHandler(repo repository.TenantRepository, rentRepo repository.RentRepository, houseRepo repository.HouseRepository, dueService *service.DueService, s3Service *service.S3Service) *TenantHandler {
+	return &TenantHandler{repo: repo, rentRepo: rentRepo, houseRepo: houseRepo, dueService: dueService, s3Service: s3Service}
 }
 
 func (h *TenantHandler) CreateTenant(c *gin.Context) {
@@ -66,12 +72,34 @@ func (h *TenantHandler) CreateTenant(c *gin.Context) {
 		return
 	}
 
+	house, err := h.houseRepo.GetHouseByID(houseID)
+	if err != nil || house.UserID != userID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "house not found"})
+		return
+	}
+	flat, err := h.houseRepo.GetFlatByID(flatID)
+	if err != nil || flat.HouseID != house.ID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "flat not found"})
+		return
+	}
+
 	nidNumber := c.PostForm("nid_number")
-	advanceAmount, _ := strconv.ParseFloat(c.PostForm("advance_amount"), 64)
-	joinDateStr := c.PostForm("join_date")
-	joinDate, _ := time.Parse(time.RFC3339, joinDateStr)
-	if joinDate.IsZero() {
-		joinDate = time.Now()
+	advanceAmount, _ := money.Parse(c.PostForm("advance_amount"))
+	members, _ := strconv.Atoi(c.PostForm("members"))
+	if members < 1 {
+		members = 1
+	}
+	loc, err := h.dueService.Location(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	joinDate := billing.Today(time.Now(), loc)
+	if joinDateStr := c.PostForm("join_date"); joinDateStr != "" {
+		if joinDate, err = billing.ParseDate(joinDateStr, loc); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid join_date, expected YYYY-MM-DD"})
+			return
+		}
 	}
 
 	tenant := models.Tenant{
@@ -81,6 +109,7 @@ func (h *TenantHandler) CreateTenant(c *gin.Context) {
 		FlatID:        flatID,
 		Name:          name,
 		Phone:         phone,
+		Members:       members,
 		NIDNumber:     nidNumber,
 		AdvanceAmount: advanceAmount,
 		JoinDate:      joinDate,
@@ -105,22 +134,18 @@ func (h *TenantHandler) CreateTenant(c *gin.Context) {
 		}
 	}
 
-	if err := h.repo.Create(&tenant); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create tenant"})
-		return
-	}
-
-	// Create Advance Payment record
+	// The advance is recorded as a payment alongside the tenant
 	if tenant.AdvanceAmount > 0 {
-		advanceRecord := models.RentPayment{
-			TenantID:    tenant.ID,
-			Month:       "Advance",
-			Year:        tenant.JoinDate.Year(),
+		err = h.repo.CreateWithAdvance(c.Request.Context(), &tenant, &models.RentPayment{
 			TotalPaid:   tenant.AdvanceAmount,
-			IsAdvance:   true,
 			PaymentDate: time.Now(),
-		}
-		_ = h.rentRepo.Create(&advanceRecord)
+		})
+	} else {
+		err = h.repo.Create(c.Request.Context(), &tenant)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create tenant"})
+		return
 	}
 
 	c.JSON(http.StatusCreated, tenant)
@@ -142,51 +167,48 @@ func (h *TenantHandler) GetTenants(c *gin.Context) {
 		return
 	}
 
-	responses := []TenantResponse{}
-	for _, t := range tenants {
-		rents, _ := h.rentRepo.GetByTenantID(t.ID)
-		var totalPaid float64
-		for _, r := range rents {
-			totalPaid += r.TotalPaid
-		}
+	today, err := h.dueService.Today(userID, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	dues, err := h.dueService.AllTenantDues(tenants, today)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 
-		// Calculate months since JoinDate
-		now := time.Now()
-		months := 0
-		if !t.JoinDate.IsZero() && t.IsActive {
-			years := now.Year() - t.JoinDate.Year()
-			months = years*12 + int(now.Month()) - int(t.JoinDate.Month()) + 1
-		}
+	c.JSON(http.StatusOK, tenantResponses(h.houseRepo, tenants, dues))
+}
 
-		// Use Flat's BasicRent for calculation
-		expectedTotal := float64(months) * t.Flat.BasicRent
-		due := expectedTotal - totalPaid
-		if due < 0 {
-			due = 0
-		}
+// tenantResponses adds each tenant's dues and house and flat names.
+func tenantResponses(houseRepo repository.HouseRepository, tenants []models.Tenant, dues map[uuid.UUID]*service.TenantDues) []TenantResponse {
+	responses := []TenantResponse{}
+	for _, t := range tenants {
+		d := dues[t.ID]
 
 		houseName := "Unknown"
-		house, err := h.houseRepo.GetHouseByID(t.HouseID)
+		house, err := houseRepo.GetHouseByID(t.HouseID)
 		if err == nil {
 			houseName = house.Name
 		}
 
 		flatNumber := "Unknown"
-		flat, err := h.houseRepo.GetFlatByID(t.FlatID)
+		flat, err := houseRepo.GetFlatByID(t.FlatID)
 		if err == nil {
 			flatNumber = flat.Number
 		}
 
 		responses = append(responses, TenantResponse{
-			Tenant:     t,
-			DueAmount:  due,
-			TotalPaid:  totalPaid,
-			HouseName:  houseName,
-			FlatNumber: flatNumber,
+			Tenant:      t,
+			DueAmount:   d.TotalDue,
+			TotalPaid:   d.TotalPaid,
+			AdvanceHeld: d.AdvanceHeld,
+			HouseName:   houseName,
+			FlatNumber:  flatNumber,
 		})
 	}
-
-	c.JSON(http.StatusOK, responses)
+	return responses
 }
 
 func (h *TenantHandler) GetTenant(c *gin.Context) {
@@ -213,6 +235,135 @@ func (h *TenantHandler) GetTenant(c *gin.Context) {
 	c.JSON(http.StatusOK, tenant)
 }
 
+// GetTenantDues returns the tenant's month-by-month due breakdown.
+func (h *TenantHandler) GetTenantDues(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	userIDStr, _ := c.Get("userID")
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		logger.Log.Error("Failed to parse userID from context in GetTenantDues", "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	tenant, err := h.repo.GetByID(id, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "tenant not found"})
+		return
+	}
+
+	today, err := h.dueService.Today(userID, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	dues, err := h.dueService.TenantDues(*tenant, today)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, dues)
+}
+
+// GetTenantStatement returns the tenant's account statement.
+// Query params: from, to (YYYY-MM-DD, inclusive; default the join date and
+// today), format (json, csv or pdf).
+func (h *TenantHandler) GetTenantStatement(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	userIDStr, _ := c.Get("userID")
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		logger.Log.Error("Failed to parse userID from context in GetTenantStatement", "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	tenant, err := h.repo.GetByID(id, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "tenant not found"})
+		return
+	}
+
+	today, err := h.dueService.Today(userID, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	from, to, ok := statementRange(c, tenant, today)
+	if !ok {
+		return
+	}
+
+	statement, err := h.dueService.Statement(*tenant, from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	writeStatement(c, tenant, statement)
+}
+
+// statementRange reads the from and to query params (YYYY-MM-DD), which
+// default to the tenant's join date and today, the account's current
+// date. On a bad value it writes the error response and returns false.
+func statementRange(c *gin.Context, tenant *models.Tenant, today time.Time) (time.Time, time.Time, bool) {
+	var err error
+	from := time.Date(tenant.JoinDate.Year(), tenant.JoinDate.Month(), tenant.JoinDate.Day(), 0, 0, 0, 0, time.UTC)
+	to := today
+	if fromStr := c.Query("from"); fromStr != "" {
+		if from, err = time.Parse("2006-01-02", fromStr); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from date, expected YYYY-MM-DD"})
+			return from, to, false
+		}
+	}
+	if toStr := c.Query("to"); toStr != "" {
+		if to, err = time.Parse("2006-01-02", toStr); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to date, expected YYYY-MM-DD"})
+			return from, to, false
+		}
+	}
+	if to.Before(from) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to must not be before from"})
+		return from, to, false
+	}
+	return from, to, true
+}
+
+// writeStatement sends the statement as json, csv or pdf, picked by the
+// format query param.
+func writeStatement(c *gin.Context, tenant *models.Tenant, statement *service.Statement) {
+	var err error
+	filename := fmt.Sprintf("statement-%s-%s-%s", tenant.Flat.Number, statement.From.Format("20060102"), statement.To.Format("20060102"))
+	switch c.DefaultQuery("format", "json") {
+	case "csv":
+		c.Header("Content-Type", "text/csv")
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename+".csv"))
+		err = service.WriteStatementCSV(c.Writer, statement)
+	case "pdf":
+		c.Header("Content-Type", "application/pdf")
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename+".pdf"))
+		err = service.WriteStatementPDF(c.Writer, statement)
+	case "json":
+		c.JSON(http.StatusOK, statement)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be json, csv or pdf"})
+	}
+	if err != nil {
+		logger.Log.Error("Failed to write statement", "tenantID", tenant.ID, "error", err)
+	}
+}
+
 func (h *TenantHandler) UpdateTenantStatus(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
@@ -229,14 +380,32 @@ func (h *TenantHandler) UpdateTenantStatus(c *gin.Context) {
 	}
 
 	var input struct {
-		IsActive bool `json:"is_active"`
+		IsActive  bool   `json:"is_active"`
+		LeaveDate string `json:"leave_date"` // YYYY-MM-DD, the last day occupied; defaults to today when deactivating
 	}
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	if err := h.repo.UpdateStatus(id, userID, input.IsActive); err != nil {
+	var leaveDate *time.Time
+	if !input.IsActive {
+		loc, err := h.dueService.Location(userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		date := billing.Today(time.Now(), loc)
+		if input.LeaveDate != "" {
+			if date, err = billing.ParseDate(input.LeaveDate, loc); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid leave_date, expected YYYY-MM-DD"})
+				return
+			}
+		}
+		leaveDate = &date
+	}
+
+	if err := h.repo.UpdateStatus(c.Request.Context(), id, userID, input.IsActive, leaveDate); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -268,11 +437,80 @@ func (h *TenantHandler) UpdateTenant(c *gin.Context) {
 	}
 	tenant.ID = id
 	tenant.UserID = userID
+	if tenant.JoinDate.IsZero() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "join_date is required"})
+		return
+	}
 
-	if err := h.repo.Update(&tenant); err != nil {
+	// Dates are calendar dates, whatever instant the client sent for them
+	loc, err := h.dueService.Location(userID)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	tenant.JoinDate = billing.DateOf(tenant.JoinDate, loc)
+	if tenant.LeaveDate != nil {
+		leaveDate := billing.DateOf(*tenant.LeaveDate, loc)
+		tenant.LeaveDate = &leaveDate
+	}
+	if tenant.LeaseEndDate != nil {
+		leaseEnd := billing.DateOf(*tenant.LeaseEndDate, loc)
+		tenant.LeaseEndDate = &leaseEnd
+	}
+
+	// Messaging preferences have their own endpoint, which tenants use too
+	existing, err := h.repo.GetByID(id, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "tenant not found"})
+		return
+	}
+	tenant.SMSOptOut = existing.SMSOptOut
+	tenant.Language = existing.Language
+	tenant.ImportBatchID = existing.ImportBatchID
+
+	if err := h.repo.Update(c.Request.Context(), &tenant); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, tenant)
+}
+
+// UpdateTenantNotifications sets whether the tenant receives SMS and in
+// which language.
+func (h *TenantHandler) UpdateTenantNotifications(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	userIDStr, _ := c.Get("userID")
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		logger.Log.Error("Failed to parse userID from context in UpdateTenantNotifications", "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	var req models.TenantNotificationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tenant, err := h.repo.GetByID(id, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "tenant not found"})
+		return
+	}
+	tenant.SMSOptOut = req.SMSOptOut
+	tenant.Language = req.Language
+
+	if err := h.repo.UpdateNotifications(c.Request.Context(), tenant); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update tenant"})
+		return
+	}
 
 	c.JSON(http.StatusOK, tenant)
 }
@@ -292,7 +530,12 @@ func (h *TenantHandler) DeleteTenant(c *gin.Context) {
 		return
 	}
 
-	if err := h.repo.Delete(id, userID); err != nil {
+	err = h.repo.Delete(c.Request.Context(), id, userID)
+	if errors.Is(err, gorm.ErrForeignKeyViolated) {
+		c.JSON(http.StatusConflict, gin.H{"error": "the tenant has payments, charges or payment proofs; mark them as left instead"})
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
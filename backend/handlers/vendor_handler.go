@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"net/http"
+	"rented-backend/logger"
+	"rented-backend/models"
+	"rented-backend/repository"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type VendorHandler struct {
+	repo repository.VendorRepository
+}
+
+func NewVendorHandler(repo repository.VendorRepository) *VendorHandler {
+	return &VendorHandler{repo: repo}
+}
+
+type VendorRequest struct {
+	Name           string `json:"name" binding:"required"`
+	Trade          string `json:"trade"`
+	Phone          string `json:"phone"`
+	PaymentDetails string `json:"payment_details"`
+	Notes          string `json:"notes"`
+	Rating         int    `json:"rating" binding:"min=0,max=5"`
+	IsActive       *bool  `json:"is_active"`
+}
+
+func (h *VendorHandler) CreateVendor(c *gin.Context) {
+	userIDStr, _ := c.Get("userID")
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		logger.Log.Error("Failed to parse userID from context in CreateVendor", "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	var req VendorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	vendor := models.Vendor{
+		ID:             uuid.New(),
+		UserID:         userID,
+		Name:           req.Name,
+		Trade:          req.Trade,
+		Phone:          req.Phone,
+		PaymentDetails: req.PaymentDetails,
+		Notes:          req.Notes,
+		Rating:         req.Rating,
+		IsActive:       true,
+	}
+
+	if err := h.repo.Create(c.Request.Context(), &vendor); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create vendor"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, vendor)
+}
+
+// GetVendors lists vendors by name. Query params: trade.
+func (h *VendorHandler) GetVendors(c *gin.Context) {
+	userIDStr, _ := c.Get("userID")
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		logger.Log.Error("Failed to parse userID from context in GetVendors", "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	vendors, err := h.repo.List(userID, c.Query("trade"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch vendors"})
+		return
+	}
+
+	c.JSON(http.StatusOK, vendors)
+}
+
+func (h *VendorHandler) UpdateVendor(c *gin.Context) {
+	userIDStr, _ := c.Get("userID")
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		logger.Log.Error("Failed to parse userID from context in UpdateVendor", "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req VendorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	vendor, err := h.repo.GetByID(id, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "vendor not found"})
+		return
+	}
+
+	vendor.Name = req.Name
+	vendor.Trade = req.Trade
+	vendor.Phone = req.Phone
+	vendor.PaymentDetails = req.PaymentDetails
+	vendor.Notes = req.Notes
+	vendor.Rating = req.Rating
+	if req.IsActive != nil {
+		vendor.IsActive = *req.IsActive
+	}
+
+	if err := h.repo.Update(c.Request.Context(), vendor); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update vendor"})
+		return
+	}
+
+	c.JSON(http.StatusOK, vendor)
+}
+
+// GetVendor returns the vendor with its jobs (tickets), payments
+// (expenses) and spend per year.
+func (h *VendorHandler) GetVendor(c *gin.Context) {
+	userIDStr, _ := c.Get("userID")
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		logger.Log.Error("Failed to parse userID from context in GetVendor", "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	vendor, err := h.repo.GetByID(id, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "vendor not found"})
+		return
+	}
+
+	history := models.VendorHistory{Vendor: *vendor}
+	if history.Jobs, err = h.repo.GetJobs(vendor.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch vendor jobs"})
+		return
+	}
+	if history.Payments, err = h.repo.GetPayments(vendor.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch vendor payments"})
+		return
+	}
+	if history.YearlySpend, err = h.repo.YearlySpend(userID, &vendor.ID, 0); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch vendor spend"})
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+// GetVendorSpend totals spend per vendor per year, largest first.
+// Query params: year (default all years).
+func (h *VendorHandler) GetVendorSpend(c *gin.Context) {
+	userIDStr, _ := c.Get("userID")
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		logger.Log.Error("Failed to parse userID from context in GetVendorSpend", "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	year := 0
+	if yearStr := c.Query("year"); yearStr != "" {
+		year, err = strconv.Atoi(yearStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid year"})
+			return
+		}
+	}
+
+	spend, err := h.repo.YearlySpend(userID, nil, year)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch vendor spend"})
+		return
+	}
+
+	c.JSON(http.StatusOK, spend)
+}
@@ -0,0 +1,42 @@
+package mail
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"rented-backend/logger"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LogMailer writes each message to an .eml file in a directory, or logs
+// its envelope when no directory is set, instead of sending it.
+type LogMailer struct {
+	from string
+	dir  string
+}
+
+func NewLogMailer(from, dir string) *LogMailer {
+	if from == "" {
+		from = "rented@localhost"
+	}
+	return &LogMailer{from: from, dir: dir}
+}
+
+func (m *LogMailer) Send(ctx context.Context, msg Message) error {
+	if m.dir == "" {
+		logger.Log.Info("Email", "to", msg.To, "subject", msg.Subject, "attachments", len(msg.Attachments))
+		return nil
+	}
+
+	data, err := build(m.from, msg)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(m.dir, 0o755); err != nil {
+		return err
+	}
+	name := time.Now().Format("20060102-150405") + "-" + uuid.NewString()[:8] + ".eml"
+	return os.WriteFile(filepath.Join(m.dir, name), data, 0o644)
+}
@@ -0,0 +1,51 @@
+// Package mail sends email. Drivers: SMTP for production and a log driver
+// that writes .eml files for development.
+package mail
+
+import (
+	"context"
+	"fmt"
+)
+
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+type Message struct {
+	To          string
+	Subject     string
+	Text        string
+	Headers     map[string]string // extra headers, e.g. List-Unsubscribe
+	Attachments []Attachment
+}
+
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+type Config struct {
+	Driver   string // smtp or log
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+	LogDir   string // log driver only; empty logs to the application log
+}
+
+// New returns the driver named in cfg.
+func New(cfg Config) (Mailer, error) {
+	switch cfg.Driver {
+	case "", "log":
+		return NewLogMailer(cfg.From, cfg.LogDir), nil
+	case "smtp":
+		if cfg.Host == "" || cfg.From == "" {
+			return nil, fmt.Errorf("SMTP host and from address are required for the smtp driver")
+		}
+		return NewSMTPMailer(cfg.Host, cfg.Port, cfg.Username, cfg.Password, cfg.From), nil
+	default:
+		return nil, fmt.Errorf("unknown mail driver %q", cfg.Driver)
+	}
+}
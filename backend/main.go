@@ -1,14 +1,21 @@
 package main
 
 import (
+	"context"
 	"log"
+	"os"
 	"rented-backend/config"
 	"rented-backend/database"
 	"rented-backend/handlers"
 	"rented-backend/logger"
+	"rented-backend/mail"
+	"rented-backend/notify"
+	"rented-backend/push"
 	"rented-backend/repository"
 	"rented-backend/router"
+	"rented-backend/scheduler"
 	"rented-backend/service"
+	"time"
 )
 
 func main() {
@@ -20,6 +27,13 @@ func main() {
 
 	// Initialize Logger
 	logger.InitLogger(cfg.Env)
+
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(cfg, os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
 	logger.Log.Info("Starting application", "env", cfg.Env)
 
 	// Initialize database
@@ -31,19 +45,107 @@ func main() {
 		log.Printf("Warning: S3 service not initialized: %v. Image uploads will fail.", err)
 	}
 
+	chargeTypeRepo := repository.NewChargeTypeRepository()
+	chargeTypeHandler := handlers.NewChargeTypeHandler(chargeTypeRepo)
+
+	tenantRepo := repository.NewTenantRepository()
+
 	rentRepo := repository.NewRentRepository()
-	rentHandler := handlers.NewRentHandler(rentRepo)
 
 	houseRepo := repository.NewHouseRepository()
-	houseHandler := handlers.NewHouseHandler(houseRepo)
-
-	tenantRepo := repository.NewTenantRepository()
-	tenantHandler := handlers.NewTenantHandler(tenantRepo, rentRepo, houseRepo, s3Service)
+	houseHandler := handlers.NewHouseHandler(houseRepo, chargeTypeRepo)
 
 	userRepo := repository.NewUserRepository()
 	authHandler := handlers.NewAuthHandler(userRepo)
 
-	dashboardHandler := handlers.NewDashboardHandler(rentRepo)
+	auditRepo := repository.NewAuditRepository()
+	auditHandler := handlers.NewAuditHandler(auditRepo)
+
+	meterRepo := repository.NewMeterRepository()
+	meterHandler := handlers.NewMeterHandler(meterRepo, houseRepo, tenantRepo, chargeTypeRepo)
+
+	chargeRepo := repository.NewChargeRepository()
+	chargeHandler := handlers.NewChargeHandler(chargeRepo, tenantRepo, chargeTypeRepo)
+
+	pusher, err := push.New(context.Background(), push.Config{
+		Driver:          cfg.PushDriver,
+		ProjectID:       cfg.FCMProjectID,
+		CredentialsFile: cfg.FCMCredentialsFile,
+	})
+	if err != nil {
+		log.Fatalf("Failed to set up push notifications: %v", err)
+	}
+	notificationRepo := repository.NewNotificationRepository()
+	notificationService := service.NewNotificationService(notificationRepo, pusher)
+	notificationHandler := handlers.NewNotificationHandler(notificationRepo)
+	rentHandler := handlers.NewRentHandler(rentRepo, chargeTypeRepo, tenantRepo, userRepo, notificationService)
+	leaseAlertService := service.NewLeaseAlertService(notificationService, userRepo, tenantRepo)
+
+	policyRepo := repository.NewPolicyRepository()
+	dueService := service.NewDueService(rentRepo, chargeRepo, chargeTypeRepo, policyRepo, tenantRepo, userRepo)
+	billingService := service.NewBillingService(dueService, userRepo, tenantRepo, chargeRepo, chargeTypeRepo, policyRepo, notificationService)
+	policyHandler := handlers.NewPolicyHandler(policyRepo, houseRepo, billingService)
+
+	tenantHandler := handlers.NewTenantHandler(tenantRepo, rentRepo, houseRepo, dueService, s3Service)
+	dashboardHandler := handlers.NewDashboardHandler(houseRepo, dueService)
+
+	vendorRepo := repository.NewVendorRepository()
+	vendorHandler := handlers.NewVendorHandler(vendorRepo)
+
+	expenseRepo := repository.NewExpenseRepository()
+	expenseService := service.NewExpenseService(expenseRepo, userRepo)
+	expenseHandler := handlers.NewExpenseHandler(expenseRepo, houseRepo, vendorRepo, s3Service)
+	mailer, err := mail.New(mail.Config{
+		Driver:   cfg.MailDriver,
+		Host:     cfg.SMTPHost,
+		Port:     cfg.SMTPPort,
+		Username: cfg.SMTPUsername,
+		Password: cfg.SMTPPassword,
+		From:     cfg.MailFrom,
+		LogDir:   cfg.MailLogDir,
+	})
+	if err != nil {
+		log.Fatalf("Failed to set up email: %v", err)
+	}
+	emailRepo := repository.NewEmailRepository()
+	emailHandler := handlers.NewEmailHandler(emailRepo)
+	monthlyReportService := service.NewMonthlyReportService(dueService, houseRepo, tenantRepo, expenseRepo, userRepo, emailRepo, mailer, cfg.EmailUnsubscribeURL, cfg.EmailPreferencesURL)
+	reportHandler := handlers.NewReportHandler(dueService, houseRepo, expenseRepo, monthlyReportService)
+	exportHandler := handlers.NewExportHandler(tenantRepo, houseRepo, rentRepo, expenseRepo, userRepo, dueService)
+
+	importRepo := repository.NewImportRepository()
+	importService := service.NewImportService(importRepo, houseRepo, tenantRepo, chargeTypeRepo)
+	importHandler := handlers.NewImportHandler(importRepo, importService)
+
+	maintenanceRepo := repository.NewMaintenanceRepository()
+	maintenanceHandler := handlers.NewMaintenanceHandler(maintenanceRepo, houseRepo, tenantRepo, chargeTypeRepo, vendorRepo, userRepo, s3Service)
+
+	tenantAuthRepo := repository.NewTenantAuthRepository()
+	notifier, err := notify.New(notify.Config{
+		Driver:   cfg.SMSDriver,
+		URL:      cfg.SMSGatewayURL,
+		APIKey:   cfg.SMSAPIKey,
+		SenderID: cfg.SMSSenderID,
+		LogFile:  cfg.SMSLogFile,
+	})
+	if err != nil {
+		log.Fatalf("Failed to set up SMS: %v", err)
+	}
+	reminderRepo := repository.NewReminderRepository()
+	messenger := service.NewMessenger(reminderRepo, notifier)
+	reminderService := service.NewReminderService(messenger, reminderRepo, dueService, userRepo, tenantRepo)
+	reminderHandler := handlers.NewReminderHandler(reminderRepo, messenger, reminderService, cfg.SMSWebhookSecret)
+
+	tenantAuthService := service.NewTenantAuthService(tenantAuthRepo, tenantRepo, notifier, cfg.TenantPortalURL)
+	tenantAuthHandler := handlers.NewTenantAuthHandler(tenantAuthService)
+
+	proofRepo := repository.NewPaymentProofRepository()
+	proofService := service.NewPaymentProofService(proofRepo, tenantRepo, chargeTypeRepo, dueService, messenger)
+	proofHandler := handlers.NewPaymentProofHandler(proofRepo, tenantRepo, userRepo, proofService, notificationService, s3Service)
+	portalHandler := handlers.NewPortalHandler(tenantRepo, rentRepo, maintenanceRepo, proofRepo, chargeTypeRepo, dueService, notificationService, s3Service)
+
+	sharedBillRepo := repository.NewSharedBillRepository()
+	sharedBillHandler := handlers.NewSharedBillHandler(sharedBillRepo, houseRepo, tenantRepo, chargeTypeRepo)
 
 	r := router.SetupRouter(
 		authHandler,
@@ -51,7 +153,35 @@ func main() {
 		tenantHandler,
 		rentHandler,
 		dashboardHandler,
+		auditHandler,
+		meterHandler,
+		chargeHandler,
+		sharedBillHandler,
+		chargeTypeHandler,
+		policyHandler,
+		reportHandler,
+		expenseHandler,
+		maintenanceHandler,
+		vendorHandler,
+		tenantAuthHandler,
+		portalHandler,
+		proofHandler,
+		reminderHandler,
+		notificationHandler,
+		emailHandler,
+		exportHandler,
+		importHandler,
 	)
 
+	// Background jobs. They run hourly so each account's day rolls over
+	// soon after its own midnight; every job is safe to repeat.
+	jobs := scheduler.New()
+	jobs.Every(time.Hour, "late-fees", billingService.ApplyAllLateFees)
+	jobs.Every(time.Hour, "recurring-expenses", expenseService.GenerateRecurring)
+	jobs.Every(time.Hour, "rent-reminders", reminderService.SendAllReminders)
+	jobs.Every(time.Hour, "lease-alerts", leaseAlertService.NotifyAllExpiring)
+	jobs.Every(time.Hour, "monthly-summary", monthlyReportService.SendAll)
+	jobs.Start(context.Background())
+
 	log.Fatal(r.Run(":" + cfg.AppPort))
 }
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"fmt"
+	"rented-backend/audit"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// AuditMiddleware attaches the authenticated user and client IP to the
+// request context so database mutations can be attributed. It must run
+// after AuthMiddleware or TenantAuthMiddleware.
+func AuditMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if tenantIDStr, ok := c.Get("tenantID"); ok {
+			tenantID, err := uuid.Parse(fmt.Sprintf("%v", tenantIDStr))
+			accountIDStr, _ := c.Get("accountID")
+			accountID, accErr := uuid.Parse(fmt.Sprintf("%v", accountIDStr))
+			if err == nil && accErr == nil {
+				ctx := audit.WithActor(c.Request.Context(), audit.Actor{
+					AccountID: accountID,
+					ID:        tenantID,
+					Type:      audit.ActorTenant,
+					IP:        c.ClientIP(),
+				})
+				c.Request = c.Request.WithContext(ctx)
+			}
+			c.Next()
+			return
+		}
+
+		userIDStr, _ := c.Get("userID")
+		userID, err := uuid.Parse(fmt.Sprintf("%v", userIDStr))
+		if err == nil {
+			actor := audit.Actor{
+				AccountID: userID,
+				ID:        userID,
+				Type:      audit.ActorUser,
+				IP:        c.ClientIP(),
+			}
+			if caretakerIDStr, ok := c.Get("caretakerID"); ok {
+				if caretakerID, err := uuid.Parse(fmt.Sprintf("%v", caretakerIDStr)); err == nil {
+					actor.ID = caretakerID
+					actor.Type = audit.ActorCaretaker
+				}
+			}
+			c.Request = c.Request.WithContext(audit.WithActor(c.Request.Context(), actor))
+		}
+		c.Next()
+	}
+}
@@ -4,51 +4,109 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"rented-backend/models"
+	"rented-backend/repository"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
+// AuthMiddleware admits landlord tokens only. Tenant portal tokens are
+// rejected so a tenant can never reach landlord endpoints. userID is the
+// account; a caretaker's token also sets caretakerID, and stops working
+// once the owner removes them.
 func AuthMiddleware() gin.HandlerFunc {
+	userRepo := repository.NewUserRepository()
 	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
+		claims, ok := parseToken(c)
+		if !ok {
 			return
 		}
 
-		parts := strings.SplitN(authHeader, " ", 2)
-		if !(len(parts) == 2 && parts[0] == "Bearer") {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization header must be Bearer token"})
+		if scope, _ := claims["scope"].(string); scope != "" && scope != models.TokenScopeLandlord {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "token is not valid for this endpoint"})
 			return
 		}
 
-		tokenString := parts[1]
-		jwtSecret := os.Getenv("JWT_SECRET")
-		if jwtSecret == "" {
-			jwtSecret = "default_secret"
-		}
-
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		c.Set("userID", claims["sub"])
+		if actor, _ := claims["act"].(string); actor != "" && actor != claims["sub"] {
+			caretakerID, err := uuid.Parse(actor)
+			ownerID, ownerErr := uuid.Parse(fmt.Sprintf("%v", claims["sub"]))
+			if err != nil || ownerErr != nil {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
+				return
 			}
-			return []byte(jwtSecret), nil
-		})
+			if _, err := userRepo.GetCaretaker(caretakerID, ownerID); err != nil {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "caretaker access has been removed"})
+				return
+			}
+			c.Set("caretakerID", actor)
+		}
+		c.Next()
+	}
+}
 
-		if err != nil || !token.Valid {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+// TenantAuthMiddleware admits tenant portal tokens only. It sets tenantID
+// and accountID (the tenant's landlord) on the context; userID is never
+// set, so landlord handlers cannot be reused by mistake.
+func TenantAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := parseToken(c)
+		if !ok {
 			return
 		}
 
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
+		if scope, _ := claims["scope"].(string); scope != models.TokenScopeTenant {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "token is not valid for this endpoint"})
 			return
 		}
 
-		c.Set("userID", claims["sub"])
+		c.Set("tenantID", claims["sub"])
+		c.Set("accountID", claims["acct"])
 		c.Next()
 	}
 }
+
+// parseToken validates the bearer token and returns its claims. On failure
+// it aborts the request and returns false.
+func parseToken(c *gin.Context) (jwt.MapClaims, bool) {
+	authHeader := c.GetHeader("Authorization")
+	if authHeader == "" {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
+		return nil, false
+	}
+
+	parts := strings.SplitN(authHeader, " ", 2)
+	if !(len(parts) == 2 && parts[0] == "Bearer") {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization header must be Bearer token"})
+		return nil, false
+	}
+
+	tokenString := parts[1]
+	jwtSecret := os.Getenv("JWT_SECRET")
+	if jwtSecret == "" {
+		jwtSecret = "default_secret"
+	}
+
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return []byte(jwtSecret), nil
+	})
+
+	if err != nil || !token.Valid {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+		return nil, false
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
+		return nil, false
+	}
+
+	return claims, true
+}
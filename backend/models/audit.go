@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuditLog records a single mutation of a tracked entity. Before and After
+// only hold the columns that changed (the full row for creates and deletes).
+type AuditLog struct {
+	ID        uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;"`
+	AccountID uuid.UUID      `json:"account_id" gorm:"type:uuid;index"`
+	ActorID   uuid.UUID      `json:"actor_id" gorm:"type:uuid;index"`
+	ActorType string         `json:"actor_type"` // "user", "caretaker", "tenant" or "system"
+	IP        string         `json:"ip"`
+	Entity    string         `json:"entity" gorm:"index"` // e.g. "tenant"
+	EntityID  string         `json:"entity_id" gorm:"index"`
+	Action    string         `json:"action"` // create, update, delete, status_change
+	Before    map[string]any `json:"before" gorm:"type:jsonb;serializer:json"`
+	After     map[string]any `json:"after" gorm:"type:jsonb;serializer:json"`
+	CreatedAt time.Time      `json:"created_at" gorm:"index"`
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"rented-backend/billing"
+	"rented-backend/money"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Charge is an amount billed to a tenant for a month on top of the flat's
+// fixed monthly charges, e.g. a metered electricity bill. Kind is the code
+// of its charge type. A tenant owes each charge type once a month; only
+// maintenance charges, billed per ticket, may repeat.
+type Charge struct {
+	ID           uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;"`
+	TenantID     uuid.UUID      `json:"tenant_id" gorm:"type:uuid;index;uniqueIndex:idx_charges_period,priority:1,where:source_type <> 'maintenance_ticket' AND waived_at IS NULL"`
+	FlatID       uuid.UUID      `json:"flat_id" gorm:"type:uuid;index"`
+	Period       billing.Period `json:"period" gorm:"not null;uniqueIndex:idx_charges_period,priority:2"`
+	Kind         string         `json:"kind"` // e.g., "electricity"
+	ChargeTypeID uuid.UUID      `json:"charge_type_id" gorm:"type:uuid;uniqueIndex:idx_charges_period,priority:3"`
+	Description  string         `json:"description"`
+	Amount       money.Amount   `json:"amount"`
+	SourceType   string         `json:"source_type"` // e.g., "meter_reading"
+	SourceID     uuid.UUID      `json:"source_id" gorm:"type:uuid;index"`
+	WaivedAt     *time.Time     `json:"waived_at"`
+	WaivedBy     *uuid.UUID     `json:"waived_by" gorm:"type:uuid"`
+	WaiveReason  string         `json:"waive_reason"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+}
+
+const (
+	ChargeKindBasicRent   = "basic_rent"
+	ChargeKindGas         = "gas"
+	ChargeKindElectricity = "electricity"
+	ChargeKindUtility     = "utility"
+	ChargeKindWater       = "water"
+	ChargeKindLateFee     = "late_fee"
+	ChargeKindMaintenance = "maintenance"
+
+	ChargeSourceMeterReading = "meter_reading"
+	ChargeSourceSharedBill   = "shared_bill"
+	ChargeSourceManual       = "manual"
+	ChargeSourceLateFee      = "late_fee"
+	ChargeSourceMaintenance  = "maintenance_ticket"
+)
+
+// IsWaived reports whether the charge was waived and no longer counts as owed.
+func (c Charge) IsWaived() bool {
+	return c.WaivedAt != nil
+}
+
+type ChargeRequest struct {
+	TenantID     uuid.UUID      `json:"tenant_id" binding:"required"`
+	ChargeTypeID uuid.UUID      `json:"charge_type_id" binding:"required"`
+	Period       billing.Period `json:"period"`
+	Amount       money.Amount   `json:"amount" binding:"required"`
+	Description  string         `json:"description"`
+}
@@ -0,0 +1,85 @@
+package models
+
+import (
+	"rented-backend/money"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	RecurrenceRecurring = "recurring"
+	RecurrenceOneOff    = "one_off"
+
+	CalculationFixed   = "fixed"
+	CalculationMetered = "metered"
+)
+
+// ChargeType is an entry in a landlord's charge catalogue. Code is stable
+// and unique per landlord; it is what charges and payment items refer to.
+type ChargeType struct {
+	ID          uuid.UUID `json:"id" gorm:"type:uuid;primary_key;"`
+	UserID      uuid.UUID `json:"user_id" gorm:"type:uuid;not null;uniqueIndex:idx_charge_type_code"`
+	Code        string    `json:"code" gorm:"not null;uniqueIndex:idx_charge_type_code" binding:"required"`
+	Name        string    `json:"name" binding:"required"`
+	Recurrence  string    `json:"recurrence" binding:"required,oneof=recurring one_off"`
+	Calculation string    `json:"calculation" binding:"required,oneof=fixed metered"`
+	Taxable     bool      `json:"taxable"`
+	TaxRate     float64   `json:"tax_rate"` // percent, applied to fixed recurring amounts
+	IsActive    bool      `json:"is_active" gorm:"default:true"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// DefaultChargeTypes is the catalogue every landlord starts with. It covers
+// the charges the app used to store as fixed columns.
+func DefaultChargeTypes() []ChargeType {
+	return []ChargeType{
+		{Code: ChargeKindBasicRent, Name: "Basic Rent", Recurrence: RecurrenceRecurring, Calculation: CalculationFixed},
+		{Code: ChargeKindGas, Name: "Gas Bill", Recurrence: RecurrenceRecurring, Calculation: CalculationFixed},
+		{Code: ChargeKindElectricity, Name: "Electricity Bill", Recurrence: RecurrenceRecurring, Calculation: CalculationMetered},
+		{Code: ChargeKindUtility, Name: "Utility Bill", Recurrence: RecurrenceRecurring, Calculation: CalculationFixed},
+		{Code: ChargeKindWater, Name: "Water Charges", Recurrence: RecurrenceRecurring, Calculation: CalculationFixed},
+		{Code: ChargeKindLateFee, Name: "Late Fee", Recurrence: RecurrenceOneOff, Calculation: CalculationFixed},
+		{Code: ChargeKindMaintenance, Name: "Maintenance", Recurrence: RecurrenceOneOff, Calculation: CalculationFixed},
+	}
+}
+
+// FlatCharge assigns a charge type to a flat with its monthly amount.
+type FlatCharge struct {
+	ID           uuid.UUID    `json:"id" gorm:"type:uuid;primary_key;"`
+	FlatID       uuid.UUID    `json:"flat_id" gorm:"type:uuid;not null;uniqueIndex:idx_flat_charge_type"`
+	ChargeTypeID uuid.UUID    `json:"charge_type_id" gorm:"type:uuid;not null;uniqueIndex:idx_flat_charge_type"`
+	ChargeType   ChargeType   `json:"charge_type" gorm:"foreignKey:ChargeTypeID"`
+	Amount       money.Amount `json:"amount"`
+	CreatedAt    time.Time    `json:"created_at"`
+	UpdatedAt    time.Time    `json:"updated_at"`
+}
+
+// MonthlyAmount is what the flat is billed for this charge each month,
+// including tax. Metered and one-off charges are billed through posted
+// charges instead and return 0.
+func (fc FlatCharge) MonthlyAmount() money.Amount {
+	if fc.ChargeType.Recurrence != RecurrenceRecurring || fc.ChargeType.Calculation != CalculationFixed || !fc.ChargeType.IsActive {
+		return 0
+	}
+	if fc.ChargeType.Taxable {
+		return fc.Amount + fc.Amount.Percent(fc.ChargeType.TaxRate)
+	}
+	return fc.Amount
+}
+
+type FlatChargeInput struct {
+	ChargeTypeID uuid.UUID    `json:"charge_type_id" binding:"required"`
+	Amount       money.Amount `json:"amount" binding:"min=0"`
+}
+
+// PaymentItem is the part of a payment that went towards one charge type.
+type PaymentItem struct {
+	ID            uuid.UUID    `json:"id" gorm:"type:uuid;primary_key;"`
+	RentPaymentID uuid.UUID    `json:"rent_payment_id" gorm:"type:uuid;not null;index;uniqueIndex:idx_payment_items_charge_type"`
+	ChargeTypeID  uuid.UUID    `json:"charge_type_id" gorm:"type:uuid;uniqueIndex:idx_payment_items_charge_type"`
+	Code          string       `json:"code"`
+	Amount        money.Amount `json:"amount"`
+	CreatedAt     time.Time    `json:"created_at"`
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EmailPreferences holds which emails a landlord receives. The unsubscribe
+// token lets the link in an email turn them off without logging in.
+type EmailPreferences struct {
+	ID               uuid.UUID `json:"id" gorm:"type:uuid;primary_key;"`
+	UserID           uuid.UUID `json:"user_id" gorm:"type:uuid;uniqueIndex"`
+	MonthlySummary   bool      `json:"monthly_summary"`
+	UnsubscribeToken string    `json:"-" gorm:"uniqueIndex"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+type EmailPreferencesRequest struct {
+	MonthlySummary *bool `json:"monthly_summary" binding:"required"`
+}
+
+const (
+	EmailPending = "pending"
+	EmailSent    = "sent"
+	EmailFailed  = "failed"
+)
+
+// ReportEmail logs the monthly summary for a period (YYYY-MM). The row is
+// written as pending before the email goes out, so only one run sends it;
+// failed sends are retried on the next run.
+type ReportEmail struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;"`
+	UserID    uuid.UUID `json:"user_id" gorm:"type:uuid;index;uniqueIndex:idx_report_emails_period"`
+	Period    string    `json:"period" gorm:"uniqueIndex:idx_report_emails_period"`
+	To        string    `json:"to"`
+	Status    string    `json:"status"`
+	Error     string    `json:"error,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,86 @@
+package models
+
+import (
+	"rented-backend/money"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ExpenseRepairs           = "repairs"
+	ExpenseCaretakerSalary   = "caretaker_salary"
+	ExpenseHoldingTax        = "holding_tax"
+	ExpenseCommonElectricity = "common_electricity"
+	ExpenseCleaning          = "cleaning"
+	ExpenseOther             = "other"
+)
+
+const (
+	ExpenseRepeatNone      = "none"
+	ExpenseRepeatMonthly   = "monthly"
+	ExpenseRepeatQuarterly = "quarterly"
+	ExpenseRepeatYearly    = "yearly"
+)
+
+// Expense is money spent on a house, or on one of its flats. A recurring
+// expense is repeated on its schedule: NextDate is the date of the next
+// copy, which is recorded as a one-off expense pointing back via ParentID.
+type Expense struct {
+	ID          uuid.UUID    `json:"id" gorm:"type:uuid;primary_key;"`
+	UserID      uuid.UUID    `json:"user_id" gorm:"type:uuid;index"`
+	HouseID     uuid.UUID    `json:"house_id" gorm:"type:uuid;index"`
+	FlatID      *uuid.UUID   `json:"flat_id" gorm:"type:uuid"`
+	Category    string       `json:"category"`
+	VendorID    *uuid.UUID   `json:"vendor_id" gorm:"type:uuid;index"`
+	Vendor      string       `json:"vendor"` // vendor name, copied from the linked vendor if any
+	Amount      money.Amount `json:"amount"`
+	Date        time.Time    `json:"date" gorm:"index"`
+	Description string       `json:"description"`
+	ReceiptURL  string       `json:"receipt_url"`
+	Recurrence  string       `json:"recurrence" gorm:"default:none"`
+	NextDate    *time.Time   `json:"next_date"`
+	ParentID    *uuid.UUID   `json:"parent_id" gorm:"type:uuid"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+}
+
+// NextOccurrence returns the date after from on the expense's schedule.
+// It keeps the day of the month of the expense's Date, moved back to the
+// last day in shorter months: an expense dated 31 January recurs on 28
+// February and then 31 March, not 3 March.
+func (e Expense) NextOccurrence(from time.Time) time.Time {
+	switch e.Recurrence {
+	case ExpenseRepeatMonthly:
+		return e.onAnchorDay(from, 1)
+	case ExpenseRepeatQuarterly:
+		return e.onAnchorDay(from, 3)
+	case ExpenseRepeatYearly:
+		return e.onAnchorDay(from, 12)
+	}
+	return from
+}
+
+func (e Expense) onAnchorDay(from time.Time, months int) time.Time {
+	day := e.Date.Day()
+	if e.Date.IsZero() {
+		day = from.Day()
+	}
+	first := time.Date(from.Year(), from.Month()+time.Month(months), 1, from.Hour(), from.Minute(), from.Second(), from.Nanosecond(), from.Location())
+	if last := first.AddDate(0, 1, -1).Day(); day > last {
+		day = last
+	}
+	return first.AddDate(0, 0, day-1)
+}
+
+type ExpenseRequest struct {
+	HouseID     uuid.UUID    `json:"house_id" binding:"required"`
+	FlatID      *uuid.UUID   `json:"flat_id"`
+	Category    string       `json:"category" binding:"required,oneof=repairs caretaker_salary holding_tax common_electricity cleaning other"`
+	VendorID    *uuid.UUID   `json:"vendor_id"`
+	Vendor      string       `json:"vendor"`
+	Amount      money.Amount `json:"amount" binding:"required,gt=0"`
+	Date        time.Time    `json:"date" binding:"required"`
+	Description string       `json:"description"`
+	Recurrence  string       `json:"recurrence" binding:"omitempty,oneof=none monthly quarterly yearly"`
+}
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNextOccurrence(t *testing.T) {
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name       string
+		recurrence string
+		anchor     time.Time
+		want       []time.Time // successive occurrences after the anchor
+	}{
+		{
+			name:       "monthly on the 31st clamps to short months without drifting",
+			recurrence: ExpenseRepeatMonthly,
+			anchor:     date(2026, time.January, 31),
+			want:       []time.Time{date(2026, time.February, 28), date(2026, time.March, 31), date(2026, time.April, 30), date(2026, time.May, 31)},
+		},
+		{
+			name:       "monthly on the 29th in a leap year",
+			recurrence: ExpenseRepeatMonthly,
+			anchor:     date(2028, time.January, 29),
+			want:       []time.Time{date(2028, time.February, 29), date(2028, time.March, 29)},
+		},
+		{
+			name:       "quarterly on the 31st",
+			recurrence: ExpenseRepeatQuarterly,
+			anchor:     date(2026, time.May, 31),
+			want:       []time.Time{date(2026, time.August, 31), date(2026, time.November, 30), date(2027, time.February, 28), date(2027, time.May, 31)},
+		},
+		{
+			name:       "yearly on 29 February",
+			recurrence: ExpenseRepeatYearly,
+			anchor:     date(2028, time.February, 29),
+			want:       []time.Time{date(2029, time.February, 28), date(2030, time.February, 28), date(2031, time.February, 28), date(2032, time.February, 29)},
+		},
+		{
+			name:       "monthly across the year end",
+			recurrence: ExpenseRepeatMonthly,
+			anchor:     date(2026, time.December, 15),
+			want:       []time.Time{date(2027, time.January, 15)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := Expense{Date: tt.anchor, Recurrence: tt.recurrence}
+			next := tt.anchor
+			for _, want := range tt.want {
+				next = e.NextOccurrence(next)
+				if !next.Equal(want) {
+					t.Fatalf("got %s, want %s", next.Format("2006-01-02"), want.Format("2006-01-02"))
+				}
+			}
+		})
+	}
+}
@@ -1,28 +1,47 @@
 package models
 
 import (
+	"rented-backend/money"
 	"time"
 
 	"github.com/google/uuid"
 )
 
 type House struct {
-	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;"`
-	UserID    uuid.UUID `json:"user_id" gorm:"type:uuid;not null"`
-	Name      string    `json:"name" binding:"required"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	Flats     []Flat    `json:"flats" gorm:"foreignKey:HouseID"`
+	ID            uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;"`
+	UserID        uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	Name          string     `json:"name" binding:"required"`
+	ImportBatchID *uuid.UUID `json:"import_batch_id,omitempty" gorm:"type:uuid;index"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+	Flats         []Flat     `json:"flats" gorm:"foreignKey:HouseID"`
 }
 
 type Flat struct {
-	ID           uuid.UUID `json:"id" gorm:"type:uuid;primary_key;"`
-	HouseID      uuid.UUID `json:"house_id" gorm:"type:uuid;not null"`
-	Number       string    `json:"number" binding:"required"`
-	BasicRent    float64   `json:"basic_rent"`
-	GasBill      float64   `json:"gas_bill"`
-	UtilityBill  float64   `json:"utility_bill"`
-	WaterCharges float64   `json:"water_charges"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID            uuid.UUID    `json:"id" gorm:"type:uuid;primary_key;"`
+	HouseID       uuid.UUID    `json:"house_id" gorm:"type:uuid;not null;uniqueIndex:idx_flats_house_number"`
+	Number        string       `json:"number" gorm:"uniqueIndex:idx_flats_house_number" binding:"required"`
+	Size          float64      `json:"size"` // square feet, used to split shared bills
+	ImportBatchID *uuid.UUID   `json:"import_batch_id,omitempty" gorm:"type:uuid;index"`
+	Charges       []FlatCharge `json:"charges" gorm:"foreignKey:FlatID"`
+	CreatedAt     time.Time    `json:"created_at"`
+	UpdatedAt     time.Time    `json:"updated_at"`
+}
+
+// ChargeAmount returns the monthly amount (including tax) the flat is
+// billed for the given charge code.
+func (f Flat) ChargeAmount(code string) money.Amount {
+	for _, fc := range f.Charges {
+		if fc.ChargeType.Code == code {
+			return fc.MonthlyAmount()
+		}
+	}
+	return 0
+}
+
+type FlatRequest struct {
+	HouseID uuid.UUID         `json:"house_id" binding:"required"`
+	Number  string            `json:"number" binding:"required"`
+	Size    float64           `json:"size"`
+	Charges []FlatChargeInput `json:"charges" binding:"dive"`
 }
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// What an import file holds; each has its own template.
+const (
+	ImportHouses   = "houses"
+	ImportFlats    = "flats"
+	ImportTenants  = "tenants"
+	ImportPayments = "payments"
+)
+
+const (
+	ImportCommitted  = "committed"
+	ImportRolledBack = "rolled_back"
+)
+
+// ImportBatch is one committed import. Every row it created carries its ID
+// so the batch can be rolled back as a whole.
+type ImportBatch struct {
+	ID           uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;"`
+	UserID       uuid.UUID  `json:"user_id" gorm:"type:uuid;index"`
+	Kind         string     `json:"kind"`
+	Filename     string     `json:"filename"`
+	Rows         int        `json:"rows"`
+	Status       string     `json:"status"`
+	RolledBackAt *time.Time `json:"rolled_back_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// ImportRowError is a problem with one cell or row of an import file. Row
+// is the spreadsheet row number, the header being row 1.
+type ImportRowError struct {
+	Row     int    `json:"row"`
+	Column  string `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+// ImportResult reports a dry run or a commit. Nothing is written unless
+// the file has no errors.
+type ImportResult struct {
+	Kind   string           `json:"kind"`
+	DryRun bool             `json:"dry_run"`
+	Rows   int              `json:"rows"`
+	Valid  bool             `json:"valid"`
+	Errors []ImportRowError `json:"errors"`
+	Batch  *ImportBatch     `json:"batch,omitempty"`
+}
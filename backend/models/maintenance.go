@@ -0,0 +1,122 @@
+package models
+
+import (
+	"rented-backend/billing"
+	"rented-backend/money"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	TicketPriorityLow    = "low"
+	TicketPriorityMedium = "medium"
+	TicketPriorityHigh   = "high"
+	TicketPriorityUrgent = "urgent"
+)
+
+const (
+	TicketOpen       = "open"
+	TicketAssigned   = "assigned"
+	TicketInProgress = "in_progress"
+	TicketResolved   = "resolved"
+)
+
+// Who pays for a ticket's cost.
+const (
+	CostToLandlord = "landlord"
+	CostToTenant   = "tenant"
+)
+
+// ticketTransitions lists the statuses each status can move to. Work moves
+// forward one step at a time; it can be handed back a step, and a resolved
+// ticket can be reopened.
+var ticketTransitions = map[string][]string{
+	TicketOpen:       {TicketAssigned},
+	TicketAssigned:   {TicketInProgress, TicketOpen},
+	TicketInProgress: {TicketResolved, TicketAssigned},
+	TicketResolved:   {TicketOpen},
+}
+
+// CanTransition reports whether a ticket may move from one status to another.
+func CanTransition(from, to string) bool {
+	for _, next := range ticketTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// MaintenanceTicket is a repair or service request for a house, optionally
+// narrowed to a flat and the tenant who raised it. Once its cost is settled
+// it links to the expense or tenant charge that records it.
+type MaintenanceTicket struct {
+	ID            uuid.UUID       `json:"id" gorm:"type:uuid;primary_key;"`
+	UserID        uuid.UUID       `json:"user_id" gorm:"type:uuid;index"`
+	HouseID       uuid.UUID       `json:"house_id" gorm:"type:uuid;index"`
+	FlatID        *uuid.UUID      `json:"flat_id" gorm:"type:uuid"`
+	TenantID      *uuid.UUID      `json:"tenant_id" gorm:"type:uuid"`
+	Title         string          `json:"title"`
+	Description   string          `json:"description"`
+	Priority      string          `json:"priority" gorm:"default:medium"`
+	Status        string          `json:"status" gorm:"default:open;index"`
+	VendorID      *uuid.UUID      `json:"vendor_id" gorm:"type:uuid;index"`
+	AssigneeName  string          `json:"assignee_name"` // vendor or caretaker
+	AssigneePhone string          `json:"assignee_phone"`
+	Cost          money.Amount    `json:"cost"`
+	CostChargedTo string          `json:"cost_charged_to"`
+	ExpenseID     *uuid.UUID      `json:"expense_id" gorm:"type:uuid"`
+	ChargeID      *uuid.UUID      `json:"charge_id" gorm:"type:uuid"`
+	ResolvedAt    *time.Time      `json:"resolved_at"`
+	Photos        []TicketPhoto   `json:"photos,omitempty" gorm:"foreignKey:TicketID"`
+	Comments      []TicketComment `json:"comments,omitempty" gorm:"foreignKey:TicketID"`
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+}
+
+type TicketPhoto struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;"`
+	TicketID  uuid.UUID `json:"ticket_id" gorm:"type:uuid;index"`
+	URL       string    `json:"url"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+const (
+	CommentByUser   = "user" // the landlord
+	CommentByTenant = "tenant"
+)
+
+type TicketComment struct {
+	ID         uuid.UUID `json:"id" gorm:"type:uuid;primary_key;"`
+	TicketID   uuid.UUID `json:"ticket_id" gorm:"type:uuid;index"`
+	AuthorID   uuid.UUID `json:"author_id" gorm:"type:uuid"`
+	AuthorType string    `json:"author_type" gorm:"default:user"` // user (landlord) or tenant
+	Body       string    `json:"body"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type TicketRequest struct {
+	HouseID       uuid.UUID  `json:"house_id"`
+	FlatID        *uuid.UUID `json:"flat_id"`
+	TenantID      *uuid.UUID `json:"tenant_id"`
+	Title         string     `json:"title" binding:"required"`
+	Description   string     `json:"description"`
+	Priority      string     `json:"priority" binding:"omitempty,oneof=low medium high urgent"`
+	VendorID      *uuid.UUID `json:"vendor_id"`
+	AssigneeName  string     `json:"assignee_name"`
+	AssigneePhone string     `json:"assignee_phone"`
+}
+
+type TicketStatusRequest struct {
+	Status        string     `json:"status" binding:"required,oneof=open assigned in_progress resolved"`
+	VendorID      *uuid.UUID `json:"vendor_id"`
+	AssigneeName  string     `json:"assignee_name"`
+	AssigneePhone string     `json:"assignee_phone"`
+}
+
+type TicketCostRequest struct {
+	Amount   money.Amount   `json:"amount" binding:"required,gt=0"`
+	ChargeTo string         `json:"charge_to" binding:"required,oneof=landlord tenant"`
+	Period   billing.Period `json:"period"` // billing month for a tenant charge, defaults to the current month
+}
@@ -0,0 +1,82 @@
+package models
+
+import (
+	"rented-backend/billing"
+	"rented-backend/money"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Meter struct {
+	ID             uuid.UUID `json:"id" gorm:"type:uuid;primary_key;"`
+	FlatID         uuid.UUID `json:"flat_id" gorm:"type:uuid;not null;index"`
+	Number         string    `json:"number" binding:"required"`
+	InitialReading float64   `json:"initial_reading"`
+	IsActive       bool      `json:"is_active" gorm:"default:true"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// MeterReading is the monthly reading of a meter. The bill breakdown is
+// stored so later tariff changes don't rewrite history.
+type MeterReading struct {
+	ID            uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;"`
+	MeterID       uuid.UUID      `json:"meter_id" gorm:"type:uuid;not null;uniqueIndex:idx_meter_period"`
+	FlatID        uuid.UUID      `json:"flat_id" gorm:"type:uuid;index"`
+	TenantID      uuid.UUID      `json:"tenant_id" gorm:"type:uuid"`
+	Period        billing.Period `json:"period" gorm:"not null;uniqueIndex:idx_meter_period"`
+	PreviousUnits float64        `json:"previous_units"`
+	CurrentUnits  float64        `json:"current_units"`
+	Units         float64        `json:"units"`
+	EnergyCharge  money.Amount   `json:"energy_charge"`
+	MeterRent     money.Amount   `json:"meter_rent"`
+	VAT           money.Amount   `json:"vat"`
+	Amount        money.Amount   `json:"amount"`
+	IsFlagged     bool           `json:"is_flagged"`
+	FlagReason    string         `json:"flag_reason"`
+	ReadingDate   time.Time      `json:"reading_date"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+}
+
+// TariffSlab charges Rate per unit for consumption up to UpTo units.
+// The last slab should have UpTo = 0, meaning no upper bound.
+type TariffSlab struct {
+	UpTo float64 `json:"up_to"`
+	Rate float64 `json:"rate"`
+}
+
+// ElectricityTariff is a landlord's slab tariff used to bill meter readings.
+type ElectricityTariff struct {
+	ID        uuid.UUID    `json:"id" gorm:"type:uuid;primary_key;"`
+	UserID    uuid.UUID    `json:"user_id" gorm:"type:uuid;uniqueIndex"`
+	Slabs     []TariffSlab `json:"slabs" gorm:"type:jsonb;serializer:json"`
+	MeterRent money.Amount `json:"meter_rent"`
+	VATRate   float64      `json:"vat_rate"` // percent, applied to energy charge and meter rent
+	CreatedAt time.Time    `json:"created_at"`
+	UpdatedAt time.Time    `json:"updated_at"`
+}
+
+// DefaultElectricityTariff mirrors the DESCO/BPDB residential slabs.
+func DefaultElectricityTariff() ElectricityTariff {
+	return ElectricityTariff{
+		Slabs: []TariffSlab{
+			{UpTo: 75, Rate: 5.26},
+			{UpTo: 200, Rate: 7.20},
+			{UpTo: 300, Rate: 7.59},
+			{UpTo: 400, Rate: 8.02},
+			{UpTo: 600, Rate: 12.67},
+			{UpTo: 0, Rate: 14.61},
+		},
+		MeterRent: money.New(40, 0),
+		VATRate:   5,
+	}
+}
+
+type MeterReadingRequest struct {
+	Period        billing.Period `json:"period"`
+	CurrentUnits  float64        `json:"current_units" binding:"min=0"`
+	PreviousUnits *float64       `json:"previous_units"` // defaults to the last recorded reading
+	ReadingDate   time.Time      `json:"reading_date"`
+}
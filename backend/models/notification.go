@@ -0,0 +1,77 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Events the landlord can be notified about, each with its own preference.
+const (
+	EventCaretakerPayment = "caretaker_payment" // a payment entered on a tenant's behalf
+	EventPaymentProof     = "payment_proof"     // a tenant submitted a payment for approval
+	EventLeaseExpiring    = "lease_expiring"
+	EventBillingRun       = "billing_run"
+)
+
+var NotificationEvents = []string{EventCaretakerPayment, EventPaymentProof, EventLeaseExpiring, EventBillingRun}
+
+const (
+	PlatformAndroid = "android"
+	PlatformIOS     = "ios"
+)
+
+// Push statuses recorded on each notification. Muted means the user turned
+// the event off; the notification is still kept in the inbox.
+const (
+	PushSent     = "sent"
+	PushFailed   = "failed"
+	PushMuted    = "muted"
+	PushNoDevice = "no_device"
+)
+
+// DeviceToken is an app install registered for push. A token belongs to
+// whichever user registered it last.
+type DeviceToken struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;"`
+	UserID    uuid.UUID `json:"user_id" gorm:"type:uuid;index"`
+	Token     string    `json:"token" gorm:"uniqueIndex"`
+	Platform  string    `json:"platform"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type DeviceTokenRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Platform string `json:"platform" binding:"required,oneof=android ios"`
+}
+
+// NotificationPreference turns push for one event on or off. Events
+// without a row are on.
+type NotificationPreference struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;"`
+	UserID    uuid.UUID `json:"user_id" gorm:"type:uuid;uniqueIndex:idx_notification_pref"`
+	Event     string    `json:"event" gorm:"uniqueIndex:idx_notification_pref"`
+	Push      bool      `json:"push"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// NotificationPreferencesRequest maps event to whether it is pushed.
+type NotificationPreferencesRequest struct {
+	Events map[string]bool `json:"events" binding:"required"`
+}
+
+// Notification is an inbox entry. Every alert is stored whether or not it
+// was pushed.
+type Notification struct {
+	ID         uuid.UUID         `json:"id" gorm:"type:uuid;primary_key;"`
+	UserID     uuid.UUID         `json:"user_id" gorm:"type:uuid;index"`
+	Event      string            `json:"event"`
+	Title      string            `json:"title"`
+	Body       string            `json:"body"`
+	Data       map[string]string `json:"data" gorm:"serializer:json"`
+	PushStatus string            `json:"push_status"`
+	DedupeKey  string            `json:"-" gorm:"index"`
+	ReadAt     *time.Time        `json:"read_at"`
+	CreatedAt  time.Time         `json:"created_at"`
+}
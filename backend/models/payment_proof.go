@@ -0,0 +1,69 @@
+package models
+
+import (
+	"rented-backend/billing"
+	"rented-backend/money"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	PaymentMethodBkash  = "bkash"
+	PaymentMethodNagad  = "nagad"
+	PaymentMethodRocket = "rocket"
+	PaymentMethodBank   = "bank"
+	PaymentMethodCash   = "cash"
+	PaymentMethodOther  = "other"
+)
+
+const (
+	ProofPending   = "pending"
+	ProofConfirmed = "confirmed"
+	ProofRejected  = "rejected"
+)
+
+// PaymentProof is a payment reported by a tenant or caretaker, e.g. a
+// mobile wallet transfer with a screenshot. It does not count towards dues
+// until the landlord confirms it, which records a RentPayment.
+type PaymentProof struct {
+	ID            uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;"`
+	UserID        uuid.UUID      `json:"user_id" gorm:"type:uuid;index"` // landlord
+	TenantID      uuid.UUID      `json:"tenant_id" gorm:"type:uuid;index"`
+	Tenant        *Tenant        `json:"tenant,omitempty" gorm:"foreignKey:TenantID"`
+	Period        billing.Period `json:"period" gorm:"not null"`
+	Amount        money.Amount   `json:"amount"`
+	Method        string         `json:"method"`
+	TransactionID string         `json:"transaction_id"`
+	ScreenshotURL string         `json:"screenshot_url"`
+	Note          string         `json:"note"`
+	SubmittedBy   string         `json:"submitted_by"` // tenant, landlord, or the name of the caretaker who entered it
+	Status        string         `json:"status" gorm:"default:pending;index"`
+	ReviewedBy    *uuid.UUID     `json:"reviewed_by" gorm:"type:uuid"`
+	ReviewedAt    *time.Time     `json:"reviewed_at"`
+	RejectReason  string         `json:"reject_reason"`
+	RentPaymentID *uuid.UUID     `json:"rent_payment_id" gorm:"type:uuid"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+}
+
+// PaymentProofRequest is submitted as multipart form fields, alongside an
+// optional screenshot file. TenantID is only read on the landlord's side.
+type PaymentProofRequest struct {
+	TenantID      uuid.UUID      `form:"tenant_id"`
+	Period        billing.Period `form:"period"`
+	Amount        money.Amount   `form:"amount" binding:"required,gt=0"`
+	Method        string         `form:"method" binding:"required,oneof=bkash nagad rocket bank cash other"`
+	TransactionID string         `form:"transaction_id"`
+	Note          string         `form:"note"`
+}
+
+// ConfirmProofRequest optionally splits the payment across charge types.
+// Without items the amount is applied to the month's dues, rent first.
+type ConfirmProofRequest struct {
+	Items []PaymentItem `json:"items"`
+}
+
+type RejectProofRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
@@ -0,0 +1,115 @@
+package models
+
+import (
+	"math"
+	"rented-backend/billing"
+	"rented-backend/money"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	LateFeeNone    = "none"
+	LateFeeFlat    = "flat"
+	LateFeePercent = "percent"
+	LateFeePerDay  = "per_day"
+)
+
+// Proration modes for the first and last month of a tenancy.
+const (
+	ProrationNone         = "none"           // always charge the full month
+	ProrationDaily        = "daily"          // 1/30 of the monthly amount per day occupied
+	ProrationCalendarDays = "calendar_days"  // share of the month's actual days occupied
+	ProrationFreeAfterDay = "free_after_day" // first month free when moving in after ProrationDay
+)
+
+// RentPolicy holds a house's payment rules. Rent for a month is due on
+// DueDay and becomes late once GraceDays have passed after it.
+type RentPolicy struct {
+	ID             uuid.UUID    `json:"id" gorm:"type:uuid;primary_key;"`
+	HouseID        uuid.UUID    `json:"house_id" gorm:"type:uuid;uniqueIndex"`
+	DueDay         int          `json:"due_day" binding:"min=1,max=28"`
+	GraceDays      int          `json:"grace_days" binding:"min=0"`
+	LateFeeType    string       `json:"late_fee_type" binding:"required,oneof=none flat percent per_day"`
+	LateFeeAmount  money.Amount `json:"late_fee_amount" binding:"min=0"`          // flat amount or amount per day
+	LateFeePercent float64      `json:"late_fee_percent" binding:"min=0,max=100"` // percent of the outstanding amount
+	LateFeeCap     money.Amount `json:"late_fee_cap" binding:"min=0"`             // upper bound for per_day fees, 0 means no cap
+	ProrationMode  string       `json:"proration_mode" gorm:"default:calendar_days" binding:"omitempty,oneof=none daily calendar_days free_after_day"`
+	ProrationDay   int          `json:"proration_day" binding:"min=0,max=28"` // cut-off day for free_after_day
+	CreatedAt      time.Time    `json:"created_at"`
+	UpdatedAt      time.Time    `json:"updated_at"`
+}
+
+// DefaultRentPolicy is used for houses without a saved policy.
+func DefaultRentPolicy(houseID uuid.UUID) RentPolicy {
+	return RentPolicy{
+		HouseID:       houseID,
+		DueDay:        10,
+		LateFeeType:   LateFeeNone,
+		ProrationMode: ProrationCalendarDays,
+	}
+}
+
+// DueDate returns the day rent for the given period is due.
+func (p RentPolicy) DueDate(period billing.Period) time.Time {
+	return time.Date(period.Year, period.Month, p.DueDay, 0, 0, 0, 0, time.UTC)
+}
+
+// LateFrom returns the first moment rent for the period counts as late.
+func (p RentPolicy) LateFrom(period billing.Period) time.Time {
+	return p.DueDate(period).AddDate(0, 0, p.GraceDays+1)
+}
+
+// LateFee returns the fee for an outstanding amount that is daysLate days
+// past the grace period.
+func (p RentPolicy) LateFee(outstanding money.Amount, daysLate int) money.Amount {
+	switch p.LateFeeType {
+	case LateFeeFlat:
+		return p.LateFeeAmount
+	case LateFeePercent:
+		return outstanding.Percent(p.LateFeePercent)
+	case LateFeePerDay:
+		fee := p.LateFeeAmount * money.Amount(daysLate)
+		if p.LateFeeCap > 0 {
+			fee = min(fee, p.LateFeeCap)
+		}
+		return fee
+	}
+	return 0
+}
+
+// OccupiedShare returns the fraction of the month's recurring charges owed
+// by a tenant who moved in on moveIn and, if set, moved out on moveOut (the
+// last day occupied). Only the first and last month of a tenancy can be
+// less than 1; under free_after_day the last month is charged in full.
+func (p RentPolicy) OccupiedShare(period billing.Period, moveIn time.Time, moveOut *time.Time) float64 {
+	daysInMonth := period.Days()
+	firstDay, lastDay := 1, daysInMonth
+	if billing.Of(moveIn) == period {
+		firstDay = moveIn.Day()
+	}
+	if moveOut != nil && billing.Of(*moveOut) == period {
+		lastDay = moveOut.Day()
+	}
+	if firstDay == 1 && lastDay == daysInMonth {
+		return 1
+	}
+
+	occupied := lastDay - firstDay + 1
+	if occupied <= 0 {
+		return 0
+	}
+
+	switch p.ProrationMode {
+	case ProrationDaily:
+		return math.Min(float64(occupied)/30, 1)
+	case ProrationCalendarDays:
+		return float64(occupied) / float64(daysInMonth)
+	case ProrationFreeAfterDay:
+		if firstDay > 1 && firstDay > p.ProrationDay {
+			return 0
+		}
+	}
+	return 1
+}
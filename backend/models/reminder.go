@@ -0,0 +1,133 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	LanguageBangla  = "bn"
+	LanguageEnglish = "en"
+)
+
+// Message kinds, each with its own template.
+const (
+	MessageBeforeDue        = "before_due"
+	MessageDueToday         = "due_today"
+	MessageOverdue          = "overdue"
+	MessagePaymentConfirmed = "payment_confirmed"
+	MessagePaymentRejected  = "payment_rejected"
+)
+
+// SMS delivery statuses. Pending marks a message recorded but not yet
+// handed to the gateway; delivered is set from the gateway's delivery
+// report; opted_out records a message not sent because the tenant opted out.
+const (
+	SMSPending   = "pending"
+	SMSSent      = "sent"
+	SMSFailed    = "failed"
+	SMSDelivered = "delivered"
+	SMSOptedOut  = "opted_out"
+)
+
+// ReminderSettings controls a landlord's automatic rent reminders.
+type ReminderSettings struct {
+	ID          uuid.UUID `json:"id" gorm:"type:uuid;primary_key;"`
+	UserID      uuid.UUID `json:"user_id" gorm:"type:uuid;uniqueIndex"`
+	Enabled     bool      `json:"enabled"`
+	DaysBefore  int       `json:"days_before"` // 0 sends no advance reminder
+	OnDueDate   bool      `json:"on_due_date"`
+	OverdueDays []int     `json:"overdue_days" gorm:"serializer:json"` // days after the due date, e.g. [3, 7, 15]
+	Language    string    `json:"language"`                            // default for tenants without their own
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// DefaultReminderSettings applies until the landlord saves their own.
+func DefaultReminderSettings(userID uuid.UUID) ReminderSettings {
+	return ReminderSettings{
+		UserID:      userID,
+		Enabled:     true,
+		DaysBefore:  3,
+		OnDueDate:   true,
+		OverdueDays: []int{3, 7, 15},
+		Language:    LanguageBangla,
+	}
+}
+
+type ReminderSettingsRequest struct {
+	Enabled     bool   `json:"enabled"`
+	DaysBefore  int    `json:"days_before" binding:"min=0,max=28"`
+	OnDueDate   bool   `json:"on_due_date"`
+	OverdueDays []int  `json:"overdue_days" binding:"dive,min=1,max=365"`
+	Language    string `json:"language" binding:"required,oneof=bn en"`
+}
+
+// MessageTemplate is a landlord's wording for one kind of message in one
+// language. Placeholders: {name}, {flat}, {amount}, {month}, {due_date},
+// {receipt} and {reason}.
+type MessageTemplate struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;"`
+	UserID    uuid.UUID `json:"user_id" gorm:"type:uuid;uniqueIndex:idx_message_template"`
+	Kind      string    `json:"kind" gorm:"uniqueIndex:idx_message_template"`
+	Language  string    `json:"language" gorm:"uniqueIndex:idx_message_template"`
+	Body      string    `json:"body"`
+	IsDefault bool      `json:"is_default" gorm:"-"` // not customised by the landlord
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type MessageTemplateRequest struct {
+	Body string `json:"body" binding:"required"`
+}
+
+// DefaultTemplates holds the built-in wording, by kind and language.
+var DefaultTemplates = map[string]map[string]string{
+	MessageBeforeDue: {
+		LanguageEnglish: "Dear {name}, rent of Tk {amount} for flat {flat} ({month}) is due on {due_date}.",
+		LanguageBangla:  "প্রিয় {name}, ফ্ল্যাট {flat}-এর {month} মাসের ভাড়া {amount} টাকা {due_date} তারিখের মধ্যে পরিশোধ করুন।",
+	},
+	MessageDueToday: {
+		LanguageEnglish: "Dear {name}, rent of Tk {amount} for flat {flat} ({month}) is due today.",
+		LanguageBangla:  "প্রিয় {name}, ফ্ল্যাট {flat}-এর {month} মাসের ভাড়া {amount} টাকা আজ পরিশোধযোগ্য।",
+	},
+	MessageOverdue: {
+		LanguageEnglish: "Dear {name}, rent of Tk {amount} for flat {flat} ({month}) is overdue. Please pay as soon as possible.",
+		LanguageBangla:  "প্রিয় {name}, ফ্ল্যাট {flat}-এর {month} মাসের ভাড়া {amount} টাকা বকেয়া রয়েছে। অনুগ্রহ করে দ্রুত পরিশোধ করুন।",
+	},
+	MessagePaymentConfirmed: {
+		LanguageEnglish: "Dear {name}, your payment of Tk {amount} for {month} has been received. Receipt no. {receipt}. Thank you.",
+		LanguageBangla:  "প্রিয় {name}, {month} মাসের {amount} টাকা গ্রহণ করা হয়েছে। রসিদ নং {receipt}। ধন্যবাদ।",
+	},
+	MessagePaymentRejected: {
+		LanguageEnglish: "Dear {name}, your payment of Tk {amount} for {month} was not accepted: {reason}",
+		LanguageBangla:  "প্রিয় {name}, {month} মাসের {amount} টাকার পেমেন্ট গ্রহণ করা হয়নি: {reason}",
+	},
+}
+
+// SMSMessage records one message to a tenant and what became of it.
+type SMSMessage struct {
+	ID         uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;"`
+	UserID     uuid.UUID  `json:"user_id" gorm:"type:uuid;index"`
+	TenantID   *uuid.UUID `json:"tenant_id" gorm:"type:uuid;index;uniqueIndex:idx_sms_messages_dedupe,where:dedupe_key <> ''"`
+	Kind       string     `json:"kind"`
+	Phone      string     `json:"phone"`
+	Body       string     `json:"body"`
+	Status     string     `json:"status" gorm:"index"`
+	ProviderID string     `json:"provider_id" gorm:"index"`
+	Error      string     `json:"error"`
+	DedupeKey  string     `json:"-" gorm:"uniqueIndex:idx_sms_messages_dedupe,where:dedupe_key <> ''"` // e.g. overdue:2026-03:7, so a reminder is sent once
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+type TenantNotificationRequest struct {
+	SMSOptOut bool   `json:"sms_opt_out"`
+	Language  string `json:"language" binding:"omitempty,oneof=bn en"`
+}
+
+type DeliveryReport struct {
+	ProviderID string `json:"message_id" form:"message_id" binding:"required"`
+	Status     string `json:"status" form:"status" binding:"required"`
+}
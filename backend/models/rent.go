@@ -1,24 +1,24 @@
 package models
 
 import (
+	"rented-backend/billing"
+	"rented-backend/money"
 	"time"
 
 	"github.com/google/uuid"
 )
 
 type RentPayment struct {
-	ID              uuid.UUID `json:"id" gorm:"type:uuid;primary_key;"`
-	TenantID        uuid.UUID `json:"tenant_id" gorm:"type:uuid;index"`
-	Month           string    `json:"month" binding:"required"` // e.g., "January"
-	Year            int       `json:"year" binding:"required"`
-	BasicRent       float64   `json:"basic_rent"`
-	GasBill         float64   `json:"gas_bill"`
-	ElectricityBill float64   `json:"electricity_bill"`
-	UtilityBill     float64   `json:"utility_bill"`
-	WaterCharges    float64   `json:"water_charges"`
-	TotalPaid       float64   `json:"total_paid"`
-	IsAdvance       bool      `json:"is_advance" gorm:"default:false"`
-	PaymentDate     time.Time `json:"payment_date"`
-	CreatedAt       time.Time `json:"created_at"`
-	UpdatedAt       time.Time `json:"updated_at"`
+	ID            uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;"`
+	TenantID      uuid.UUID      `json:"tenant_id" gorm:"type:uuid;index:idx_rent_payments_period,priority:1"`
+	Period        billing.Period `json:"period" gorm:"index:idx_rent_payments_period,priority:2"` // none for an advance
+	Items         []PaymentItem  `json:"items" gorm:"foreignKey:RentPaymentID"`
+	TotalPaid     money.Amount   `json:"total_paid"`
+	IsAdvance     bool           `json:"is_advance" gorm:"not null;default:false"`
+	PaymentDate   time.Time      `json:"payment_date"`
+	Method        string         `json:"method"` // cash, bkash, bank, ... see PaymentMethod*
+	TransactionID string         `json:"transaction_id"`
+	ImportBatchID *uuid.UUID     `json:"import_batch_id,omitempty" gorm:"type:uuid;index"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
 }
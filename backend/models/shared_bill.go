@@ -0,0 +1,57 @@
+package models
+
+import (
+	"rented-backend/billing"
+	"rented-backend/money"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	SplitEqual     = "equal"
+	SplitHeadcount = "headcount"
+	SplitSize      = "size"
+	SplitCustom    = "custom"
+)
+
+// SharedBill is a house-level utility bill (e.g. one gas bill for the whole
+// building) split across the flats occupied in that month.
+type SharedBill struct {
+	ID           uuid.UUID         `json:"id" gorm:"type:uuid;primary_key;"`
+	HouseID      uuid.UUID         `json:"house_id" gorm:"type:uuid;not null;index;uniqueIndex:idx_shared_bills_period"`
+	Kind         string            `json:"kind" gorm:"uniqueIndex:idx_shared_bills_period"` // charge type code, e.g. "gas"
+	ChargeTypeID uuid.UUID         `json:"charge_type_id" gorm:"type:uuid"`
+	Period       billing.Period    `json:"period" gorm:"not null;uniqueIndex:idx_shared_bills_period"`
+	TotalAmount  money.Amount      `json:"total_amount"`
+	SplitMethod  string            `json:"split_method"`
+	Description  string            `json:"description"`
+	Shares       []SharedBillShare `json:"shares" gorm:"foreignKey:SharedBillID"`
+	CreatedAt    time.Time         `json:"created_at"`
+	UpdatedAt    time.Time         `json:"updated_at"`
+}
+
+// SharedBillShare keeps the basis each flat's share was computed from so
+// the split can be explained to a tenant who disputes it.
+type SharedBillShare struct {
+	ID           uuid.UUID    `json:"id" gorm:"type:uuid;primary_key;"`
+	SharedBillID uuid.UUID    `json:"shared_bill_id" gorm:"type:uuid;not null;index"`
+	FlatID       uuid.UUID    `json:"flat_id" gorm:"type:uuid"`
+	FlatNumber   string       `json:"flat_number"`
+	TenantID     uuid.UUID    `json:"tenant_id" gorm:"type:uuid"`
+	TenantName   string       `json:"tenant_name"`
+	Basis        float64      `json:"basis"`   // headcount, size or weight used for the split
+	Percent      float64      `json:"percent"` // share of the total, 0-100
+	Amount       money.Amount `json:"amount"`
+	ChargeID     uuid.UUID    `json:"charge_id" gorm:"type:uuid"`
+	CreatedAt    time.Time    `json:"created_at"`
+}
+
+type SharedBillRequest struct {
+	ChargeTypeID uuid.UUID             `json:"charge_type_id" binding:"required"`
+	Period       billing.Period        `json:"period"`
+	TotalAmount  money.Amount          `json:"total_amount" binding:"required,gt=0"`
+	SplitMethod  string                `json:"split_method" binding:"required,oneof=equal headcount size custom"`
+	Weights      map[uuid.UUID]float64 `json:"weights"` // flat_id -> weight, for the custom method
+	Description  string                `json:"description"`
+}
@@ -1,25 +1,32 @@
 package models
 
 import (
+	"rented-backend/money"
 	"time"
 
 	"github.com/google/uuid"
 )
 
 type Tenant struct {
-	ID            uuid.UUID `json:"id" gorm:"type:uuid;primary_key;"`
-	UserID        uuid.UUID `json:"user_id" gorm:"type:uuid"`
-	HouseID       uuid.UUID `json:"house_id" gorm:"type:uuid"`
-	FlatID        uuid.UUID `json:"flat_id" gorm:"type:uuid"`
-	Flat          Flat      `json:"flat" gorm:"foreignKey:FlatID"`
-	Name          string    `json:"name" binding:"required"`
-	Phone         string    `json:"phone" binding:"required"`
-	NIDNumber     string    `json:"nid_number"`
-	NIDFrontURL   string    `json:"nid_front_url"`
-	NIDBackURL    string    `json:"nid_back_url"`
-	IsActive      bool      `json:"is_active" gorm:"default:true"`
-	JoinDate      time.Time `json:"join_date"`
-	AdvanceAmount float64   `json:"advance_amount"`
-	CreatedAt     time.Time `json:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at"`
+	ID            uuid.UUID    `json:"id" gorm:"type:uuid;primary_key;"`
+	UserID        uuid.UUID    `json:"user_id" gorm:"type:uuid;index"`
+	HouseID       uuid.UUID    `json:"house_id" gorm:"type:uuid;index"`
+	FlatID        uuid.UUID    `json:"flat_id" gorm:"type:uuid;index"`
+	Flat          Flat         `json:"flat" gorm:"foreignKey:FlatID"`
+	Name          string       `json:"name" binding:"required"`
+	Phone         string       `json:"phone" binding:"required"`
+	Members       int          `json:"members" gorm:"default:1"` // people living in the flat
+	NIDNumber     string       `json:"nid_number"`
+	NIDFrontURL   string       `json:"nid_front_url"`
+	NIDBackURL    string       `json:"nid_back_url"`
+	IsActive      bool         `json:"is_active" gorm:"default:true"`
+	JoinDate      time.Time    `json:"join_date"`
+	LeaveDate     *time.Time   `json:"leave_date"` // last day occupied, set when the tenant is marked inactive
+	LeaseEndDate  *time.Time   `json:"lease_end_date"`
+	AdvanceAmount money.Amount `json:"advance_amount"`
+	SMSOptOut     bool         `json:"sms_opt_out"`
+	Language      string       `json:"language"` // bn or en for messages; empty uses the landlord's default
+	ImportBatchID *uuid.UUID   `json:"import_batch_id,omitempty" gorm:"type:uuid;index"`
+	CreatedAt     time.Time    `json:"created_at"`
+	UpdatedAt     time.Time    `json:"updated_at"`
 }
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Token scopes. Landlord tokens issued before scopes existed carry none.
+const (
+	TokenScopeLandlord = "landlord"
+	TokenScopeTenant   = "tenant"
+)
+
+const (
+	LoginCodeOTP  = "otp"
+	LoginCodeLink = "link"
+)
+
+// TenantLoginCode is a one-time code or magic link sent to a tenant's
+// phone. Only a hash of the secret is stored.
+type TenantLoginCode struct {
+	ID         uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;"`
+	Phone      string     `json:"phone" gorm:"index"` // normalised, see NormalizePhone
+	Kind       string     `json:"kind"`
+	SecretHash string     `json:"-" gorm:"index"`
+	Attempts   int        `json:"attempts"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	UsedAt     *time.Time `json:"used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type TenantLoginRequest struct {
+	Phone string `json:"phone" binding:"required"`
+}
+
+type TenantOTPVerifyRequest struct {
+	Phone    string     `json:"phone" binding:"required"`
+	Code     string     `json:"code" binding:"required"`
+	TenantID *uuid.UUID `json:"tenant_id"` // needed when the phone belongs to several tenancies
+}
+
+type TenantLinkVerifyRequest struct {
+	Token    string     `json:"token" binding:"required"`
+	TenantID *uuid.UUID `json:"tenant_id"`
+}
+
+type TenantAuthResponse struct {
+	Tenant Tenant `json:"tenant"`
+	Token  string `json:"token"`
+}
+
+// NormalizePhone keeps the digits of a phone number and writes Bangladeshi
+// numbers in local form, so "+880 1711-000000" becomes "01711000000".
+func NormalizePhone(phone string) string {
+	var b strings.Builder
+	for _, r := range phone {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	digits := b.String()
+	if strings.HasPrefix(digits, "880") && len(digits) == 13 {
+		digits = "0" + digits[3:]
+	}
+	return digits
+}
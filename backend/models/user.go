@@ -1,19 +1,62 @@
 package models
 
 import (
+	"rented-backend/billing"
+	"rented-backend/money"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// DefaultTimezone is the time zone of accounts that have not chosen one.
+const DefaultTimezone = "Asia/Dhaka"
+
+// User is a landlord account. Timezone decides where the account's days
+// and billing months begin; timestamps are still stored in UTC. Locale is
+// the language (bn or en) of exports and reports when none is asked for.
+// Currency is the currency of every amount the account holds.
+//
+// A caretaker is a user with OwnerID set. They sign in with their own
+// email and password and work on the owner's account.
 type User struct {
-	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;"`
-	Email     string    `json:"email" gorm:"unique;not null"`
-	Password  string    `json:"-"`
-	Name      string    `json:"name"`
-	GoogleID  string    `json:"google_id" gorm:"uniqueIndex"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID        uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;"`
+	OwnerID   *uuid.UUID     `json:"owner_id,omitempty" gorm:"type:uuid;index"`
+	Email     string         `json:"email" gorm:"unique;not null"`
+	Password  string         `json:"-"`
+	Name      string         `json:"name"`
+	GoogleID  string         `json:"google_id" gorm:"uniqueIndex"`
+	Timezone  string         `json:"timezone" gorm:"not null;default:Asia/Dhaka"`
+	Currency  money.Currency `json:"currency" gorm:"not null;default:BDT"`
+	Locale    string         `json:"locale" gorm:"not null;default:en"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+// Location is the account's time zone, or DefaultTimezone if it is unset
+// or unknown.
+func (u User) Location() *time.Location {
+	if u.Timezone != "" {
+		if loc, err := time.LoadLocation(u.Timezone); err == nil {
+			return loc
+		}
+	}
+	loc, err := time.LoadLocation(DefaultTimezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// Today is the account's current calendar date, see billing.Today.
+func (u User) Today(now time.Time) time.Time {
+	return billing.Today(now, u.Location())
+}
+
+// AccountSettingsRequest changes where and how an account is billed.
+type AccountSettingsRequest struct {
+	Timezone string `json:"timezone" binding:"required"` // IANA name, e.g. Asia/Dhaka
+	Currency string `json:"currency" binding:"required,len=3,uppercase"`
+	Locale   string `json:"locale" binding:"required,oneof=bn en"`
 }
 
 type RegisterRequest struct {
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"rented-backend/money"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Vendor is a plumber, electrician, caretaker or other service provider
+// the landlord pays. Expenses and maintenance tickets can point to one.
+type Vendor struct {
+	ID             uuid.UUID `json:"id" gorm:"type:uuid;primary_key;"`
+	UserID         uuid.UUID `json:"user_id" gorm:"type:uuid;index"`
+	Name           string    `json:"name"`
+	Trade          string    `json:"trade"` // e.g. plumber, electrician, painter
+	Phone          string    `json:"phone"`
+	PaymentDetails string    `json:"payment_details"` // bKash number, bank account, ...
+	Notes          string    `json:"notes"`
+	Rating         int       `json:"rating"` // 1-5, 0 means not rated
+	IsActive       bool      `json:"is_active" gorm:"default:true"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// VendorSpend is what was paid to a vendor in a year.
+type VendorSpend struct {
+	VendorID   uuid.UUID    `json:"vendor_id"`
+	VendorName string       `json:"vendor_name"`
+	Year       int          `json:"year"`
+	Amount     money.Amount `json:"amount"`
+	Payments   int          `json:"payments"`
+}
+
+// VendorHistory is a vendor's jobs and payments.
+type VendorHistory struct {
+	Vendor      Vendor              `json:"vendor"`
+	Jobs        []MaintenanceTicket `json:"jobs"`
+	Payments    []Expense           `json:"payments"`
+	YearlySpend []VendorSpend       `json:"yearly_spend"`
+}
@@ -0,0 +1,177 @@
+// Package money holds amounts as a whole number of minor units (paisa for
+// taka) so sums and comparisons are exact.
+//
+// In JSON an Amount is a string with two decimals in major units, e.g.
+// "1500.50" for 1,500 taka 50 paisa, so no client reads it through a
+// float. A bare number such as 1500.50 is accepted as well and is read from
+// the decimal literal itself. More than two decimals is an error. In the
+// database an Amount is a bigint of minor units.
+//
+// Amounts carry no currency of their own. Every amount belonging to an
+// account is in that account's currency (User.Currency), so it is stored
+// and sent once per account rather than with each amount.
+package money
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Amount is a sum of money in minor units.
+type Amount int64
+
+// Scale is the number of minor units in one major unit.
+const Scale = 100
+
+// Currency is an ISO 4217 currency code.
+type Currency string
+
+const BDT Currency = "BDT"
+
+// DefaultCurrency is used for accounts that have not chosen one.
+const DefaultCurrency = BDT
+
+var ErrInvalid = errors.New("amount must be a number with at most two decimals")
+
+// New returns major units plus minor units, e.g. New(1500, 50) for 1500.50.
+func New(major, minor int64) Amount {
+	return Amount(major*Scale + minor)
+}
+
+// Parse reads a decimal such as "1500", "-20.5", ".50" or "1,500.50".
+// Thousands separators must group the whole part in threes; a trailing
+// point such as "1." is an error.
+func Parse(s string) (Amount, error) {
+	s = strings.TrimSpace(s)
+	negative := false
+	switch {
+	case strings.HasPrefix(s, "-"):
+		negative, s = true, s[1:]
+	case strings.HasPrefix(s, "+"):
+		s = s[1:]
+	}
+	whole, frac, hasPoint := strings.Cut(s, ".")
+	if (whole == "" && frac == "") || (hasPoint && frac == "") || len(frac) > 2 {
+		return 0, ErrInvalid
+	}
+	if strings.Contains(whole, ",") {
+		if !grouped(whole) {
+			return 0, ErrInvalid
+		}
+		whole = strings.ReplaceAll(whole, ",", "")
+	}
+	if !digitsOnly(whole) || !digitsOnly(frac) {
+		return 0, ErrInvalid
+	}
+
+	var major, minor int64
+	if frac != "" {
+		minor, _ = strconv.ParseInt(frac, 10, 64)
+		if len(frac) == 1 {
+			minor *= 10
+		}
+	}
+	if whole != "" {
+		var err error
+		if major, err = strconv.ParseInt(whole, 10, 64); err != nil || major > (math.MaxInt64-minor)/Scale {
+			return 0, ErrInvalid
+		}
+	}
+	a := Amount(major*Scale + minor)
+	if negative {
+		a = -a
+	}
+	return a, nil
+}
+
+// grouped reports whether s is split by commas into groups of three
+// digits, the first of one to three, as in "1,500" or "12,345,678".
+func grouped(s string) bool {
+	groups := strings.Split(s, ",")
+	if len(groups[0]) < 1 || len(groups[0]) > 3 {
+		return false
+	}
+	for _, g := range groups[1:] {
+		if len(g) != 3 {
+			return false
+		}
+	}
+	return true
+}
+
+func digitsOnly(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// FromFloat rounds f, in major units, to the nearest minor unit. It is
+// meant for amounts worked out from rates, such as units times a tariff.
+func FromFloat(f float64) Amount {
+	return Amount(math.Round(f * Scale))
+}
+
+// Float64 returns the amount in major units, for ratios and spreadsheet
+// cells. Don't add amounts up as floats.
+func (a Amount) Float64() float64 {
+	return float64(a) / Scale
+}
+
+// Mul returns a times f rounded to the nearest minor unit, e.g. a prorated
+// share of a monthly charge.
+func (a Amount) Mul(f float64) Amount {
+	return Amount(math.Round(float64(a) * f))
+}
+
+// Percent returns p percent of a rounded to the nearest minor unit.
+func (a Amount) Percent(p float64) Amount {
+	return a.Mul(p / 100)
+}
+
+// String formats the amount in major units with two decimals, e.g.
+// "1500.50".
+func (a Amount) String() string {
+	sign := ""
+	u := uint64(a)
+	if a < 0 {
+		sign, u = "-", uint64(-a)
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, u/Scale, u%Scale)
+}
+
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(a.String())), nil
+}
+
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	s := string(data)
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	}
+	parsed, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	*a = parsed
+	return nil
+}
+
+// UnmarshalParam reads amounts sent as form fields or query parameters.
+func (a *Amount) UnmarshalParam(param string) error {
+	parsed, err := Parse(param)
+	if err != nil {
+		return err
+	}
+	*a = parsed
+	return nil
+}
@@ -0,0 +1,147 @@
+package money
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Amount
+		wantErr bool
+	}{
+		{in: "1500", want: New(1500, 0)},
+		{in: "1500.5", want: New(1500, 50)},
+		{in: "1500.05", want: New(1500, 5)},
+		{in: ".50", want: New(0, 50)},
+		{in: " 20 ", want: New(20, 0)},
+		{in: "+20.10", want: New(20, 10)},
+		{in: "-20.5", want: -New(20, 50)},
+		{in: "-0.01", want: -1},
+		{in: "1,500.50", want: New(1500, 50)},
+		{in: "12,345,678", want: New(12345678, 0)},
+		{in: "92233720368547757.99", want: New(92233720368547757, 99)},
+		{in: "-92233720368547757.99", want: -New(92233720368547757, 99)},
+		{in: "", wantErr: true},
+		{in: "-", wantErr: true},
+		{in: ".", wantErr: true},
+		{in: "1.", wantErr: true},
+		{in: "1.005", wantErr: true},
+		{in: "0.001", wantErr: true},
+		{in: "--1", wantErr: true},
+		{in: "+-1", wantErr: true},
+		{in: "1e3", wantErr: true},
+		{in: "1 000", wantErr: true},
+		{in: "1,5", wantErr: true},
+		{in: "1500,50", wantErr: true},
+		{in: ",500", wantErr: true},
+		{in: "1,500,", wantErr: true},
+		{in: "1234,567", wantErr: true},
+		{in: "1.50,00", wantErr: true},
+		{in: "92233720368547758.07", want: math.MaxInt64},
+		{in: "92233720368547758.08", wantErr: true},
+		{in: "92233720368547759", wantErr: true},
+		{in: "99999999999999999999", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Parse(%q) = %d, want an error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.in, err)
+		} else if got != tt.want {
+			t.Errorf("Parse(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		in   Amount
+		want string
+	}{
+		{0, "0.00"},
+		{5, "0.05"},
+		{-5, "-0.05"},
+		{New(1500, 50), "1500.50"},
+		{-New(1500, 50), "-1500.50"},
+		{math.MaxInt64, "92233720368547758.07"},
+		{math.MinInt64, "-92233720368547758.08"},
+	}
+	for _, tt := range tests {
+		if got := tt.in.String(); got != tt.want {
+			t.Errorf("Amount(%d).String() = %s, want %s", int64(tt.in), got, tt.want)
+		}
+	}
+}
+
+func TestMarshalJSON(t *testing.T) {
+	got, err := json.Marshal(struct {
+		Amount Amount `json:"amount"`
+	}{-New(1500, 5)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"amount":"-1500.05"}`; string(got) != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Amount
+		wantErr bool
+	}{
+		{in: `"1500.50"`, want: New(1500, 50)},
+		{in: `1500.50`, want: New(1500, 50)},
+		{in: `"-0.50"`, want: -50},
+		{in: `-0.5`, want: -50},
+		{in: `"1,500.50"`, want: New(1500, 50)},
+		{in: `0.1`, want: 10},
+		{in: `null`, want: 7}, // left untouched
+		{in: `1.005`, wantErr: true},
+		{in: `"1."`, wantErr: true},
+		{in: `1e2`, wantErr: true},
+		{in: `"abc"`, wantErr: true},
+		{in: `true`, wantErr: true},
+		{in: `99999999999999999999`, wantErr: true},
+	}
+	for _, tt := range tests {
+		a := Amount(7)
+		err := json.Unmarshal([]byte(tt.in), &a)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Unmarshal(%s) = %d, want an error", tt.in, a)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Unmarshal(%s): %v", tt.in, err)
+		} else if a != tt.want {
+			t.Errorf("Unmarshal(%s) = %d, want %d", tt.in, a, tt.want)
+		}
+	}
+}
+
+// Every amount survives a round trip through its JSON form.
+func TestJSONRoundTrip(t *testing.T) {
+	for _, a := range []Amount{0, 1, -1, 99, New(1500, 50), -New(1500, 50), math.MaxInt64 - 1, math.MinInt64 + 1} {
+		data, err := json.Marshal(a)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var back Amount
+		if err := json.Unmarshal(data, &back); err != nil {
+			t.Errorf("%s: %v", data, err)
+		} else if back != a {
+			t.Errorf("%s read back as %d, want %d", data, back, a)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// HTTPNotifier posts messages to an SMS gateway as a form with api_key,
+// senderid, number and message, the shape most local gateways accept.
+type HTTPNotifier struct {
+	url      string
+	apiKey   string
+	senderID string
+	client   *http.Client
+}
+
+func NewHTTPNotifier(gatewayURL, apiKey, senderID string) *HTTPNotifier {
+	return &HTTPNotifier{
+		url:      gatewayURL,
+		apiKey:   apiKey,
+		senderID: senderID,
+		client:   &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+func (n *HTTPNotifier) Send(ctx context.Context, phone, message string) (Result, error) {
+	form := url.Values{
+		"api_key":  {n.apiKey},
+		"senderid": {n.senderID},
+		"number":   {phone},
+		"message":  {message},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, strings.NewReader(form.Encode()))
+	if err != nil {
+		return Result{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return Result{}, err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode >= 300 {
+		return Result{}, fmt.Errorf("sms gateway returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	// Gateways name the message ID differently; take whichever is present
+	var parsed struct {
+		MessageID any `json:"message_id"`
+		ID        any `json:"id"`
+		RequestID any `json:"request_id"`
+	}
+	result := Result{}
+	if json.Unmarshal(body, &parsed) == nil {
+		for _, id := range []any{parsed.MessageID, parsed.ID, parsed.RequestID} {
+			if id != nil {
+				result.ProviderID = fmt.Sprintf("%v", id)
+				break
+			}
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,41 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"rented-backend/logger"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LogNotifier writes messages to a file, or to the application log when no
+// file is set, instead of sending them.
+type LogNotifier struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewLogNotifier(path string) *LogNotifier {
+	return &LogNotifier{path: path}
+}
+
+func (n *LogNotifier) Send(ctx context.Context, phone, message string) (Result, error) {
+	result := Result{ProviderID: "log-" + uuid.NewString()}
+	if n.path == "" {
+		logger.Log.Info("SMS", "phone", phone, "message", message, "id", result.ProviderID)
+		return result, nil
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	f, err := os.OpenFile(n.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return Result{}, err
+	}
+	defer f.Close()
+	_, err = fmt.Fprintf(f, "%s\t%s\t%s\t%s\n", time.Now().Format(time.RFC3339), result.ProviderID, phone, message)
+	return result, err
+}
@@ -0,0 +1,40 @@
+// Package notify delivers text messages to phones. Drivers: an HTTP SMS
+// gateway for production and a log/file driver for development.
+package notify
+
+import (
+	"context"
+	"fmt"
+)
+
+// Result is the gateway's answer to a send.
+type Result struct {
+	ProviderID string // the gateway's message ID, used to match delivery reports
+}
+
+type Notifier interface {
+	Send(ctx context.Context, phone, message string) (Result, error)
+}
+
+type Config struct {
+	Driver   string // http or log
+	URL      string
+	APIKey   string
+	SenderID string
+	LogFile  string // log driver only; empty logs to the application log
+}
+
+// New returns the driver named in cfg.
+func New(cfg Config) (Notifier, error) {
+	switch cfg.Driver {
+	case "", "log":
+		return NewLogNotifier(cfg.LogFile), nil
+	case "http":
+		if cfg.URL == "" {
+			return nil, fmt.Errorf("SMS gateway URL is required for the http driver")
+		}
+		return NewHTTPNotifier(cfg.URL, cfg.APIKey, cfg.SenderID), nil
+	default:
+		return nil, fmt.Errorf("unknown SMS driver %q", cfg.Driver)
+	}
+}
@@ -0,0 +1,115 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/auth"
+	"cloud.google.com/go/auth/credentials"
+)
+
+const fcmScope = "https://www.googleapis.com/auth/firebase.messaging"
+
+// FCMPusher sends through the Firebase Cloud Messaging HTTP v1 API,
+// authenticated as a service account.
+type FCMPusher struct {
+	creds  *auth.Credentials
+	url    string
+	client *http.Client
+}
+
+func NewFCMPusher(ctx context.Context, projectID, credentialsFile string) (*FCMPusher, error) {
+	opts := &credentials.DetectOptions{Scopes: []string{fcmScope}}
+	var creds *auth.Credentials
+	var err error
+	if credentialsFile != "" {
+		creds, err = credentials.NewCredentialsFromFile(credentials.ServiceAccount, credentialsFile, opts)
+	} else {
+		creds, err = credentials.DetectDefault(opts)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("loading FCM credentials: %w", err)
+	}
+
+	if projectID == "" {
+		if projectID, err = creds.ProjectID(ctx); err != nil {
+			return nil, fmt.Errorf("reading FCM project: %w", err)
+		}
+		if projectID == "" {
+			return nil, fmt.Errorf("FCM project ID is required")
+		}
+	}
+
+	return &FCMPusher{
+		creds:  creds,
+		url:    "https://fcm.googleapis.com/v1/projects/" + projectID + "/messages:send",
+		client: &http.Client{Timeout: 15 * time.Second},
+	}, nil
+}
+
+func (p *FCMPusher) Send(ctx context.Context, token string, msg Message) error {
+	payload := map[string]any{
+		"message": map[string]any{
+			"token": token,
+			"notification": map[string]string{
+				"title": msg.Title,
+				"body":  msg.Body,
+			},
+			"data": msg.Data,
+		},
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	accessToken, err := p.creds.Token(ctx)
+	if err != nil {
+		return fmt.Errorf("getting FCM access token: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+accessToken.Value)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 300 {
+		return nil
+	}
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	var parsed struct {
+		Error struct {
+			Status  string `json:"status"`
+			Details []struct {
+				ErrorCode string `json:"errorCode"`
+			} `json:"details"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(respBody, &parsed) == nil {
+		// UNREGISTERED: the app was uninstalled or the token rotated
+		if parsed.Error.Status == "NOT_FOUND" {
+			return ErrInvalidToken
+		}
+		for _, d := range parsed.Error.Details {
+			if d.ErrorCode == "UNREGISTERED" {
+				return ErrInvalidToken
+			}
+		}
+	}
+	return fmt.Errorf("fcm returned %s: %s", resp.Status, strings.TrimSpace(string(respBody)))
+}
@@ -0,0 +1,42 @@
+// Package push delivers notifications to the landlord mobile app. Drivers:
+// Firebase Cloud Messaging for production and an in-memory stub for
+// development and tests.
+package push
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidToken means the device token is no longer registered and
+// should be dropped.
+var ErrInvalidToken = errors.New("device token is no longer valid")
+
+type Message struct {
+	Title string
+	Body  string
+	Data  map[string]string // delivered to the app alongside the alert
+}
+
+type Pusher interface {
+	Send(ctx context.Context, token string, msg Message) error
+}
+
+type Config struct {
+	Driver          string // fcm or stub
+	ProjectID       string // fcm only; empty uses the credentials' project
+	CredentialsFile string // fcm only; a service account key, empty uses the default credentials
+}
+
+// New returns the driver named in cfg.
+func New(ctx context.Context, cfg Config) (Pusher, error) {
+	switch cfg.Driver {
+	case "", "stub":
+		return NewStubPusher(), nil
+	case "fcm":
+		return NewFCMPusher(ctx, cfg.ProjectID, cfg.CredentialsFile)
+	default:
+		return nil, fmt.Errorf("unknown push driver %q", cfg.Driver)
+	}
+}
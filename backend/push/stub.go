@@ -0,0 +1,50 @@
+package push
+
+import (
+	"context"
+	"rented-backend/logger"
+	"sync"
+)
+
+// Sent is a message the stub driver accepted.
+type Sent struct {
+	Token   string
+	Message Message
+}
+
+// StubPusher keeps messages in memory instead of sending them. Tokens
+// added with Invalidate fail with ErrInvalidToken.
+type StubPusher struct {
+	mu      sync.Mutex
+	sent    []Sent
+	invalid map[string]bool
+}
+
+func NewStubPusher() *StubPusher {
+	return &StubPusher{invalid: map[string]bool{}}
+}
+
+func (p *StubPusher) Send(ctx context.Context, token string, msg Message) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.invalid[token] {
+		return ErrInvalidToken
+	}
+	p.sent = append(p.sent, Sent{Token: token, Message: msg})
+	logger.Log.Info("Push", "token", token, "title", msg.Title, "body", msg.Body)
+	return nil
+}
+
+// Invalidate makes later sends to token fail as unregistered.
+func (p *StubPusher) Invalidate(token string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.invalid[token] = true
+}
+
+// Sent returns the messages accepted so far, oldest first.
+func (p *StubPusher) Sent() []Sent {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]Sent(nil), p.sent...)
+}
@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"rented-backend/database"
+	"rented-backend/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type AuditFilter struct {
+	Entity   string
+	EntityID string
+	ActorID  *uuid.UUID
+	From     *time.Time
+	To       *time.Time
+	Limit    int
+}
+
+type AuditRepository interface {
+	List(accountID uuid.UUID, filter AuditFilter) ([]models.AuditLog, error)
+}
+
+type auditRepository struct{}
+
+func NewAuditRepository() AuditRepository {
+	return &auditRepository{}
+}
+
+func (r *auditRepository) List(accountID uuid.UUID, filter AuditFilter) ([]models.AuditLog, error) {
+	logs := []models.AuditLog{}
+	query := database.DB.Where("account_id = ?", accountID)
+
+	if filter.Entity != "" {
+		query = query.Where("entity = ?", filter.Entity)
+	}
+	if filter.EntityID != "" {
+		query = query.Where("entity_id = ?", filter.EntityID)
+	}
+	if filter.ActorID != nil {
+		query = query.Where("actor_id = ?", *filter.ActorID)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+
+	limit := filter.Limit
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+
+	err := query.Order("created_at DESC").Limit(limit).Find(&logs).Error
+	return logs, err
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"rented-backend/database"
+	"rented-backend/models"
+
+	"github.com/google/uuid"
+)
+
+type ChargeRepository interface {
+	Create(ctx context.Context, charge *models.Charge) error
+	Update(ctx context.Context, charge *models.Charge) error
+	GetByTenantID(tenantID uuid.UUID) ([]models.Charge, error)
+	GetByUserID(userID uuid.UUID) ([]models.Charge, error)
+	GetByID(id uuid.UUID, userID uuid.UUID) (*models.Charge, error)
+	Delete(ctx context.Context, charge *models.Charge) error
+}
+
+type chargeRepository struct{}
+
+func NewChargeRepository() ChargeRepository {
+	return &chargeRepository{}
+}
+
+func (r *chargeRepository) Create(ctx context.Context, charge *models.Charge) error {
+	return database.DB.WithContext(ctx).Create(charge).Error
+}
+
+func (r *chargeRepository) Update(ctx context.Context, charge *models.Charge) error {
+	return database.DB.WithContext(ctx).Save(charge).Error
+}
+
+func (r *chargeRepository) GetByTenantID(tenantID uuid.UUID) ([]models.Charge, error) {
+	charges := []models.Charge{}
+	err := database.DB.Where("tenant_id = ?", tenantID).Order("period, created_at").Find(&charges).Error
+	return charges, err
+}
+
+// GetByUserID returns the charges of every tenant of the landlord.
+func (r *chargeRepository) GetByUserID(userID uuid.UUID) ([]models.Charge, error) {
+	charges := []models.Charge{}
+	err := database.DB.Where("tenant_id IN (SELECT id FROM tenants WHERE user_id = ?)", userID).Order("period, created_at").Find(&charges).Error
+	return charges, err
+}
+
+func (r *chargeRepository) GetByID(id uuid.UUID, userID uuid.UUID) (*models.Charge, error) {
+	var charge models.Charge
+	err := database.DB.
+		Joins("JOIN tenants ON tenants.id = charges.tenant_id").
+		Where("charges.id = ? AND tenants.user_id = ?", id, userID).
+		First(&charge).Error
+	if err != nil {
+		return nil, err
+	}
+	return &charge, nil
+}
+
+func (r *chargeRepository) Delete(ctx context.Context, charge *models.Charge) error {
+	return database.DB.WithContext(ctx).Delete(charge).Error
+}
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"rented-backend/database"
+	"rented-backend/models"
+
+	"github.com/google/uuid"
+)
+
+type ChargeTypeRepository interface {
+	Create(ctx context.Context, chargeType *models.ChargeType) error
+	Update(ctx context.Context, chargeType *models.ChargeType) error
+	GetAll(userID uuid.UUID) ([]models.ChargeType, error)
+	GetByID(id uuid.UUID, userID uuid.UUID) (*models.ChargeType, error)
+	GetByCode(userID uuid.UUID, code string) (*models.ChargeType, error)
+	GetByIDs(userID uuid.UUID, ids []uuid.UUID) (map[uuid.UUID]models.ChargeType, error)
+}
+
+type chargeTypeRepository struct{}
+
+func NewChargeTypeRepository() ChargeTypeRepository {
+	return &chargeTypeRepository{}
+}
+
+func (r *chargeTypeRepository) Create(ctx context.Context, chargeType *models.ChargeType) error {
+	return database.DB.WithContext(ctx).Create(chargeType).Error
+}
+
+func (r *chargeTypeRepository) Update(ctx context.Context, chargeType *models.ChargeType) error {
+	return database.DB.WithContext(ctx).Save(chargeType).Error
+}
+
+func (r *chargeTypeRepository) GetAll(userID uuid.UUID) ([]models.ChargeType, error) {
+	chargeTypes := []models.ChargeType{}
+	err := database.DB.Where("user_id = ?", userID).Order("created_at").Find(&chargeTypes).Error
+	return chargeTypes, err
+}
+
+func (r *chargeTypeRepository) GetByID(id uuid.UUID, userID uuid.UUID) (*models.ChargeType, error) {
+	var chargeType models.ChargeType
+	err := database.DB.Where("id = ? AND user_id = ?", id, userID).First(&chargeType).Error
+	if err != nil {
+		return nil, err
+	}
+	return &chargeType, nil
+}
+
+func (r *chargeTypeRepository) GetByCode(userID uuid.UUID, code string) (*models.ChargeType, error) {
+	var chargeType models.ChargeType
+	err := database.DB.Where("user_id = ? AND code = ?", userID, code).First(&chargeType).Error
+	if err != nil {
+		return nil, err
+	}
+	return &chargeType, nil
+}
+
+// GetByIDs returns the landlord's charge types among ids, keyed by ID.
+// IDs that don't belong to the landlord are simply missing from the map.
+func (r *chargeTypeRepository) GetByIDs(userID uuid.UUID, ids []uuid.UUID) (map[uuid.UUID]models.ChargeType, error) {
+	var chargeTypes []models.ChargeType
+	if err := database.DB.Where("user_id = ? AND id IN ?", userID, ids).Find(&chargeTypes).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[uuid.UUID]models.ChargeType, len(chargeTypes))
+	for _, ct := range chargeTypes {
+		byID[ct.ID] = ct
+	}
+	return byID, nil
+}
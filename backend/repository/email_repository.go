@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"rented-backend/database"
+	"rented-backend/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Email preferences and the send log are not landlord data, so they are
+// not audited.
+
+type EmailRepository interface {
+	GetPreferences(userID uuid.UUID) (*models.EmailPreferences, error)
+	GetPreferencesByToken(token string) (*models.EmailPreferences, error)
+	SavePreferences(prefs *models.EmailPreferences) error
+	ClaimReportEmail(email *models.ReportEmail) error
+	UpdateReportEmail(email *models.ReportEmail) error
+}
+
+// ErrReportClaimed is returned when the period's summary was already sent
+// or is being sent.
+var ErrReportClaimed = errors.New("monthly summary already sent")
+
+type emailRepository struct{}
+
+func NewEmailRepository() EmailRepository {
+	return &emailRepository{}
+}
+
+// GetPreferences returns the landlord's preferences, creating the default
+// (everything on) with a fresh unsubscribe token the first time.
+func (r *emailRepository) GetPreferences(userID uuid.UUID) (*models.EmailPreferences, error) {
+	var prefs models.EmailPreferences
+	err := database.DB.Where("user_id = ?", userID).First(&prefs).Error
+	if err == nil {
+		return &prefs, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	token := make([]byte, 24)
+	if _, err := rand.Read(token); err != nil {
+		return nil, err
+	}
+	prefs = models.EmailPreferences{
+		ID:               uuid.New(),
+		UserID:           userID,
+		MonthlySummary:   true,
+		UnsubscribeToken: hex.EncodeToString(token),
+	}
+	if err := database.DB.Create(&prefs).Error; err != nil {
+		return nil, err
+	}
+	return &prefs, nil
+}
+
+func (r *emailRepository) GetPreferencesByToken(token string) (*models.EmailPreferences, error) {
+	var prefs models.EmailPreferences
+	if err := database.DB.Where("unsubscribe_token = ?", token).First(&prefs).Error; err != nil {
+		return nil, err
+	}
+	return &prefs, nil
+}
+
+func (r *emailRepository) SavePreferences(prefs *models.EmailPreferences) error {
+	return database.DB.Save(prefs).Error
+}
+
+// ClaimReportEmail records the summary as pending before it is sent. A
+// period that failed before is claimed again; one that is pending or sent
+// returns ErrReportClaimed.
+func (r *emailRepository) ClaimReportEmail(email *models.ReportEmail) error {
+	email.Status = models.EmailPending
+	err := database.DB.Create(email).Error
+	if !errors.Is(err, gorm.ErrDuplicatedKey) {
+		return err
+	}
+
+	var existing models.ReportEmail
+	if err := database.DB.Where("user_id = ? AND period = ?", email.UserID, email.Period).First(&existing).Error; err != nil {
+		return err
+	}
+	result := database.DB.Model(&models.ReportEmail{}).Where("id = ? AND status = ?", existing.ID, models.EmailFailed).
+		Updates(map[string]any{"status": models.EmailPending, "to": email.To, "error": ""})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrReportClaimed
+	}
+	email.ID = existing.ID
+	email.CreatedAt = existing.CreatedAt
+	return nil
+}
+
+// UpdateReportEmail saves the outcome of a claimed send.
+func (r *emailRepository) UpdateReportEmail(email *models.ReportEmail) error {
+	return database.DB.Model(email).Updates(map[string]any{"status": email.Status, "error": email.Error}).Error
+}
@@ -0,0 +1,201 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"rented-backend/billing"
+	"rented-backend/database"
+	"rented-backend/models"
+	"rented-backend/money"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var ErrAlreadyBooked = errors.New("recurring expense was already booked")
+
+type ExpenseFilter struct {
+	HouseID  *uuid.UUID
+	FlatID   *uuid.UUID
+	Category string
+	From     *time.Time
+	To       *time.Time // exclusive
+}
+
+// NOIRow is one house's net operating income for a month: rent collected
+// for the month less the expenses dated in it.
+type NOIRow struct {
+	HouseID   uuid.UUID      `json:"house_id"`
+	HouseName string         `json:"house_name"`
+	Period    billing.Period `json:"period"`
+	Income    money.Amount   `json:"income"`
+	Expenses  money.Amount   `json:"expenses"`
+	NOI       money.Amount   `json:"noi"`
+}
+
+type ExpenseRepository interface {
+	Create(ctx context.Context, expense *models.Expense) error
+	Update(ctx context.Context, expense *models.Expense) error
+	Delete(ctx context.Context, expense *models.Expense) error
+	GetByID(id uuid.UUID, userID uuid.UUID) (*models.Expense, error)
+	List(userID uuid.UUID, filter ExpenseFilter) ([]models.Expense, error)
+	Stream(userID uuid.UUID, filter ExpenseFilter, fn func(models.Expense) error) error
+	GetDueRecurring(now time.Time) ([]models.Expense, error)
+	BookRecurring(ctx context.Context, tmpl *models.Expense, occurrences []models.Expense, next time.Time) error
+	NetOperatingIncome(userID uuid.UUID, filter DashboardFilter) ([]NOIRow, error)
+}
+
+type expenseRepository struct{}
+
+func NewExpenseRepository() ExpenseRepository {
+	return &expenseRepository{}
+}
+
+func (r *expenseRepository) Create(ctx context.Context, expense *models.Expense) error {
+	return database.DB.WithContext(ctx).Create(expense).Error
+}
+
+func (r *expenseRepository) Update(ctx context.Context, expense *models.Expense) error {
+	return database.DB.WithContext(ctx).Save(expense).Error
+}
+
+func (r *expenseRepository) Delete(ctx context.Context, expense *models.Expense) error {
+	return database.DB.WithContext(ctx).Delete(expense).Error
+}
+
+func (r *expenseRepository) GetByID(id uuid.UUID, userID uuid.UUID) (*models.Expense, error) {
+	var expense models.Expense
+	err := database.DB.Where("id = ? AND user_id = ?", id, userID).First(&expense).Error
+	if err != nil {
+		return nil, err
+	}
+	return &expense, nil
+}
+
+func (r *expenseRepository) List(userID uuid.UUID, filter ExpenseFilter) ([]models.Expense, error) {
+	expenses := []models.Expense{}
+	err := expenseQuery(userID, filter).Order("date DESC").Find(&expenses).Error
+	return expenses, err
+}
+
+// Stream calls fn for each matching expense, oldest first, reading one row
+// at a time. It stops at the first error fn returns.
+func (r *expenseRepository) Stream(userID uuid.UUID, filter ExpenseFilter, fn func(models.Expense) error) error {
+	rows, err := expenseQuery(userID, filter).Model(&models.Expense{}).Order("date, created_at").Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var expense models.Expense
+		if err := database.DB.ScanRows(rows, &expense); err != nil {
+			return err
+		}
+		if err := fn(expense); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func expenseQuery(userID uuid.UUID, filter ExpenseFilter) *gorm.DB {
+	query := database.DB.Where("user_id = ?", userID)
+	if filter.HouseID != nil {
+		query = query.Where("house_id = ?", *filter.HouseID)
+	}
+	if filter.FlatID != nil {
+		query = query.Where("flat_id = ?", *filter.FlatID)
+	}
+	if filter.Category != "" {
+		query = query.Where("category = ?", filter.Category)
+	}
+	if filter.From != nil {
+		query = query.Where("date >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("date < ?", *filter.To)
+	}
+	return query
+}
+
+// GetDueRecurring returns recurring expenses whose next copy is due.
+func (r *expenseRepository) GetDueRecurring(now time.Time) ([]models.Expense, error) {
+	var expenses []models.Expense
+	err := database.DB.
+		Where("recurrence <> ? AND next_date IS NOT NULL AND next_date <= ?", models.ExpenseRepeatNone, now).
+		Find(&expenses).Error
+	return expenses, err
+}
+
+// BookRecurring records copies of a recurring expense and moves its next
+// date on to next, in one transaction. The template is claimed first, at
+// the next date it was read with, so when another run has booked it in the
+// meantime nothing is recorded and ErrAlreadyBooked is returned.
+func (r *expenseRepository) BookRecurring(ctx context.Context, tmpl *models.Expense, occurrences []models.Expense, next time.Time) error {
+	return database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// By value: Update writes the new date through tmpl.NextDate
+		readAt := *tmpl.NextDate
+		result := tx.Model(tmpl).Where("next_date = ?", readAt).Update("next_date", next)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrAlreadyBooked
+		}
+		if len(occurrences) == 0 {
+			return nil
+		}
+		return tx.Create(&occurrences).Error
+	})
+}
+
+const noiQuery = `
+WITH income AS (
+	SELECT t.house_id, p.period, SUM(p.total_paid) AS amount
+	FROM rent_payments p
+	JOIN tenants t ON t.id = p.tenant_id
+	WHERE t.user_id = @user AND NOT p.is_advance
+	GROUP BY t.house_id, p.period
+),
+spent AS (
+	SELECT e.house_id, CAST(date_trunc('month', e.date) AS date) AS period, SUM(e.amount) AS amount
+	FROM expenses e
+	WHERE e.user_id = @user AND e.date >= @from AND e.date < @until
+	GROUP BY e.house_id, 2
+),
+months AS (
+	SELECT CAST(m AS date) AS period
+	FROM generate_series(CAST(@from AS date), CAST(@to AS date), interval '1 month') AS m
+)
+SELECT h.id AS house_id, h.name AS house_name, months.period,
+	CAST(COALESCE(income.amount, 0) AS bigint) AS income,
+	CAST(COALESCE(spent.amount, 0) AS bigint) AS expenses
+FROM houses h
+CROSS JOIN months
+LEFT JOIN income ON income.house_id = h.id AND income.period = months.period
+LEFT JOIN spent ON spent.house_id = h.id AND spent.period = months.period
+WHERE h.user_id = @user AND (CAST(@house AS uuid) IS NULL OR h.id = CAST(@house AS uuid))
+ORDER BY h.name, months.period`
+
+// NetOperatingIncome returns income, expenses and NOI per house per month
+// over the filter's months.
+func (r *expenseRepository) NetOperatingIncome(userID uuid.UUID, filter DashboardFilter) ([]NOIRow, error) {
+	rows := []NOIRow{}
+	err := database.DB.Raw(noiQuery,
+		sql.Named("user", userID),
+		sql.Named("from", filter.From),
+		sql.Named("to", filter.To),
+		sql.Named("until", filter.To.AddDate(0, 1, 0)),
+		sql.Named("house", filter.HouseID),
+	).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for i := range rows {
+		rows[i].NOI = rows[i].Income - rows[i].Expenses
+	}
+	return rows, nil
+}
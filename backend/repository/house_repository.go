@@ -1,19 +1,22 @@
 package repository
 
 import (
+	"context"
 	"rented-backend/database"
 	"rented-backend/models"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type HouseRepository interface {
-	CreateHouse(house *models.House) error
+	CreateHouse(ctx context.Context, house *models.House) error
 	GetUserHouses(userID uuid.UUID) ([]models.House, error)
-	CreateFlat(flat *models.Flat) error
+	CreateFlat(ctx context.Context, flat *models.Flat) error
 	GetHouseFlats(houseID uuid.UUID) ([]models.Flat, error)
 	GetHouseByID(id uuid.UUID) (*models.House, error)
 	GetFlatByID(id uuid.UUID) (*models.Flat, error)
+	ReplaceFlatCharges(ctx context.Context, flatID uuid.UUID, charges []models.FlatCharge) error
 }
 
 type houseRepository struct{}
@@ -22,23 +25,23 @@ func NewHouseRepository() HouseRepository {
 	return &houseRepository{}
 }
 
-func (r *houseRepository) CreateHouse(house *models.House) error {
-	return database.DB.Create(house).Error
+func (r *houseRepository) CreateHouse(ctx context.Context, house *models.House) error {
+	return database.DB.WithContext(ctx).Create(house).Error
 }
 
 func (r *houseRepository) GetUserHouses(userID uuid.UUID) ([]models.House, error) {
 	houses := []models.House{}
-	err := database.DB.Preload("Flats").Where("user_id = ?", userID).Find(&houses).Error
+	err := database.DB.Preload("Flats.Charges.ChargeType").Where("user_id = ?", userID).Find(&houses).Error
 	return houses, err
 }
 
-func (r *houseRepository) CreateFlat(flat *models.Flat) error {
-	return database.DB.Create(flat).Error
+func (r *houseRepository) CreateFlat(ctx context.Context, flat *models.Flat) error {
+	return database.DB.WithContext(ctx).Create(flat).Error
 }
 
 func (r *houseRepository) GetHouseFlats(houseID uuid.UUID) ([]models.Flat, error) {
 	flats := []models.Flat{}
-	err := database.DB.Where("house_id = ?", houseID).Find(&flats).Error
+	err := database.DB.Preload("Charges.ChargeType").Where("house_id = ?", houseID).Find(&flats).Error
 	return flats, err
 }
 
@@ -50,6 +53,28 @@ func (r *houseRepository) GetHouseByID(id uuid.UUID) (*models.House, error) {
 
 func (r *houseRepository) GetFlatByID(id uuid.UUID) (*models.Flat, error) {
 	var flat models.Flat
-	err := database.DB.First(&flat, id).Error
+	err := database.DB.Preload("Charges.ChargeType").First(&flat, id).Error
 	return &flat, err
 }
+
+// ReplaceFlatCharges swaps the flat's charge assignments for the given ones.
+func (r *houseRepository) ReplaceFlatCharges(ctx context.Context, flatID uuid.UUID, charges []models.FlatCharge) error {
+	return database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var existing []models.FlatCharge
+		if err := tx.Where("flat_id = ?", flatID).Find(&existing).Error; err != nil {
+			return err
+		}
+		// Delete one by one so each removal is audited
+		for i := range existing {
+			if err := tx.Delete(&existing[i]).Error; err != nil {
+				return err
+			}
+		}
+		for i := range charges {
+			if err := tx.Create(&charges[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
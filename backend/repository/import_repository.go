@@ -0,0 +1,202 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"rented-backend/database"
+	"rented-backend/models"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var ErrImportRolledBack = errors.New("this import has already been rolled back")
+
+// ImportInUseError means rows added since an import depend on it, so it
+// cannot be rolled back without losing them.
+type ImportInUseError struct {
+	Dependents []string
+}
+
+func (e *ImportInUseError) Error() string {
+	return "cannot roll back, other records now depend on this import: " + strings.Join(e.Dependents, ", ")
+}
+
+// ImportPlan is everything one import file creates. Flats carry their
+// charges and payments their items.
+type ImportPlan struct {
+	Houses   []models.House
+	Flats    []models.Flat
+	Tenants  []models.Tenant
+	Payments []models.RentPayment
+}
+
+type ImportRepository interface {
+	Commit(ctx context.Context, batch *models.ImportBatch, plan *ImportPlan) error
+	GetBatch(id uuid.UUID, userID uuid.UUID) (*models.ImportBatch, error)
+	ListBatches(userID uuid.UUID) ([]models.ImportBatch, error)
+	Rollback(ctx context.Context, batch *models.ImportBatch) error
+}
+
+type importRepository struct{}
+
+func NewImportRepository() ImportRepository {
+	return &importRepository{}
+}
+
+// Commit writes the batch and every row of the plan in one transaction.
+// Rows are created one at a time so each is audited.
+func (r *importRepository) Commit(ctx context.Context, batch *models.ImportBatch, plan *ImportPlan) error {
+	return database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(batch).Error; err != nil {
+			return err
+		}
+		for i := range plan.Houses {
+			plan.Houses[i].ImportBatchID = &batch.ID
+			if err := tx.Omit("Flats").Create(&plan.Houses[i]).Error; err != nil {
+				return err
+			}
+		}
+		for i := range plan.Flats {
+			plan.Flats[i].ImportBatchID = &batch.ID
+			if err := tx.Create(&plan.Flats[i]).Error; err != nil {
+				return err
+			}
+		}
+		for i := range plan.Tenants {
+			plan.Tenants[i].ImportBatchID = &batch.ID
+			if err := tx.Omit("Flat").Create(&plan.Tenants[i]).Error; err != nil {
+				return err
+			}
+		}
+		for i := range plan.Payments {
+			plan.Payments[i].ImportBatchID = &batch.ID
+			if err := tx.Create(&plan.Payments[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *importRepository) GetBatch(id uuid.UUID, userID uuid.UUID) (*models.ImportBatch, error) {
+	var batch models.ImportBatch
+	if err := database.DB.Where("id = ? AND user_id = ?", id, userID).First(&batch).Error; err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+func (r *importRepository) ListBatches(userID uuid.UUID) ([]models.ImportBatch, error) {
+	batches := []models.ImportBatch{}
+	err := database.DB.Where("user_id = ?", userID).Order("created_at DESC").Find(&batches).Error
+	return batches, err
+}
+
+// importDependents are the rows that point at houses, flats or tenants.
+// Any not created by the batch itself block its rollback.
+var importDependents = []struct {
+	table, column, parent string
+	tagged                bool // the table has import_batch_id
+}{
+	{"flats", "house_id", "houses", true},
+	{"tenants", "house_id", "houses", true},
+	{"tenants", "flat_id", "flats", true},
+	{"rent_payments", "tenant_id", "tenants", true},
+	{"charges", "tenant_id", "tenants", false},
+	{"payment_proofs", "tenant_id", "tenants", false},
+	{"maintenance_tickets", "house_id", "houses", false},
+	{"maintenance_tickets", "flat_id", "flats", false},
+	{"maintenance_tickets", "tenant_id", "tenants", false},
+	{"expenses", "house_id", "houses", false},
+	{"expenses", "flat_id", "flats", false},
+	{"meters", "flat_id", "flats", false},
+	{"shared_bills", "house_id", "houses", false},
+	{"rent_policies", "house_id", "houses", false},
+}
+
+// Rollback deletes every row the batch created, children first, in one
+// transaction. It refuses when later records depend on them.
+func (r *importRepository) Rollback(ctx context.Context, batch *models.ImportBatch) error {
+	if batch.Status == models.ImportRolledBack {
+		return ErrImportRolledBack
+	}
+
+	return database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		inUse := &ImportInUseError{}
+		for _, d := range importDependents {
+			query := tx.Table(d.table).
+				Where(d.column+" IN (?)", tx.Table(d.parent).Select("id").Where("import_batch_id = ?", batch.ID))
+			if d.tagged {
+				query = query.Where("import_batch_id IS NULL OR import_batch_id <> ?", batch.ID)
+			}
+			var count int64
+			if err := query.Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				inUse.Dependents = append(inUse.Dependents, fmt.Sprintf("%d %s", count, strings.ReplaceAll(d.table, "_", " ")))
+			}
+		}
+		if len(inUse.Dependents) > 0 {
+			return inUse
+		}
+
+		// Deleted one by one so each removal is audited
+		var payments []models.RentPayment
+		if err := tx.Where("import_batch_id = ?", batch.ID).Find(&payments).Error; err != nil {
+			return err
+		}
+		for i := range payments {
+			if err := tx.Where("rent_payment_id = ?", payments[i].ID).Delete(&models.PaymentItem{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Delete(&payments[i]).Error; err != nil {
+				return err
+			}
+		}
+
+		var tenants []models.Tenant
+		if err := tx.Where("import_batch_id = ?", batch.ID).Find(&tenants).Error; err != nil {
+			return err
+		}
+		for i := range tenants {
+			if err := tx.Delete(&tenants[i]).Error; err != nil {
+				return err
+			}
+		}
+
+		var flats []models.Flat
+		if err := tx.Preload("Charges").Where("import_batch_id = ?", batch.ID).Find(&flats).Error; err != nil {
+			return err
+		}
+		for i := range flats {
+			for j := range flats[i].Charges {
+				if err := tx.Delete(&flats[i].Charges[j]).Error; err != nil {
+					return err
+				}
+			}
+			if err := tx.Omit("Charges").Delete(&flats[i]).Error; err != nil {
+				return err
+			}
+		}
+
+		var houses []models.House
+		if err := tx.Where("import_batch_id = ?", batch.ID).Find(&houses).Error; err != nil {
+			return err
+		}
+		for i := range houses {
+			if err := tx.Delete(&houses[i]).Error; err != nil {
+				return err
+			}
+		}
+
+		now := time.Now()
+		batch.Status = models.ImportRolledBack
+		batch.RolledBackAt = &now
+		return tx.Save(batch).Error
+	})
+}
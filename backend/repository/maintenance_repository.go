@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"rented-backend/database"
+	"rented-backend/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var ErrCostSettled = errors.New("ticket cost is already settled")
+
+type TicketFilter struct {
+	HouseID  *uuid.UUID
+	TenantID *uuid.UUID
+	Status   string
+	Priority string
+}
+
+type MaintenanceRepository interface {
+	Create(ctx context.Context, ticket *models.MaintenanceTicket) error
+	Update(ctx context.Context, ticket *models.MaintenanceTicket) error
+	GetByID(id uuid.UUID, userID uuid.UUID) (*models.MaintenanceTicket, error)
+	List(userID uuid.UUID, filter TicketFilter) ([]models.MaintenanceTicket, error)
+	AddPhoto(ctx context.Context, photo *models.TicketPhoto) error
+	AddComment(ctx context.Context, comment *models.TicketComment) error
+	SettleCost(ctx context.Context, ticket *models.MaintenanceTicket, expense *models.Expense, charge *models.Charge) error
+}
+
+type maintenanceRepository struct{}
+
+func NewMaintenanceRepository() MaintenanceRepository {
+	return &maintenanceRepository{}
+}
+
+func (r *maintenanceRepository) Create(ctx context.Context, ticket *models.MaintenanceTicket) error {
+	return database.DB.WithContext(ctx).Create(ticket).Error
+}
+
+func (r *maintenanceRepository) Update(ctx context.Context, ticket *models.MaintenanceTicket) error {
+	return database.DB.WithContext(ctx).Omit("Photos", "Comments").Save(ticket).Error
+}
+
+func (r *maintenanceRepository) GetByID(id uuid.UUID, userID uuid.UUID) (*models.MaintenanceTicket, error) {
+	var ticket models.MaintenanceTicket
+	err := database.DB.
+		Preload("Photos", func(db *gorm.DB) *gorm.DB { return db.Order("created_at") }).
+		Preload("Comments", func(db *gorm.DB) *gorm.DB { return db.Order("created_at") }).
+		Where("id = ? AND user_id = ?", id, userID).
+		First(&ticket).Error
+	if err != nil {
+		return nil, err
+	}
+	return &ticket, nil
+}
+
+func (r *maintenanceRepository) List(userID uuid.UUID, filter TicketFilter) ([]models.MaintenanceTicket, error) {
+	query := database.DB.Where("user_id = ?", userID)
+	if filter.HouseID != nil {
+		query = query.Where("house_id = ?", *filter.HouseID)
+	}
+	if filter.TenantID != nil {
+		query = query.Where("tenant_id = ?", *filter.TenantID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Priority != "" {
+		query = query.Where("priority = ?", filter.Priority)
+	}
+
+	tickets := []models.MaintenanceTicket{}
+	err := query.Order("created_at DESC").Find(&tickets).Error
+	return tickets, err
+}
+
+func (r *maintenanceRepository) AddPhoto(ctx context.Context, photo *models.TicketPhoto) error {
+	return database.DB.WithContext(ctx).Create(photo).Error
+}
+
+func (r *maintenanceRepository) AddComment(ctx context.Context, comment *models.TicketComment) error {
+	return database.DB.WithContext(ctx).Create(comment).Error
+}
+
+// SettleCost records the ticket's cost as either an expense or a tenant
+// charge, in the same transaction as the ticket update. It fails with
+// ErrCostSettled if the ticket's cost has already been settled.
+func (r *maintenanceRepository) SettleCost(ctx context.Context, ticket *models.MaintenanceTicket, expense *models.Expense, charge *models.Charge) error {
+	return database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Claim the ticket first so a concurrent settlement books nothing
+		result := tx.Model(ticket).Where("expense_id IS NULL AND charge_id IS NULL").Updates(map[string]any{
+			"cost":            ticket.Cost,
+			"cost_charged_to": ticket.CostChargedTo,
+			"expense_id":      ticket.ExpenseID,
+			"charge_id":       ticket.ChargeID,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrCostSettled
+		}
+
+		if expense != nil {
+			if err := tx.Create(expense).Error; err != nil {
+				return err
+			}
+		}
+		if charge != nil {
+			return tx.Create(charge).Error
+		}
+		return nil
+	})
+}
@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"rented-backend/database"
+	"rented-backend/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var ErrReadingNotFlagged = errors.New("only a flagged reading can be corrected")
+
+type MeterRepository interface {
+	CreateMeter(ctx context.Context, meter *models.Meter) error
+	GetFlatMeters(flatID uuid.UUID, userID uuid.UUID) ([]models.Meter, error)
+	GetMeterByID(id uuid.UUID, userID uuid.UUID) (*models.Meter, error)
+	GetLatestReading(meterID uuid.UUID) (*models.MeterReading, error)
+	GetReadings(meterID uuid.UUID) ([]models.MeterReading, error)
+	GetReading(id uuid.UUID, meterID uuid.UUID) (*models.MeterReading, error)
+	CreateReading(ctx context.Context, reading *models.MeterReading, charge *models.Charge) error
+	ReplaceFlaggedReading(ctx context.Context, reading *models.MeterReading, charge *models.Charge) error
+	GetTariff(userID uuid.UUID) (*models.ElectricityTariff, error)
+	SaveTariff(ctx context.Context, tariff *models.ElectricityTariff) error
+}
+
+type meterRepository struct{}
+
+func NewMeterRepository() MeterRepository {
+	return &meterRepository{}
+}
+
+func (r *meterRepository) CreateMeter(ctx context.Context, meter *models.Meter) error {
+	return database.DB.WithContext(ctx).Create(meter).Error
+}
+
+func (r *meterRepository) GetFlatMeters(flatID uuid.UUID, userID uuid.UUID) ([]models.Meter, error) {
+	meters := []models.Meter{}
+	err := database.DB.
+		Joins("JOIN flats ON flats.id = meters.flat_id").
+		Joins("JOIN houses ON houses.id = flats.house_id").
+		Where("meters.flat_id = ? AND houses.user_id = ?", flatID, userID).
+		Find(&meters).Error
+	return meters, err
+}
+
+func (r *meterRepository) GetMeterByID(id uuid.UUID, userID uuid.UUID) (*models.Meter, error) {
+	var meter models.Meter
+	err := database.DB.
+		Joins("JOIN flats ON flats.id = meters.flat_id").
+		Joins("JOIN houses ON houses.id = flats.house_id").
+		Where("meters.id = ? AND houses.user_id = ?", id, userID).
+		First(&meter).Error
+	if err != nil {
+		return nil, err
+	}
+	return &meter, nil
+}
+
+// GetLatestReading returns the latest reading that is not flagged, or nil
+// without error when the meter has none yet.
+func (r *meterRepository) GetLatestReading(meterID uuid.UUID) (*models.MeterReading, error) {
+	var reading models.MeterReading
+	err := database.DB.Where("meter_id = ? AND is_flagged = ?", meterID, false).Order("reading_date DESC").First(&reading).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &reading, nil
+}
+
+func (r *meterRepository) GetReadings(meterID uuid.UUID) ([]models.MeterReading, error) {
+	readings := []models.MeterReading{}
+	err := database.DB.Where("meter_id = ?", meterID).Order("reading_date DESC").Find(&readings).Error
+	return readings, err
+}
+
+func (r *meterRepository) GetReading(id uuid.UUID, meterID uuid.UUID) (*models.MeterReading, error) {
+	var reading models.MeterReading
+	err := database.DB.Where("id = ? AND meter_id = ?", id, meterID).First(&reading).Error
+	if err != nil {
+		return nil, err
+	}
+	return &reading, nil
+}
+
+// CreateReading stores the reading and, when given, the charge it bills in
+// a single transaction.
+func (r *meterRepository) CreateReading(ctx context.Context, reading *models.MeterReading, charge *models.Charge) error {
+	return database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(reading).Error; err != nil {
+			return err
+		}
+		if charge == nil {
+			return nil
+		}
+		return tx.Create(charge).Error
+	})
+}
+
+// ReplaceFlaggedReading overwrites a flagged reading with its correction
+// and stores the charge it bills, if any, in a single transaction. It fails
+// with ErrReadingNotFlagged if the reading is not flagged any more.
+func (r *meterRepository) ReplaceFlaggedReading(ctx context.Context, reading *models.MeterReading, charge *models.Charge) error {
+	return database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(reading).Where("is_flagged = ?", true).Updates(map[string]any{
+			"tenant_id":      reading.TenantID,
+			"previous_units": reading.PreviousUnits,
+			"current_units":  reading.CurrentUnits,
+			"units":          reading.Units,
+			"energy_charge":  reading.EnergyCharge,
+			"meter_rent":     reading.MeterRent,
+			"vat":            reading.VAT,
+			"amount":         reading.Amount,
+			"is_flagged":     reading.IsFlagged,
+			"flag_reason":    reading.FlagReason,
+			"reading_date":   reading.ReadingDate,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrReadingNotFlagged
+		}
+		if charge == nil {
+			return nil
+		}
+		return tx.Create(charge).Error
+	})
+}
+
+// GetTariff returns the landlord's tariff, or the default tariff if none is saved.
+func (r *meterRepository) GetTariff(userID uuid.UUID) (*models.ElectricityTariff, error) {
+	var tariff models.ElectricityTariff
+	err := database.DB.Where("user_id = ?", userID).First(&tariff).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		tariff = models.DefaultElectricityTariff()
+		tariff.UserID = userID
+		return &tariff, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &tariff, nil
+}
+
+func (r *meterRepository) SaveTariff(ctx context.Context, tariff *models.ElectricityTariff) error {
+	return database.DB.WithContext(ctx).Save(tariff).Error
+}
@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"rented-backend/database"
+	"rented-backend/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Devices, preferences and the inbox are per-login settings and a delivery
+// log, not landlord data, so they are not audited.
+
+type NotificationRepository interface {
+	SaveDevice(device *models.DeviceToken) error
+	DeleteDevice(userID uuid.UUID, token string) error
+	DeleteToken(token string) error
+	GetDevices(userID uuid.UUID) ([]models.DeviceToken, error)
+	GetPreferences(userID uuid.UUID) (map[string]bool, error)
+	SavePreferences(userID uuid.UUID, events map[string]bool) error
+	Create(notification *models.Notification) error
+	WasSent(userID uuid.UUID, dedupeKey string) (bool, error)
+	List(userID uuid.UUID, unreadOnly bool) ([]models.Notification, error)
+	UnreadCount(userID uuid.UUID) (int64, error)
+	MarkRead(id uuid.UUID, userID uuid.UUID) error
+	MarkAllRead(userID uuid.UUID) error
+}
+
+type notificationRepository struct{}
+
+func NewNotificationRepository() NotificationRepository {
+	return &notificationRepository{}
+}
+
+// SaveDevice registers the token for the user, taking it over if another
+// user registered it before.
+func (r *notificationRepository) SaveDevice(device *models.DeviceToken) error {
+	return database.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "token"}},
+		DoUpdates: clause.AssignmentColumns([]string{"user_id", "platform", "updated_at"}),
+	}).Create(device).Error
+}
+
+func (r *notificationRepository) DeleteDevice(userID uuid.UUID, token string) error {
+	return database.DB.Where("user_id = ? AND token = ?", userID, token).Delete(&models.DeviceToken{}).Error
+}
+
+// DeleteToken drops a token the push service reported as unregistered.
+func (r *notificationRepository) DeleteToken(token string) error {
+	return database.DB.Where("token = ?", token).Delete(&models.DeviceToken{}).Error
+}
+
+func (r *notificationRepository) GetDevices(userID uuid.UUID) ([]models.DeviceToken, error) {
+	devices := []models.DeviceToken{}
+	err := database.DB.Where("user_id = ?", userID).Find(&devices).Error
+	return devices, err
+}
+
+// GetPreferences returns every event with whether it is pushed; events
+// the user never changed are on.
+func (r *notificationRepository) GetPreferences(userID uuid.UUID) (map[string]bool, error) {
+	var saved []models.NotificationPreference
+	if err := database.DB.Where("user_id = ?", userID).Find(&saved).Error; err != nil {
+		return nil, err
+	}
+	prefs := map[string]bool{}
+	for _, event := range models.NotificationEvents {
+		prefs[event] = true
+	}
+	for _, p := range saved {
+		prefs[p.Event] = p.Push
+	}
+	return prefs, nil
+}
+
+func (r *notificationRepository) SavePreferences(userID uuid.UUID, events map[string]bool) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		for event, enabled := range events {
+			pref := models.NotificationPreference{ID: uuid.New(), UserID: userID, Event: event, Push: enabled}
+			err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "user_id"}, {Name: "event"}},
+				DoUpdates: clause.AssignmentColumns([]string{"push", "updated_at"}),
+			}).Create(&pref).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *notificationRepository) Create(notification *models.Notification) error {
+	return database.DB.Create(notification).Error
+}
+
+// WasSent reports whether a notification with the key is already in the
+// user's inbox.
+func (r *notificationRepository) WasSent(userID uuid.UUID, dedupeKey string) (bool, error) {
+	var count int64
+	err := database.DB.Model(&models.Notification{}).
+		Where("user_id = ? AND dedupe_key = ?", userID, dedupeKey).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// List returns the user's inbox, newest first, capped at 200 entries.
+func (r *notificationRepository) List(userID uuid.UUID, unreadOnly bool) ([]models.Notification, error) {
+	query := database.DB.Where("user_id = ?", userID)
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+	notifications := []models.Notification{}
+	err := query.Order("created_at DESC").Limit(200).Find(&notifications).Error
+	return notifications, err
+}
+
+func (r *notificationRepository) UnreadCount(userID uuid.UUID) (int64, error) {
+	var count int64
+	err := database.DB.Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Count(&count).Error
+	return count, err
+}
+
+// MarkRead returns gorm.ErrRecordNotFound when the notification is not the user's.
+func (r *notificationRepository) MarkRead(id uuid.UUID, userID uuid.UUID) error {
+	result := database.DB.Model(&models.Notification{}).
+		Where("id = ? AND user_id = ?", id, userID).
+		Where("read_at IS NULL").
+		Update("read_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		var count int64
+		if err := database.DB.Model(&models.Notification{}).Where("id = ? AND user_id = ?", id, userID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return gorm.ErrRecordNotFound
+		}
+	}
+	return nil
+}
+
+func (r *notificationRepository) MarkAllRead(userID uuid.UUID) error {
+	return database.DB.Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Update("read_at", time.Now()).Error
+}
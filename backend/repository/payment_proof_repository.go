@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"rented-backend/database"
+	"rented-backend/models"
+	"rented-backend/money"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrProofReviewed is returned when a proof was confirmed or rejected
+// while the caller was reviewing it.
+var ErrProofReviewed = errors.New("payment has already been reviewed")
+
+type ProofFilter struct {
+	Status   string
+	HouseID  *uuid.UUID
+	TenantID *uuid.UUID
+}
+
+type PaymentProofRepository interface {
+	Create(ctx context.Context, proof *models.PaymentProof) error
+	GetByID(id uuid.UUID, userID uuid.UUID) (*models.PaymentProof, error)
+	GetByTenantID(tenantID uuid.UUID) ([]models.PaymentProof, error)
+	List(userID uuid.UUID, filter ProofFilter) ([]models.PaymentProof, error)
+	TransactionUsed(userID uuid.UUID, method, transactionID string) (bool, error)
+	PendingTotal(tenantID uuid.UUID) (money.Amount, error)
+	Confirm(ctx context.Context, proof *models.PaymentProof, payment *models.RentPayment) error
+	Reject(ctx context.Context, proof *models.PaymentProof) error
+}
+
+type paymentProofRepository struct{}
+
+func NewPaymentProofRepository() PaymentProofRepository {
+	return &paymentProofRepository{}
+}
+
+func (r *paymentProofRepository) Create(ctx context.Context, proof *models.PaymentProof) error {
+	return database.DB.WithContext(ctx).Omit("Tenant").Create(proof).Error
+}
+
+func (r *paymentProofRepository) GetByID(id uuid.UUID, userID uuid.UUID) (*models.PaymentProof, error) {
+	var proof models.PaymentProof
+	err := database.DB.Preload("Tenant.Flat").Where("id = ? AND user_id = ?", id, userID).First(&proof).Error
+	if err != nil {
+		return nil, err
+	}
+	return &proof, nil
+}
+
+func (r *paymentProofRepository) GetByTenantID(tenantID uuid.UUID) ([]models.PaymentProof, error) {
+	proofs := []models.PaymentProof{}
+	err := database.DB.Where("tenant_id = ?", tenantID).Order("created_at DESC").Find(&proofs).Error
+	return proofs, err
+}
+
+// List returns the landlord's proofs, oldest first so the approval queue
+// is worked in order.
+func (r *paymentProofRepository) List(userID uuid.UUID, filter ProofFilter) ([]models.PaymentProof, error) {
+	query := database.DB.Preload("Tenant.Flat").Where("payment_proofs.user_id = ?", userID)
+	if filter.Status != "" {
+		query = query.Where("payment_proofs.status = ?", filter.Status)
+	}
+	if filter.TenantID != nil {
+		query = query.Where("payment_proofs.tenant_id = ?", *filter.TenantID)
+	}
+	if filter.HouseID != nil {
+		query = query.Where("payment_proofs.tenant_id IN (?)",
+			database.DB.Model(&models.Tenant{}).Select("id").Where("house_id = ?", *filter.HouseID))
+	}
+
+	proofs := []models.PaymentProof{}
+	err := query.Order("payment_proofs.created_at").Find(&proofs).Error
+	return proofs, err
+}
+
+// TransactionUsed reports whether a pending or confirmed proof already
+// carries the transaction ID, to catch the same screenshot sent twice.
+func (r *paymentProofRepository) TransactionUsed(userID uuid.UUID, method, transactionID string) (bool, error) {
+	var count int64
+	err := database.DB.Model(&models.PaymentProof{}).
+		Where("user_id = ? AND method = ? AND transaction_id = ? AND status <> ?", userID, method, transactionID, models.ProofRejected).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *paymentProofRepository) PendingTotal(tenantID uuid.UUID) (money.Amount, error) {
+	var total money.Amount
+	err := database.DB.Model(&models.PaymentProof{}).
+		Where("tenant_id = ? AND status = ?", tenantID, models.ProofPending).
+		Select("CAST(COALESCE(SUM(amount), 0) AS bigint)").Scan(&total).Error
+	return total, err
+}
+
+// Confirm records the payment and marks the proof confirmed in one
+// transaction. It fails with ErrProofReviewed if the proof is no longer
+// pending.
+func (r *paymentProofRepository) Confirm(ctx context.Context, proof *models.PaymentProof, payment *models.RentPayment) error {
+	return database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(payment).Error; err != nil {
+			return err
+		}
+		return review(tx, proof)
+	})
+}
+
+// Reject marks the proof rejected. It fails with ErrProofReviewed if the
+// proof is no longer pending.
+func (r *paymentProofRepository) Reject(ctx context.Context, proof *models.PaymentProof) error {
+	return review(database.DB.WithContext(ctx), proof)
+}
+
+func review(tx *gorm.DB, proof *models.PaymentProof) error {
+	result := tx.Model(proof).Where("status = ?", models.ProofPending).Updates(map[string]any{
+		"status":          proof.Status,
+		"reviewed_by":     proof.ReviewedBy,
+		"reviewed_at":     proof.ReviewedAt,
+		"reject_reason":   proof.RejectReason,
+		"rent_payment_id": proof.RentPaymentID,
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrProofReviewed
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"rented-backend/database"
+	"rented-backend/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type PolicyRepository interface {
+	GetByHouseID(houseID uuid.UUID) (*models.RentPolicy, error)
+	Save(ctx context.Context, policy *models.RentPolicy) error
+}
+
+type policyRepository struct{}
+
+func NewPolicyRepository() PolicyRepository {
+	return &policyRepository{}
+}
+
+// GetByHouseID returns the house's policy, or the default policy if none is saved.
+func (r *policyRepository) GetByHouseID(houseID uuid.UUID) (*models.RentPolicy, error) {
+	var policy models.RentPolicy
+	err := database.DB.Where("house_id = ?", houseID).First(&policy).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		policy = models.DefaultRentPolicy(houseID)
+		return &policy, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+func (r *policyRepository) Save(ctx context.Context, policy *models.RentPolicy) error {
+	return database.DB.WithContext(ctx).Save(policy).Error
+}
@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"rented-backend/database"
+	"rented-backend/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type MessageFilter struct {
+	TenantID *uuid.UUID
+	Kind     string
+	Status   string
+}
+
+type ReminderRepository interface {
+	GetSettings(userID uuid.UUID) (*models.ReminderSettings, error)
+	SaveSettings(ctx context.Context, settings *models.ReminderSettings) error
+	GetTemplates(userID uuid.UUID) ([]models.MessageTemplate, error)
+	SaveTemplate(ctx context.Context, template *models.MessageTemplate) error
+	ClaimMessage(message *models.SMSMessage) error
+	UpdateMessage(message *models.SMSMessage) error
+	ListMessages(userID uuid.UUID, filter MessageFilter) ([]models.SMSMessage, error)
+	UpdateDeliveryStatus(providerID, status string) (int64, error)
+}
+
+// ErrMessageClaimed is returned when a message with the same dedupe key
+// was already sent to the tenant, or is being sent.
+var ErrMessageClaimed = errors.New("message already sent")
+
+type reminderRepository struct{}
+
+func NewReminderRepository() ReminderRepository {
+	return &reminderRepository{}
+}
+
+// GetSettings returns the landlord's settings, or the defaults if none are saved.
+func (r *reminderRepository) GetSettings(userID uuid.UUID) (*models.ReminderSettings, error) {
+	var settings models.ReminderSettings
+	err := database.DB.Where("user_id = ?", userID).First(&settings).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		settings = models.DefaultReminderSettings(userID)
+		return &settings, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+func (r *reminderRepository) SaveSettings(ctx context.Context, settings *models.ReminderSettings) error {
+	return database.DB.WithContext(ctx).Save(settings).Error
+}
+
+// GetTemplates returns the landlord's customised templates only; callers
+// fall back to models.DefaultTemplates.
+func (r *reminderRepository) GetTemplates(userID uuid.UUID) ([]models.MessageTemplate, error) {
+	templates := []models.MessageTemplate{}
+	err := database.DB.Where("user_id = ?", userID).Find(&templates).Error
+	return templates, err
+}
+
+func (r *reminderRepository) SaveTemplate(ctx context.Context, template *models.MessageTemplate) error {
+	return database.DB.WithContext(ctx).Save(template).Error
+}
+
+// Sent messages are a delivery log, not landlord data, so they are not
+// audited.
+
+// ClaimMessage records the message as pending before it is sent. A
+// message with a dedupe key goes to the tenant once: an earlier failed
+// attempt keeps its row with the key cleared, and one that is pending,
+// sent or held back for an opt-out returns ErrMessageClaimed.
+func (r *reminderRepository) ClaimMessage(message *models.SMSMessage) error {
+	message.Status = models.SMSPending
+	if message.DedupeKey != "" {
+		err := database.DB.Model(&models.SMSMessage{}).
+			Where("tenant_id = ? AND dedupe_key = ? AND status = ?", message.TenantID, message.DedupeKey, models.SMSFailed).
+			Update("dedupe_key", "").Error
+		if err != nil {
+			return err
+		}
+	}
+	err := database.DB.Create(message).Error
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return ErrMessageClaimed
+	}
+	return err
+}
+
+// UpdateMessage saves the outcome of a claimed send.
+func (r *reminderRepository) UpdateMessage(message *models.SMSMessage) error {
+	return database.DB.Model(message).Updates(map[string]any{
+		"status":      message.Status,
+		"provider_id": message.ProviderID,
+		"error":       message.Error,
+	}).Error
+}
+
+func (r *reminderRepository) ListMessages(userID uuid.UUID, filter MessageFilter) ([]models.SMSMessage, error) {
+	query := database.DB.Where("user_id = ?", userID)
+	if filter.TenantID != nil {
+		query = query.Where("tenant_id = ?", *filter.TenantID)
+	}
+	if filter.Kind != "" {
+		query = query.Where("kind = ?", filter.Kind)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	messages := []models.SMSMessage{}
+	err := query.Order("created_at DESC").Limit(500).Find(&messages).Error
+	return messages, err
+}
+
+func (r *reminderRepository) UpdateDeliveryStatus(providerID, status string) (int64, error) {
+	result := database.DB.Model(&models.SMSMessage{}).Where("provider_id = ?", providerID).Update("status", status)
+	return result.RowsAffected, result.Error
+}
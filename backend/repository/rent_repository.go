@@ -1,37 +1,120 @@
 package repository
 
 import (
+	"context"
+	"database/sql"
+	"rented-backend/billing"
 	"rented-backend/database"
 	"rented-backend/models"
-
+	"rented-backend/money"
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type TenantDue struct {
-	TenantName string    `json:"tenant_name"`
-	TenantID   uuid.UUID `json:"tenant_id"`
-	FlatNo     string    `json:"flat_no"`
-	DueAmount  float64   `json:"due_amount"`
-	Month      string    `json:"month,omitempty"`
+	TenantName string       `json:"tenant_name"`
+	TenantID   uuid.UUID    `json:"tenant_id"`
+	FlatNo     string       `json:"flat_no"`
+	DueAmount  money.Amount `json:"due_amount"`
+	// Items breaks the due down by charge type code
+	Items map[string]money.Amount `json:"items,omitempty"`
+}
+
+// DashboardFilter limits dashboard figures to whole months from From to
+// To (both the first day of a month) and optionally to one house.
+type DashboardFilter struct {
+	From    time.Time
+	To      time.Time
+	HouseID *uuid.UUID
+}
+
+// MonthlyFigures are one month's billing, collection and occupancy.
+type MonthlyFigures struct {
+	Period        billing.Period `json:"period"`
+	Billed        money.Amount   `json:"billed"`    // expected income as the due service bills it
+	Collected     money.Amount   `json:"collected"` // payments recorded for the month, advances excluded
+	Expenses      money.Amount   `json:"expenses"`
+	NOI           money.Amount   `json:"noi"` // collected less expenses
+	TotalFlats    int            `json:"total_flats"`
+	OccupiedFlats int            `json:"occupied_flats"`
+	VacancyRate   float64        `json:"vacancy_rate"`
 }
 
 type DashboardStats struct {
-	TotalRevenue   float64     `json:"total_revenue"`
-	TotalDue       float64     `json:"total_due"`
-	CollectedCount int         `json:"collected_count"`
-	TotalFlats     int         `json:"total_flats"`
-	OccupiedFlats  int         `json:"occupied_flats"`
-	TopDues        []TenantDue `json:"top_dues"`
+	From           time.Time    `json:"from"`
+	To             time.Time    `json:"to"`
+	HouseID        *uuid.UUID   `json:"house_id,omitempty"`
+	TotalRevenue   money.Amount `json:"total_revenue"`
+	TotalDue       money.Amount `json:"total_due"`
+	CollectedCount int          `json:"collected_count"`
+	TotalFlats     int          `json:"total_flats"`
+	OccupiedFlats  int          `json:"occupied_flats"`
+	// Expected vs actual income over the period
+	ExpectedIncome money.Amount `json:"expected_income"`
+	ActualIncome   money.Amount `json:"actual_income"`
+	CollectionRate float64      `json:"collection_rate"` // collected ÷ billed
+	Expenses       money.Amount `json:"expenses"`
+	NOI            money.Amount `json:"noi"`          // net operating income: actual income less expenses
+	VacancyRate    float64      `json:"vacancy_rate"` // average over the period
+	// Overdue is past the grace period; not-yet-late is due but still within it
+	OverdueCount     int              `json:"overdue_count"`
+	OverdueAmount    money.Amount     `json:"overdue_amount"`
+	NotYetLateCount  int              `json:"not_yet_late_count"`
+	NotYetLateAmount money.Amount     `json:"not_yet_late_amount"`
+	TopDues          []TenantDue      `json:"top_dues"`
+	Months           []MonthlyFigures `json:"months"` // the period, month by month
+	Trend            []MonthlyFigures `json:"trend"`  // the 12 months ending with the period
+}
+
+// SetMonths sets the month-by-month figures and the period totals drawn
+// from them.
+func (s *DashboardStats) SetMonths(months []MonthlyFigures) {
+	s.Months = months
+	s.ExpectedIncome, s.ActualIncome, s.Expenses = 0, 0, 0
+
+	var vacancy float64
+	for _, m := range months {
+		s.ExpectedIncome += m.Billed
+		s.ActualIncome += m.Collected
+		s.Expenses += m.Expenses
+		vacancy += m.VacancyRate
+	}
+	s.TotalRevenue = s.ActualIncome
+	s.NOI = s.ActualIncome - s.Expenses
+	s.CollectionRate = 0
+	if s.ExpectedIncome > 0 {
+		s.CollectionRate = float64(s.ActualIncome) / float64(s.ExpectedIncome)
+	}
+	s.VacancyRate = 0
+	if len(months) > 0 {
+		s.VacancyRate = vacancy / float64(len(months))
+	}
+}
+
+// PaymentExportRow is a payment with the tenant, house and flat it was made for.
+type PaymentExportRow struct {
+	PaymentDate   time.Time
+	TenantName    string
+	HouseName     string
+	FlatNumber    string
+	Period        billing.Period
+	TotalPaid     money.Amount
+	IsAdvance     bool
+	Method        string
+	TransactionID string
 }
 
 type RentRepository interface {
-	Create(rent *models.RentPayment) error
+	Create(ctx context.Context, rent *models.RentPayment) error
 	GetByTenantID(tenantID uuid.UUID) ([]models.RentPayment, error)
+	GetByUserID(userID uuid.UUID) ([]models.RentPayment, error)
 	GetByID(id uuid.UUID) (*models.RentPayment, error)
-	Delete(id uuid.UUID) error
-	GetDashboardStats(userID uuid.UUID) (*DashboardStats, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+	GetDashboardStats(userID uuid.UUID, filter DashboardFilter) (*DashboardStats, error)
+	GetMonthlyFigures(userID uuid.UUID, filter DashboardFilter) ([]MonthlyFigures, error)
+	StreamPayments(userID uuid.UUID, from, to time.Time, fn func(PaymentExportRow) error) error
 }
 
 type rentRepository struct{}
@@ -40,161 +123,225 @@ func NewRentRepository() RentRepository {
 	return &rentRepository{}
 }
 
-func (r *rentRepository) Create(rent *models.RentPayment) error {
+func (r *rentRepository) Create(ctx context.Context, rent *models.RentPayment) error {
 	rent.ID = uuid.New()
 	if rent.PaymentDate.IsZero() {
 		rent.PaymentDate = time.Now()
 	}
 	if !rent.IsAdvance {
-		rent.TotalPaid = rent.BasicRent + rent.GasBill + rent.ElectricityBill + rent.UtilityBill + rent.WaterCharges
+		rent.TotalPaid = 0
+		for i := range rent.Items {
+			rent.Items[i].ID = uuid.New()
+			rent.TotalPaid += rent.Items[i].Amount
+		}
 	}
-	return database.DB.Create(rent).Error
+	return database.DB.WithContext(ctx).Create(rent).Error
 }
 
 func (r *rentRepository) GetByTenantID(tenantID uuid.UUID) ([]models.RentPayment, error) {
 	var rents []models.RentPayment
-	err := database.DB.Find(&rents, "tenant_id = ?", tenantID).Error
+	err := database.DB.Preload("Items").Find(&rents, "tenant_id = ?", tenantID).Error
+	return rents, err
+}
+
+// GetByUserID returns the payments of every tenant of the landlord.
+func (r *rentRepository) GetByUserID(userID uuid.UUID) ([]models.RentPayment, error) {
+	var rents []models.RentPayment
+	err := database.DB.Preload("Items").Where("tenant_id IN (SELECT id FROM tenants WHERE user_id = ?)", userID).Find(&rents).Error
 	return rents, err
 }
 
 func (r *rentRepository) GetByID(id uuid.UUID) (*models.RentPayment, error) {
 	var rent models.RentPayment
-	err := database.DB.First(&rent, "id = ?", id).Error
+	err := database.DB.Preload("Items").First(&rent, "id = ?", id).Error
 	if err != nil {
 		return nil, err
 	}
 	return &rent, nil
 }
 
-func (r *rentRepository) Delete(id uuid.UUID) error {
-	return database.DB.Delete(&models.RentPayment{}, "id = ?", id).Error
+func (r *rentRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	rent, err := r.GetByID(id)
+	if err != nil {
+		return err
+	}
+	return database.DB.WithContext(ctx).Delete(rent).Error
 }
 
-func (r *rentRepository) GetDashboardStats(userID uuid.UUID) (*DashboardStats, error) {
-	stats := &DashboardStats{}
+// StreamPayments calls fn for each of the landlord's payments made from
+// from up to (not including) to, oldest first, reading one row at a time.
+// It stops at the first error fn returns.
+func (r *rentRepository) StreamPayments(userID uuid.UUID, from, to time.Time, fn func(PaymentExportRow) error) error {
+	rows, err := database.DB.Table("rent_payments").
+		Select("rent_payments.payment_date, tenants.name AS tenant_name, houses.name AS house_name, flats.number AS flat_number, "+
+			"rent_payments.period, rent_payments.total_paid, rent_payments.is_advance, rent_payments.method, rent_payments.transaction_id").
+		Joins("JOIN tenants ON tenants.id = rent_payments.tenant_id").
+		Joins("LEFT JOIN houses ON houses.id = tenants.house_id").
+		Joins("LEFT JOIN flats ON flats.id = tenants.flat_id").
+		Where("tenants.user_id = ?", userID).
+		Where("rent_payments.payment_date >= ? AND rent_payments.payment_date < ?", from, to).
+		Order("rent_payments.payment_date, rent_payments.created_at").
+		Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
 
-	// 1. Total Revenue (Current Month)
-	now := time.Now()
-	currentMonth := now.Format("January")
-	currentYear := now.Year()
+	for rows.Next() {
+		var row PaymentExportRow
+		if err := database.DB.ScanRows(rows, &row); err != nil {
+			return err
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
 
-	// Join with Tenants and Houses to filter by UserID
-	// Rents -> Tenants -> Houses -> User
-	// Revenue Query
-	database.DB.Table("rent_payments").
-		Joins("JOIN tenants ON tenants.id = rent_payments.tenant_id").
-		Where("tenants.user_id = ? AND rent_payments.month = ? AND rent_payments.year = ?", userID, currentMonth, currentYear).
-		Select("COALESCE(SUM(total_paid), 0)").
-		Scan(&stats.TotalRevenue)
+func (r *rentRepository) GetDashboardStats(userID uuid.UUID, filter DashboardFilter) (*DashboardStats, error) {
+	stats := &DashboardStats{From: filter.From, To: filter.To, HouseID: filter.HouseID}
+
+	// 1. Billed, collected and occupancy, month by month, are set by the
+	// due service through SetMonths
 
-	// Collected Count (Current Month)
+	// 2. Payments recorded for the period
 	var count int64
-	database.DB.Table("rent_payments").
-		Joins("JOIN tenants ON tenants.id = rent_payments.tenant_id").
-		Where("tenants.user_id = ? AND rent_payments.month = ? AND rent_payments.year = ?", userID, currentMonth, currentYear).
-		Count(&count)
+	err := scopeTenants(database.DB.Table("rent_payments").
+		Joins("JOIN tenants ON tenants.id = rent_payments.tenant_id"), userID, filter.HouseID).
+		Where("rent_payments.is_advance = ?", false).
+		Where("rent_payments.period BETWEEN ? AND ?", filter.From, filter.To).
+		Count(&count).Error
+	if err != nil {
+		return nil, err
+	}
 	stats.CollectedCount = int(count)
 
-	// 2. Occupancy Rates
-	// Total Flats
+	// 3. Current occupancy
 	var totalFlats int64
-	database.DB.Table("flats").
+	flats := database.DB.Table("flats").
 		Joins("JOIN houses ON houses.id = flats.house_id").
-		Where("houses.user_id = ?", userID).
-		Count(&totalFlats)
+		Where("houses.user_id = ?", userID)
+	if filter.HouseID != nil {
+		flats = flats.Where("houses.id = ?", *filter.HouseID)
+	}
+	if err := flats.Count(&totalFlats).Error; err != nil {
+		return nil, err
+	}
 	stats.TotalFlats = int(totalFlats)
 
-	// Occupied Flats (Active Tenants)
 	var occupiedFlats int64
-	database.DB.Table("tenants").
-		Where("user_id = ? AND is_active = ?", userID, true).
-		Count(&occupiedFlats)
+	err = scopeTenants(database.DB.Table("tenants"), userID, filter.HouseID).
+		Where("tenants.is_active = ?", true).
+		Distinct("tenants.flat_id").
+		Count(&occupiedFlats).Error
+	if err != nil {
+		return nil, err
+	}
 	stats.OccupiedFlats = int(occupiedFlats)
 
-	// 3. Total Due & Top Dues logic
-	// This is complex to calculate in SQL efficiently if we need "Expected - Paid".
-	// Depending on scale, iterating active tenants is safest for accurate business logic.
+	// 4. Total Due & Top Dues are filled in by the due service, which
+	// walks each tenant's months with the house's rent policy.
+
+	return stats, nil
+}
 
-	var tenants []models.Tenant
-	if err := database.DB.Preload("Flat").Where("user_id = ? AND is_active = ?", userID, true).Find(&tenants).Error; err != nil {
+// monthlyFiguresQuery computes MonthlyFigures for a series of months in one
+// pass. A flat is occupied in a month if a tenant had moved in by the end of
+// it and had not left before it started. Billed is left to the due service,
+// which prorates the months a tenant moves in or out.
+const monthlyFiguresQuery = `
+WITH months AS (
+	SELECT generate_series(CAST(@from AS date), CAST(@to AS date), interval '1 month')::date AS start
+),
+scope_flats AS (
+	SELECT flats.id FROM flats
+	JOIN houses ON houses.id = flats.house_id
+	WHERE houses.user_id = @user AND (CAST(@house AS uuid) IS NULL OR houses.id = CAST(@house AS uuid))
+),
+scope_tenants AS (
+	SELECT tenants.* FROM tenants
+	WHERE tenants.user_id = @user AND (CAST(@house AS uuid) IS NULL OR tenants.house_id = CAST(@house AS uuid))
+),
+occupancy AS (
+	SELECT months.start, t.id AS tenant_id, t.flat_id
+	FROM months
+	JOIN scope_tenants t
+		ON t.join_date < months.start + interval '1 month'
+		AND (t.leave_date >= months.start OR (t.leave_date IS NULL AND t.is_active))
+),
+collected AS (
+	SELECT months.start, SUM(p.total_paid) AS amount
+	FROM months
+	JOIN rent_payments p ON p.period = months.start
+	JOIN scope_tenants t ON t.id = p.tenant_id
+	WHERE NOT p.is_advance
+	GROUP BY months.start
+),
+spent AS (
+	SELECT months.start, SUM(e.amount) AS amount
+	FROM months
+	JOIN expenses e ON e.date >= months.start AND e.date < months.start + interval '1 month'
+	WHERE e.user_id = @user AND (CAST(@house AS uuid) IS NULL OR e.house_id = CAST(@house AS uuid))
+	GROUP BY months.start
+),
+occupied AS (
+	SELECT start, COUNT(DISTINCT flat_id) AS flats FROM occupancy GROUP BY start
+)
+SELECT
+	months.start,
+	CAST(COALESCE(collected.amount, 0) AS bigint) AS collected,
+	CAST(COALESCE(spent.amount, 0) AS bigint) AS expenses,
+	(SELECT COUNT(*) FROM scope_flats) AS total_flats,
+	COALESCE(occupied.flats, 0) AS occupied_flats
+FROM months
+LEFT JOIN collected ON collected.start = months.start
+LEFT JOIN spent ON spent.start = months.start
+LEFT JOIN occupied ON occupied.start = months.start
+ORDER BY months.start`
+
+func (r *rentRepository) GetMonthlyFigures(userID uuid.UUID, filter DashboardFilter) ([]MonthlyFigures, error) {
+	var rows []struct {
+		Start         time.Time
+		Collected     money.Amount
+		Expenses      money.Amount
+		TotalFlats    int
+		OccupiedFlats int
+	}
+	err := database.DB.Raw(monthlyFiguresQuery,
+		sql.Named("from", filter.From),
+		sql.Named("to", filter.To),
+		sql.Named("user", userID),
+		sql.Named("house", filter.HouseID),
+	).Scan(&rows).Error
+	if err != nil {
 		return nil, err
 	}
 
-	totalDue := 0.0
-	var duesList []TenantDue
-
-	for _, t := range tenants {
-		// Calculate due for this tenant
-		// Logic similar to frontend: JoinDate -> Now
-		joinDate := t.JoinDate
-		iterDate := time.Date(joinDate.Year(), joinDate.Month(), 1, 0, 0, 0, 0, time.UTC)
-		targetDate := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
-
-		tenantDue := 0.0
-
-		// Get payments for this tenant
-		var rents []models.RentPayment
-		database.DB.Where("tenant_id = ?", t.ID).Find(&rents) // Optimization: Fetch all rents for user once? For now this is valid.
-
-		flatTotal := t.Flat.BasicRent + t.Flat.GasBill + t.Flat.UtilityBill + t.Flat.WaterCharges
-
-		for !iterDate.After(targetDate) {
-			mStr := iterDate.Format("January")
-			yInt := iterDate.Year()
-
-			// Find payment for this month/year
-			// Optimization: Filter in memory from fetched rents
-			var paidAmount float64
-			var hasPayment bool
-			var recordedElec float64
-
-			for _, r := range rents {
-				if r.Month == mStr && r.Year == yInt {
-					paidAmount += r.TotalPaid
-					if !hasPayment {
-						recordedElec = r.ElectricityBill // Take first record's elec
-					}
-					hasPayment = true
-				}
-			}
-
-			if !hasPayment {
-				tenantDue += flatTotal
-			} else {
-				expected := flatTotal + recordedElec
-				if paidAmount < expected-1 {
-					tenantDue += (expected - paidAmount)
-				}
-			}
-
-			iterDate = iterDate.AddDate(0, 1, 0)
+	figures := make([]MonthlyFigures, 0, len(rows))
+	for _, row := range rows {
+		f := MonthlyFigures{
+			Period:        billing.Of(row.Start),
+			Collected:     row.Collected,
+			Expenses:      row.Expenses,
+			NOI:           row.Collected - row.Expenses,
+			TotalFlats:    row.TotalFlats,
+			OccupiedFlats: row.OccupiedFlats,
 		}
-
-		if tenantDue > 0 {
-			totalDue += tenantDue
-			duesList = append(duesList, TenantDue{
-				TenantName: t.Name,
-				TenantID:   t.ID,
-				FlatNo:     t.Flat.Number,
-				DueAmount:  tenantDue,
-			})
+		if f.TotalFlats > 0 {
+			f.VacancyRate = float64(f.TotalFlats-f.OccupiedFlats) / float64(f.TotalFlats)
 		}
+		figures = append(figures, f)
 	}
+	return figures, nil
+}
 
-	stats.TotalDue = totalDue
-	// Sort dues descending
-	// Note: basic bubblesort or slice sort for limited items
-	// Implementing simple sort here isn't efficient for large lists but fine for "Top Dues"
-	// Let's assume frontend or a helper function sorts, or we do it here.
-	// For MVP, just return the list, maybe slicing top 5 is better done after sort.
-	// We'll leave list unsorted or sort simply if needed.
-
-	// Truncate to top 5
-	if len(duesList) > 5 {
-		stats.TopDues = duesList[:5] // Crude truncation
-	} else {
-		stats.TopDues = duesList
+// scopeTenants restricts a query joined with tenants to the landlord and,
+// if set, one house.
+func scopeTenants(db *gorm.DB, userID uuid.UUID, houseID *uuid.UUID) *gorm.DB {
+	db = db.Where("tenants.user_id = ?", userID)
+	if houseID != nil {
+		db = db.Where("tenants.house_id = ?", *houseID)
 	}
-
-	return stats, nil
+	return db
 }
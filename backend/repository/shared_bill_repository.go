@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+	"rented-backend/database"
+	"rented-backend/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type SharedBillRepository interface {
+	Create(ctx context.Context, bill *models.SharedBill, charges []models.Charge) error
+	GetByHouseID(houseID uuid.UUID) ([]models.SharedBill, error)
+	GetByID(id uuid.UUID, userID uuid.UUID) (*models.SharedBill, error)
+}
+
+type sharedBillRepository struct{}
+
+func NewSharedBillRepository() SharedBillRepository {
+	return &sharedBillRepository{}
+}
+
+// Create stores the bill, its shares and the charge posted for each share
+// in a single transaction.
+func (r *sharedBillRepository) Create(ctx context.Context, bill *models.SharedBill, charges []models.Charge) error {
+	return database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(bill).Error; err != nil {
+			return err
+		}
+		for i := range charges {
+			if err := tx.Create(&charges[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *sharedBillRepository) GetByHouseID(houseID uuid.UUID) ([]models.SharedBill, error) {
+	bills := []models.SharedBill{}
+	err := database.DB.Preload("Shares").Where("house_id = ?", houseID).Order("period DESC, created_at DESC").Find(&bills).Error
+	return bills, err
+}
+
+func (r *sharedBillRepository) GetByID(id uuid.UUID, userID uuid.UUID) (*models.SharedBill, error) {
+	var bill models.SharedBill
+	err := database.DB.Preload("Shares").
+		Joins("JOIN houses ON houses.id = shared_bills.house_id").
+		Where("shared_bills.id = ? AND houses.user_id = ?", id, userID).
+		First(&bill).Error
+	if err != nil {
+		return nil, err
+	}
+	return &bill, nil
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"errors"
+	"rented-backend/database"
+	"rented-backend/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var ErrNoAttemptsLeft = errors.New("no attempts left for this code")
+
+type TenantAuthRepository interface {
+	CreateCode(code *models.TenantLoginCode) error
+	GetLatestCode(phone, kind string) (*models.TenantLoginCode, error)
+	GetCodeByHash(kind, hash string) (*models.TenantLoginCode, error)
+	UseAttempt(id uuid.UUID, max int) error
+	MarkUsed(id uuid.UUID, at time.Time) error
+}
+
+type tenantAuthRepository struct{}
+
+func NewTenantAuthRepository() TenantAuthRepository {
+	return &tenantAuthRepository{}
+}
+
+// Login codes are not audited; they hold no landlord data.
+
+func (r *tenantAuthRepository) CreateCode(code *models.TenantLoginCode) error {
+	return database.DB.Create(code).Error
+}
+
+func (r *tenantAuthRepository) GetLatestCode(phone, kind string) (*models.TenantLoginCode, error) {
+	var code models.TenantLoginCode
+	err := database.DB.Where("phone = ? AND kind = ?", phone, kind).Order("created_at DESC").First(&code).Error
+	if err != nil {
+		return nil, err
+	}
+	return &code, nil
+}
+
+func (r *tenantAuthRepository) GetCodeByHash(kind, hash string) (*models.TenantLoginCode, error) {
+	var code models.TenantLoginCode
+	err := database.DB.Where("kind = ? AND secret_hash = ?", kind, hash).First(&code).Error
+	if err != nil {
+		return nil, err
+	}
+	return &code, nil
+}
+
+// UseAttempt counts one verification attempt against a code. The check and
+// the increment are one statement, so concurrent guesses cannot get past
+// max; once max attempts have been made it fails with ErrNoAttemptsLeft.
+func (r *tenantAuthRepository) UseAttempt(id uuid.UUID, max int) error {
+	var attempts []int
+	err := database.DB.Raw("UPDATE tenant_login_codes SET attempts = attempts + 1 WHERE id = ? AND attempts < ? RETURNING attempts", id, max).
+		Scan(&attempts).Error
+	if err != nil {
+		return err
+	}
+	if len(attempts) == 0 {
+		return ErrNoAttemptsLeft
+	}
+	return nil
+}
+
+// MarkUsed consumes a code. It fails if the code was already used, so a
+// code cannot be redeemed twice by concurrent requests.
+func (r *tenantAuthRepository) MarkUsed(id uuid.UUID, at time.Time) error {
+	result := database.DB.Model(&models.TenantLoginCode{}).Where("id = ? AND used_at IS NULL", id).
+		UpdateColumn("used_at", at)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
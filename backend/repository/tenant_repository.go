@@ -1,19 +1,28 @@
 package repository
 
 import (
+	"context"
+	"rented-backend/billing"
 	"rented-backend/database"
 	"rented-backend/models"
+	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type TenantRepository interface {
-	Create(tenant *models.Tenant) error
+	Create(ctx context.Context, tenant *models.Tenant) error
+	CreateWithAdvance(ctx context.Context, tenant *models.Tenant, advance *models.RentPayment) error
 	GetAll(userID uuid.UUID) ([]models.Tenant, error)
 	GetByID(id uuid.UUID, userID uuid.UUID) (*models.Tenant, error)
-	Update(tenant *models.Tenant) error
-	UpdateStatus(id uuid.UUID, userID uuid.UUID, isActive bool) error
-	Delete(id uuid.UUID, userID uuid.UUID) error
+	GetOccupantsByFlatID(flatID uuid.UUID, period billing.Period) ([]models.Tenant, error)
+	GetOccupantsByHouseID(houseID uuid.UUID, period billing.Period) ([]models.Tenant, error)
+	GetActiveByPhone(phone string) ([]models.Tenant, error)
+	Update(ctx context.Context, tenant *models.Tenant) error
+	UpdateNotifications(ctx context.Context, tenant *models.Tenant) error
+	UpdateStatus(ctx context.Context, id uuid.UUID, userID uuid.UUID, isActive bool, leaveDate *time.Time) error
+	Delete(ctx context.Context, id uuid.UUID, userID uuid.UUID) error
 }
 
 type tenantRepository struct{}
@@ -22,33 +31,115 @@ func NewTenantRepository() TenantRepository {
 	return &tenantRepository{}
 }
 
-func (r *tenantRepository) Create(tenant *models.Tenant) error {
-	return database.DB.Create(tenant).Error
+func (r *tenantRepository) Create(ctx context.Context, tenant *models.Tenant) error {
+	return database.DB.WithContext(ctx).Create(tenant).Error
+}
+
+// CreateWithAdvance adds the tenant together with the advance they paid on
+// moving in, so neither is saved without the other.
+func (r *tenantRepository) CreateWithAdvance(ctx context.Context, tenant *models.Tenant, advance *models.RentPayment) error {
+	return database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(tenant).Error; err != nil {
+			return err
+		}
+		advance.ID = uuid.New()
+		advance.TenantID = tenant.ID
+		advance.IsAdvance = true
+		return tx.Create(advance).Error
+	})
 }
 
 func (r *tenantRepository) GetAll(userID uuid.UUID) ([]models.Tenant, error) {
 	var tenants []models.Tenant
-	err := database.DB.Preload("Flat").Where("user_id = ?", userID).Find(&tenants).Error
+	err := database.DB.Preload("Flat.Charges.ChargeType").Where("user_id = ?", userID).Find(&tenants).Error
 	return tenants, err
 }
 
 func (r *tenantRepository) GetByID(id uuid.UUID, userID uuid.UUID) (*models.Tenant, error) {
 	var tenant models.Tenant
-	err := database.DB.Preload("Flat").Where("id = ? AND user_id = ?", id, userID).First(&tenant).Error
+	err := database.DB.Preload("Flat.Charges.ChargeType").Where("id = ? AND user_id = ?", id, userID).First(&tenant).Error
 	if err != nil {
 		return nil, err
 	}
 	return &tenant, nil
 }
 
-func (r *tenantRepository) Update(tenant *models.Tenant) error {
-	return database.DB.Save(tenant).Error
+// GetOccupantsByFlatID returns the tenants who lived in the flat during the
+// period, in the order they moved in.
+func (r *tenantRepository) GetOccupantsByFlatID(flatID uuid.UUID, period billing.Period) ([]models.Tenant, error) {
+	var tenants []models.Tenant
+	err := database.DB.
+		Where("flat_id = ? AND join_date < ? AND (leave_date >= ? OR (leave_date IS NULL AND is_active = ?))",
+			flatID, period.End(), period.Start(), true).
+		Order("join_date").
+		Find(&tenants).Error
+	return tenants, err
 }
 
-func (r *tenantRepository) UpdateStatus(id uuid.UUID, userID uuid.UUID, isActive bool) error {
-	return database.DB.Model(&models.Tenant{}).Where("id = ? AND user_id = ?", id, userID).Update("is_active", isActive).Error
+// GetOccupantsByHouseID returns the tenants who lived in the house during
+// the period: moved in before it ended and not gone before it started.
+func (r *tenantRepository) GetOccupantsByHouseID(houseID uuid.UUID, period billing.Period) ([]models.Tenant, error) {
+	var tenants []models.Tenant
+	err := database.DB.Preload("Flat.Charges.ChargeType").
+		Where("house_id = ? AND join_date < ? AND (leave_date >= ? OR (leave_date IS NULL AND is_active = ?))",
+			houseID, period.End(), period.Start(), true).
+		Order("join_date").
+		Find(&tenants).Error
+	return tenants, err
 }
 
-func (r *tenantRepository) Delete(id uuid.UUID, userID uuid.UUID) error {
-	return database.DB.Where("id = ? AND user_id = ?", id, userID).Delete(&models.Tenant{}).Error
+// GetActiveByPhone returns the active tenancies, across landlords, whose
+// phone number matches. Stored numbers are compared in normalised form.
+func (r *tenantRepository) GetActiveByPhone(phone string) ([]models.Tenant, error) {
+	phone = models.NormalizePhone(phone)
+	if len(phone) < 10 {
+		return nil, nil
+	}
+
+	var candidates []models.Tenant
+	err := database.DB.Preload("Flat").
+		Where("is_active = ? AND regexp_replace(phone, '[^0-9]', '', 'g') LIKE ?", true, "%"+phone[len(phone)-10:]).
+		Find(&candidates).Error
+	if err != nil {
+		return nil, err
+	}
+
+	tenants := []models.Tenant{}
+	for _, t := range candidates {
+		if models.NormalizePhone(t.Phone) == phone {
+			tenants = append(tenants, t)
+		}
+	}
+	return tenants, nil
+}
+
+func (r *tenantRepository) Update(ctx context.Context, tenant *models.Tenant) error {
+	return database.DB.WithContext(ctx).Save(tenant).Error
+}
+
+// UpdateNotifications saves the tenant's SMS opt-out and language only.
+func (r *tenantRepository) UpdateNotifications(ctx context.Context, tenant *models.Tenant) error {
+	return database.DB.WithContext(ctx).Model(tenant).Select("sms_opt_out", "language").Updates(tenant).Error
+}
+
+// UpdateStatus activates or deactivates a tenant. The leave date is stored
+// alongside and should be nil when reactivating.
+func (r *tenantRepository) UpdateStatus(ctx context.Context, id uuid.UUID, userID uuid.UUID, isActive bool, leaveDate *time.Time) error {
+	// Load first so the update is addressed by primary key and can be audited
+	tenant, err := r.GetByID(id, userID)
+	if err != nil {
+		return err
+	}
+	return database.DB.WithContext(ctx).Model(tenant).Updates(map[string]any{
+		"is_active":  isActive,
+		"leave_date": leaveDate,
+	}).Error
+}
+
+func (r *tenantRepository) Delete(ctx context.Context, id uuid.UUID, userID uuid.UUID) error {
+	var tenant models.Tenant
+	if err := database.DB.Where("id = ? AND user_id = ?", id, userID).First(&tenant).Error; err != nil {
+		return err
+	}
+	return database.DB.WithContext(ctx).Delete(&tenant).Error
 }
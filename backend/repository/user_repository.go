@@ -5,6 +5,7 @@ import (
 	"rented-backend/models"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type UserRepository interface {
@@ -12,6 +13,12 @@ type UserRepository interface {
 	GetByEmail(email string) (*models.User, error)
 	GetByID(id uuid.UUID) (*models.User, error)
 	GetByGoogleID(googleID string) (*models.User, error)
+	CreateCaretaker(caretaker *models.User) error
+	GetCaretakers(ownerID uuid.UUID) ([]models.User, error)
+	GetCaretaker(id uuid.UUID, ownerID uuid.UUID) (*models.User, error)
+	DeleteCaretaker(id uuid.UUID, ownerID uuid.UUID) error
+	ListIDs() ([]uuid.UUID, error)
+	UpdateSettings(user *models.User) error
 }
 
 type userRepository struct{}
@@ -20,9 +27,21 @@ func NewUserRepository() UserRepository {
 	return &userRepository{}
 }
 
+// Create stores the user together with the default charge catalogue.
 func (r *userRepository) Create(user *models.User) error {
 	user.ID = uuid.New()
-	return database.DB.Create(user).Error
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		chargeTypes := models.DefaultChargeTypes()
+		for i := range chargeTypes {
+			chargeTypes[i].ID = uuid.New()
+			chargeTypes[i].UserID = user.ID
+			chargeTypes[i].IsActive = true
+		}
+		return tx.Create(&chargeTypes).Error
+	})
 }
 
 func (r *userRepository) GetByEmail(email string) (*models.User, error) {
@@ -51,3 +70,46 @@ func (r *userRepository) GetByGoogleID(googleID string) (*models.User, error) {
 	}
 	return &user, nil
 }
+
+// CreateCaretaker stores a caretaker. They share their owner's charge
+// catalogue, so none is created.
+func (r *userRepository) CreateCaretaker(caretaker *models.User) error {
+	caretaker.ID = uuid.New()
+	return database.DB.Create(caretaker).Error
+}
+
+func (r *userRepository) GetCaretakers(ownerID uuid.UUID) ([]models.User, error) {
+	var caretakers []models.User
+	err := database.DB.Where("owner_id = ?", ownerID).Order("name").Find(&caretakers).Error
+	return caretakers, err
+}
+
+func (r *userRepository) GetCaretaker(id uuid.UUID, ownerID uuid.UUID) (*models.User, error) {
+	var caretaker models.User
+	err := database.DB.Where("id = ? AND owner_id = ?", id, ownerID).First(&caretaker).Error
+	if err != nil {
+		return nil, err
+	}
+	return &caretaker, nil
+}
+
+func (r *userRepository) DeleteCaretaker(id uuid.UUID, ownerID uuid.UUID) error {
+	caretaker, err := r.GetCaretaker(id, ownerID)
+	if err != nil {
+		return err
+	}
+	return database.DB.Delete(caretaker).Error
+}
+
+// ListIDs returns the accounts, leaving out caretakers, who work on their
+// owner's.
+func (r *userRepository) ListIDs() ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := database.DB.Model(&models.User{}).Where("owner_id IS NULL").Pluck("id", &ids).Error
+	return ids, err
+}
+
+// UpdateSettings saves the account's time zone, currency and locale.
+func (r *userRepository) UpdateSettings(user *models.User) error {
+	return database.DB.Model(user).Select("timezone", "currency", "locale", "updated_at").Updates(user).Error
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"rented-backend/database"
+	"rented-backend/models"
+
+	"github.com/google/uuid"
+)
+
+type VendorRepository interface {
+	Create(ctx context.Context, vendor *models.Vendor) error
+	Update(ctx context.Context, vendor *models.Vendor) error
+	GetByID(id uuid.UUID, userID uuid.UUID) (*models.Vendor, error)
+	List(userID uuid.UUID, trade string) ([]models.Vendor, error)
+	GetJobs(vendorID uuid.UUID) ([]models.MaintenanceTicket, error)
+	GetPayments(vendorID uuid.UUID) ([]models.Expense, error)
+	YearlySpend(userID uuid.UUID, vendorID *uuid.UUID, year int) ([]models.VendorSpend, error)
+}
+
+type vendorRepository struct{}
+
+func NewVendorRepository() VendorRepository {
+	return &vendorRepository{}
+}
+
+func (r *vendorRepository) Create(ctx context.Context, vendor *models.Vendor) error {
+	return database.DB.WithContext(ctx).Create(vendor).Error
+}
+
+func (r *vendorRepository) Update(ctx context.Context, vendor *models.Vendor) error {
+	return database.DB.WithContext(ctx).Save(vendor).Error
+}
+
+func (r *vendorRepository) GetByID(id uuid.UUID, userID uuid.UUID) (*models.Vendor, error) {
+	var vendor models.Vendor
+	err := database.DB.Where("id = ? AND user_id = ?", id, userID).First(&vendor).Error
+	if err != nil {
+		return nil, err
+	}
+	return &vendor, nil
+}
+
+func (r *vendorRepository) List(userID uuid.UUID, trade string) ([]models.Vendor, error) {
+	query := database.DB.Where("user_id = ?", userID)
+	if trade != "" {
+		query = query.Where("trade = ?", trade)
+	}
+	vendors := []models.Vendor{}
+	err := query.Order("name").Find(&vendors).Error
+	return vendors, err
+}
+
+func (r *vendorRepository) GetJobs(vendorID uuid.UUID) ([]models.MaintenanceTicket, error) {
+	tickets := []models.MaintenanceTicket{}
+	err := database.DB.Where("vendor_id = ?", vendorID).Order("created_at DESC").Find(&tickets).Error
+	return tickets, err
+}
+
+func (r *vendorRepository) GetPayments(vendorID uuid.UUID) ([]models.Expense, error) {
+	expenses := []models.Expense{}
+	err := database.DB.Where("vendor_id = ?", vendorID).Order("date DESC").Find(&expenses).Error
+	return expenses, err
+}
+
+// YearlySpend totals expenses per vendor per year, optionally for one
+// vendor and/or one year (0 means every year).
+func (r *vendorRepository) YearlySpend(userID uuid.UUID, vendorID *uuid.UUID, year int) ([]models.VendorSpend, error) {
+	query := database.DB.Table("expenses").
+		Select("vendors.id AS vendor_id, vendors.name AS vendor_name, CAST(extract(year from expenses.date) AS int) AS year, CAST(SUM(expenses.amount) AS bigint) AS amount, COUNT(*) AS payments").
+		Joins("JOIN vendors ON vendors.id = expenses.vendor_id").
+		Where("expenses.user_id = ?", userID)
+	if vendorID != nil {
+		query = query.Where("expenses.vendor_id = ?", *vendorID)
+	}
+	if year != 0 {
+		query = query.Where("extract(year from expenses.date) = ?", year)
+	}
+
+	spend := []models.VendorSpend{}
+	err := query.Group("vendors.id, vendors.name, 3").Order("year DESC, amount DESC").Scan(&spend).Error
+	return spend, err
+}
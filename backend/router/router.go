@@ -14,6 +14,24 @@ func SetupRouter(
 	tenantHandler *handlers.TenantHandler,
 	rentHandler *handlers.RentHandler,
 	dashboardHandler *handlers.DashboardHandler,
+	auditHandler *handlers.AuditHandler,
+	meterHandler *handlers.MeterHandler,
+	chargeHandler *handlers.ChargeHandler,
+	sharedBillHandler *handlers.SharedBillHandler,
+	chargeTypeHandler *handlers.ChargeTypeHandler,
+	policyHandler *handlers.PolicyHandler,
+	reportHandler *handlers.ReportHandler,
+	expenseHandler *handlers.ExpenseHandler,
+	maintenanceHandler *handlers.MaintenanceHandler,
+	vendorHandler *handlers.VendorHandler,
+	tenantAuthHandler *handlers.TenantAuthHandler,
+	portalHandler *handlers.PortalHandler,
+	proofHandler *handlers.PaymentProofHandler,
+	reminderHandler *handlers.ReminderHandler,
+	notificationHandler *handlers.NotificationHandler,
+	emailHandler *handlers.EmailHandler,
+	exportHandler *handlers.ExportHandler,
+	importHandler *handlers.ImportHandler,
 ) *gin.Engine {
 	r := gin.Default()
 
@@ -33,22 +51,99 @@ func SetupRouter(
 			auth.POST("/google", authHandler.GoogleLogin)
 		}
 
+		// SMS gateway delivery reports (Public, shared secret)
+		api.POST("/sms/delivery", reminderHandler.DeliveryReport)
+
+		// Unsubscribe link in emails (Public, token)
+		api.GET("/email/unsubscribe", emailHandler.Unsubscribe)
+		api.POST("/email/unsubscribe", emailHandler.Unsubscribe)
+
+		// Tenant login (Public)
+		tenantAuth := api.Group("/tenant-auth")
+		{
+			tenantAuth.POST("/otp", tenantAuthHandler.RequestOTP)
+			tenantAuth.POST("/otp/verify", tenantAuthHandler.VerifyOTP)
+			tenantAuth.POST("/link", tenantAuthHandler.RequestLink)
+			tenantAuth.POST("/link/verify", tenantAuthHandler.VerifyLink)
+		}
+
+		// Tenant portal, tenant tokens only
+		portal := api.Group("/portal")
+		portal.Use(middleware.TenantAuthMiddleware(), middleware.AuditMiddleware())
+		{
+			portal.GET("/me", portalHandler.GetMe)
+			portal.PUT("/preferences", portalHandler.UpdatePreferences)
+			portal.GET("/dues", portalHandler.GetDues)
+			portal.GET("/statement", portalHandler.GetStatement)
+			portal.GET("/receipts", portalHandler.GetReceipts)
+			portal.GET("/receipts/:id", portalHandler.GetReceipt)
+			portal.POST("/tickets", portalHandler.CreateTicket)
+			portal.GET("/tickets", portalHandler.GetTickets)
+			portal.GET("/tickets/:id", portalHandler.GetTicket)
+			portal.POST("/tickets/:id/comments", portalHandler.AddTicketComment)
+			portal.POST("/tickets/:id/photos", portalHandler.UploadTicketPhoto)
+			portal.POST("/payments", portalHandler.SubmitPaymentProof)
+			portal.GET("/payments", portalHandler.GetPaymentProofs)
+		}
+
 		// Protected routes
 		protected := api.Group("/")
-		protected.Use(middleware.AuthMiddleware())
+		protected.Use(middleware.AuthMiddleware(), middleware.AuditMiddleware())
 		{
 			// Auth Profile
 			protected.GET("/auth/me", authHandler.GetProfile)
+			protected.PUT("/auth/me/settings", authHandler.UpdateSettings)
+
+			// Caretakers, managed by the account owner
+			protected.GET("/caretakers", authHandler.GetCaretakers)
+			protected.POST("/caretakers", authHandler.AddCaretaker)
+			protected.DELETE("/caretakers/:id", authHandler.RemoveCaretaker)
 
 			// Dashboard
 			protected.GET("/dashboard", dashboardHandler.GetStats)
 
+			// Reports
+			protected.GET("/reports/aging", reportHandler.GetAging)
+			protected.GET("/reports/noi", reportHandler.GetNOI)
+			protected.GET("/reports/vendor-spend", vendorHandler.GetVendorSpend)
+			protected.GET("/reports/monthly", reportHandler.GetMonthlyReport)
+
+			// Spreadsheet exports
+			exports := protected.Group("/exports")
+			{
+				exports.GET("/tenants", exportHandler.ExportTenants)
+				exports.GET("/payments", exportHandler.ExportPayments)
+				exports.GET("/aging", exportHandler.ExportAging)
+				exports.GET("/expenses", exportHandler.ExportExpenses)
+			}
+
+			// Spreadsheet imports, validated with dry_run=true first
+			imports := protected.Group("/imports")
+			{
+				imports.GET("/", importHandler.GetImports)
+				imports.GET("/templates/:kind", importHandler.GetTemplate)
+				imports.POST("/:kind", importHandler.Import)
+				imports.POST("/batches/:id/rollback", importHandler.RollbackImport)
+			}
+
+			// Email preferences
+			protected.GET("/email/preferences", emailHandler.GetPreferences)
+			protected.PUT("/email/preferences", emailHandler.UpdatePreferences)
+
+			// Audit log
+			protected.GET("/audit", auditHandler.GetAuditLogs)
+
 			// House & Flat routes
 			houses := protected.Group("/houses")
 			{
 				houses.POST("/", houseHandler.CreateHouse)
 				houses.GET("/", houseHandler.GetUserHouses)
 				houses.POST("/flats", houseHandler.CreateFlat)
+				houses.PUT("/flats/:id/charges", houseHandler.UpdateFlatCharges)
+				houses.POST("/:id/shared-bills", sharedBillHandler.CreateSharedBill)
+				houses.GET("/:id/shared-bills", sharedBillHandler.GetHouseSharedBills)
+				houses.GET("/:id/policy", policyHandler.GetHousePolicy)
+				houses.PUT("/:id/policy", policyHandler.UpdateHousePolicy)
 			}
 
 			tenants := protected.Group("/tenants")
@@ -60,13 +155,110 @@ func SetupRouter(
 				tenants.PUT("/:id/status", tenantHandler.UpdateTenantStatus)
 				tenants.DELETE("/:id", tenantHandler.DeleteTenant)
 				tenants.GET("/:id/rents", rentHandler.GetTenantRents)
+				tenants.GET("/:id/charges", chargeHandler.GetTenantCharges)
+				tenants.GET("/:id/dues", tenantHandler.GetTenantDues)
+				tenants.GET("/:id/statement", tenantHandler.GetTenantStatement)
+				tenants.PUT("/:id/notifications", tenantHandler.UpdateTenantNotifications)
 			}
 
 			rents := protected.Group("/rents")
 			{
 				rents.POST("/", rentHandler.CreateRent)
 				rents.DELETE("/:id", rentHandler.DeleteRent)
+				rents.GET("/:id/receipt", rentHandler.GetReceipt)
+			}
+
+			// Charge catalogue & posted charges
+			chargeTypes := protected.Group("/charge-types")
+			{
+				chargeTypes.GET("/", chargeTypeHandler.GetChargeTypes)
+				chargeTypes.POST("/", chargeTypeHandler.CreateChargeType)
+				chargeTypes.PUT("/:id", chargeTypeHandler.UpdateChargeType)
+			}
+
+			charges := protected.Group("/charges")
+			{
+				charges.POST("/", chargeHandler.CreateCharge)
+				charges.DELETE("/:id", chargeHandler.DeleteCharge)
+				charges.POST("/:id/waive", chargeHandler.WaiveCharge)
 			}
+
+			expenses := protected.Group("/expenses")
+			{
+				expenses.POST("/", expenseHandler.CreateExpense)
+				expenses.GET("/", expenseHandler.GetExpenses)
+				expenses.PUT("/:id", expenseHandler.UpdateExpense)
+				expenses.DELETE("/:id", expenseHandler.DeleteExpense)
+				expenses.POST("/:id/receipt", expenseHandler.UploadReceipt)
+			}
+
+			tickets := protected.Group("/tickets")
+			{
+				tickets.POST("/", maintenanceHandler.CreateTicket)
+				tickets.GET("/", maintenanceHandler.GetTickets)
+				tickets.GET("/:id", maintenanceHandler.GetTicket)
+				tickets.PUT("/:id/status", maintenanceHandler.UpdateTicketStatus)
+				tickets.POST("/:id/comments", maintenanceHandler.AddComment)
+				tickets.POST("/:id/photos", maintenanceHandler.UploadPhoto)
+				tickets.POST("/:id/cost", maintenanceHandler.SetTicketCost)
+			}
+
+			// Tenant-submitted payments awaiting approval
+			proofs := protected.Group("/payment-proofs")
+			{
+				proofs.POST("/", proofHandler.SubmitPaymentProof)
+				proofs.GET("/", proofHandler.GetPaymentProofs)
+				proofs.POST("/:id/confirm", proofHandler.ConfirmPaymentProof)
+				proofs.POST("/:id/reject", proofHandler.RejectPaymentProof)
+			}
+
+			reminders := protected.Group("/reminders")
+			{
+				reminders.GET("/settings", reminderHandler.GetSettings)
+				reminders.PUT("/settings", reminderHandler.UpdateSettings)
+				reminders.GET("/templates", reminderHandler.GetTemplates)
+				reminders.PUT("/templates/:kind/:language", reminderHandler.UpdateTemplate)
+				reminders.GET("/messages", reminderHandler.GetMessages)
+				reminders.POST("/run", reminderHandler.RunReminders)
+			}
+
+			// Landlord app push devices and alert inbox
+			protected.POST("/devices", notificationHandler.RegisterDevice)
+			protected.DELETE("/devices/:token", notificationHandler.UnregisterDevice)
+
+			notifications := protected.Group("/notifications")
+			{
+				notifications.GET("/", notificationHandler.GetNotifications)
+				notifications.POST("/read", notificationHandler.MarkAllRead)
+				notifications.POST("/:id/read", notificationHandler.MarkRead)
+				notifications.GET("/preferences", notificationHandler.GetPreferences)
+				notifications.PUT("/preferences", notificationHandler.UpdatePreferences)
+			}
+
+			vendors := protected.Group("/vendors")
+			{
+				vendors.POST("/", vendorHandler.CreateVendor)
+				vendors.GET("/", vendorHandler.GetVendors)
+				vendors.GET("/:id", vendorHandler.GetVendor)
+				vendors.PUT("/:id", vendorHandler.UpdateVendor)
+			}
+
+			// Electricity meters & tariff
+			meters := protected.Group("/meters")
+			{
+				meters.POST("/", meterHandler.CreateMeter)
+				meters.GET("/", meterHandler.GetMeters)
+				meters.POST("/:id/readings", meterHandler.CreateReading)
+				meters.GET("/:id/readings", meterHandler.GetReadings)
+				meters.PUT("/:id/readings/:readingId", meterHandler.ReplaceReading)
+			}
+
+			protected.GET("/shared-bills/:id", sharedBillHandler.GetSharedBill)
+
+			protected.POST("/billing/late-fees/run", policyHandler.RunLateFees)
+
+			protected.GET("/tariffs/electricity", meterHandler.GetTariff)
+			protected.PUT("/tariffs/electricity", meterHandler.UpdateTariff)
 		}
 	}
 
@@ -0,0 +1,59 @@
+package scheduler
+
+import (
+	"context"
+	"rented-backend/logger"
+	"time"
+)
+
+type job struct {
+	name     string
+	interval time.Duration
+	run      func(ctx context.Context, now time.Time) error
+}
+
+// Scheduler runs background jobs at fixed intervals. Jobs must be
+// idempotent: each one runs once at start-up and then on every tick.
+type Scheduler struct {
+	jobs []job
+}
+
+func New() *Scheduler {
+	return &Scheduler{}
+}
+
+// Every registers fn to run every interval.
+func (s *Scheduler) Every(interval time.Duration, name string, fn func(ctx context.Context, now time.Time) error) {
+	s.jobs = append(s.jobs, job{name: name, interval: interval, run: fn})
+}
+
+// Start launches every job in its own goroutine until ctx is cancelled.
+func (s *Scheduler) Start(ctx context.Context) {
+	for _, j := range s.jobs {
+		go s.loop(ctx, j)
+	}
+}
+
+func (s *Scheduler) loop(ctx context.Context, j job) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	s.runOnce(ctx, j)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.runOnce(ctx, j)
+		}
+	}
+}
+
+func (s *Scheduler) runOnce(ctx context.Context, j job) {
+	start := time.Now()
+	if err := j.run(ctx, start); err != nil {
+		logger.Log.Error("Scheduled job failed", "job", j.name, "error", err)
+		return
+	}
+	logger.Log.Debug("Scheduled job finished", "job", j.name, "took", time.Since(start))
+}
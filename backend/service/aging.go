@@ -0,0 +1,161 @@
+package service
+
+import (
+	"rented-backend/models"
+	"rented-backend/money"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Aging report groupings.
+const (
+	AgingByTenant = "tenant"
+	AgingByFlat   = "flat"
+	AgingByHouse  = "house"
+)
+
+// AgingBuckets splits an outstanding amount by how many days have passed
+// since it fell due. Amounts not yet due count as current (0-30).
+type AgingBuckets struct {
+	Days0To30  money.Amount `json:"days_0_30"`
+	Days31To60 money.Amount `json:"days_31_60"`
+	Days61To90 money.Amount `json:"days_61_90"`
+	Days90Plus money.Amount `json:"days_90_plus"`
+	Total      money.Amount `json:"total"`
+}
+
+// Add puts amount into the bucket for ageDays.
+func (b *AgingBuckets) Add(ageDays int, amount money.Amount) {
+	switch {
+	case ageDays <= 30:
+		b.Days0To30 += amount
+	case ageDays <= 60:
+		b.Days31To60 += amount
+	case ageDays <= 90:
+		b.Days61To90 += amount
+	default:
+		b.Days90Plus += amount
+	}
+	b.Total += amount
+}
+
+func (b *AgingBuckets) merge(other AgingBuckets) {
+	b.Days0To30 += other.Days0To30
+	b.Days31To60 += other.Days31To60
+	b.Days61To90 += other.Days61To90
+	b.Days90Plus += other.Days90Plus
+	b.Total += other.Total
+}
+
+// AgingRow is one tenant, flat or house of the aging report. Tenant fields
+// are only set when grouping by tenant.
+type AgingRow struct {
+	ID         uuid.UUID `json:"id"`
+	HouseName  string    `json:"house_name"`
+	FlatNumber string    `json:"flat_number,omitempty"`
+	TenantName string    `json:"tenant_name,omitempty"`
+	IsActive   *bool     `json:"is_active,omitempty"`
+	OldestDays int       `json:"oldest_days"` // age of the oldest unpaid month
+	AgingBuckets
+}
+
+type AgingReport struct {
+	GroupBy string       `json:"group_by"`
+	AsOf    time.Time    `json:"as_of"`
+	Rows    []AgingRow   `json:"rows"`
+	Totals  AgingBuckets `json:"totals"`
+}
+
+// BuildAging buckets every tenant's unpaid months by age and groups the
+// result. Tenants who have left are included as long as they owe money.
+func BuildAging(tenants []models.Tenant, dues map[uuid.UUID]*TenantDues, houseNames map[uuid.UUID]string, groupBy string, asOf time.Time) AgingReport {
+	report := AgingReport{GroupBy: groupBy, AsOf: asOf, Rows: []AgingRow{}}
+	rows := map[uuid.UUID]*AgingRow{}
+	order := []uuid.UUID{}
+
+	for _, t := range tenants {
+		d, ok := dues[t.ID]
+		if !ok || d.TotalDue <= 0 {
+			continue
+		}
+
+		var key uuid.UUID
+		switch groupBy {
+		case AgingByHouse:
+			key = t.HouseID
+		case AgingByFlat:
+			key = t.FlatID
+		default:
+			key = t.ID
+		}
+
+		row, ok := rows[key]
+		if !ok {
+			row = &AgingRow{ID: key, HouseName: houseNames[t.HouseID]}
+			if groupBy != AgingByHouse {
+				row.FlatNumber = t.Flat.Number
+			}
+			if groupBy == AgingByTenant {
+				active := t.IsActive
+				row.TenantName = t.Name
+				row.IsActive = &active
+			}
+			rows[key] = row
+			order = append(order, key)
+		}
+
+		for _, m := range d.Months {
+			if m.Due <= 0 {
+				continue
+			}
+			age := max(int(asOf.Sub(m.DueDate).Hours()/24), 0)
+			row.Add(age, m.Due)
+			row.OldestDays = max(row.OldestDays, age)
+		}
+	}
+
+	for _, key := range order {
+		report.Rows = append(report.Rows, *rows[key])
+		report.Totals.merge(rows[key].AgingBuckets)
+	}
+	return report
+}
+
+// SortAging orders rows by the given field: total, days_0_30, days_31_60,
+// days_61_90, days_90_plus, oldest_days or name. Unknown fields sort by
+// total.
+func SortAging(rows []AgingRow, field string, desc bool) {
+	value := func(r AgingRow) int64 {
+		switch field {
+		case "days_0_30":
+			return int64(r.Days0To30)
+		case "days_31_60":
+			return int64(r.Days31To60)
+		case "days_61_90":
+			return int64(r.Days61To90)
+		case "days_90_plus":
+			return int64(r.Days90Plus)
+		case "oldest_days":
+			return int64(r.OldestDays)
+		}
+		return int64(r.Total)
+	}
+	name := func(r AgingRow) string {
+		return r.HouseName + "\x00" + r.FlatNumber + "\x00" + r.TenantName
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		if field == "name" {
+			if desc {
+				return name(rows[i]) > name(rows[j])
+			}
+			return name(rows[i]) < name(rows[j])
+		}
+		if desc {
+			return value(rows[i]) > value(rows[j])
+		}
+		return value(rows[i]) < value(rows[j])
+	})
+}
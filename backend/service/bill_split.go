@@ -0,0 +1,63 @@
+package service
+
+import (
+	"errors"
+	"math"
+	"rented-backend/money"
+
+	"github.com/google/uuid"
+)
+
+type SplitParticipant struct {
+	ID    uuid.UUID
+	Basis float64
+}
+
+type SplitShare struct {
+	ID      uuid.UUID
+	Basis   float64
+	Percent float64
+	Amount  money.Amount
+}
+
+var ErrNoSplitBasis = errors.New("nothing to split the bill by: every share basis is zero")
+
+// SplitBill divides total in proportion to each participant's basis. The
+// amounts are rounded to the paisa and any rounding difference is put on
+// the largest share so the shares always add up to total.
+func SplitBill(total money.Amount, participants []SplitParticipant) ([]SplitShare, error) {
+	if len(participants) == 0 {
+		return nil, errors.New("no occupied flats to split the bill across")
+	}
+
+	sum := 0.0
+	for _, p := range participants {
+		if p.Basis < 0 {
+			return nil, errors.New("share basis cannot be negative")
+		}
+		sum += p.Basis
+	}
+	if sum == 0 {
+		return nil, ErrNoSplitBasis
+	}
+
+	shares := make([]SplitShare, len(participants))
+	var allocated money.Amount
+	largest := 0
+	for i, p := range participants {
+		shares[i] = SplitShare{
+			ID:      p.ID,
+			Basis:   p.Basis,
+			Percent: math.Round(p.Basis/sum*10000) / 100,
+			Amount:  total.Mul(p.Basis / sum),
+		}
+		allocated += shares[i].Amount
+		if shares[i].Amount > shares[largest].Amount {
+			largest = i
+		}
+	}
+
+	shares[largest].Amount += total - allocated
+
+	return shares, nil
+}
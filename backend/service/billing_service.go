@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"rented-backend/audit"
+	"rented-backend/billing"
+	"rented-backend/logger"
+	"rented-backend/models"
+	"rented-backend/repository"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BillingService runs the periodic billing jobs.
+type BillingService struct {
+	dueService     *DueService
+	userRepo       repository.UserRepository
+	tenantRepo     repository.TenantRepository
+	chargeRepo     repository.ChargeRepository
+	chargeTypeRepo repository.ChargeTypeRepository
+	policyRepo     repository.PolicyRepository
+	notifications  *NotificationService
+}
+
+func NewBillingService(dueService *DueService, userRepo repository.UserRepository, tenantRepo repository.TenantRepository, chargeRepo repository.ChargeRepository, chargeTypeRepo repository.ChargeTypeRepository, policyRepo repository.PolicyRepository, notifications *NotificationService) *BillingService {
+	return &BillingService{
+		dueService:     dueService,
+		userRepo:       userRepo,
+		tenantRepo:     tenantRepo,
+		chargeRepo:     chargeRepo,
+		chargeTypeRepo: chargeTypeRepo,
+		policyRepo:     policyRepo,
+		notifications:  notifications,
+	}
+}
+
+// ApplyAllLateFees applies late fees for every landlord and notifies those
+// who were billed.
+func (s *BillingService) ApplyAllLateFees(ctx context.Context, now time.Time) error {
+	userIDs, err := s.userRepo.ListIDs()
+	if err != nil {
+		return err
+	}
+	for _, userID := range userIDs {
+		jobCtx := audit.WithActor(ctx, audit.Actor{AccountID: userID, Type: audit.ActorSystem})
+		posted, err := s.ApplyLateFees(jobCtx, userID, now)
+		if err != nil {
+			logger.Log.Error("Failed to apply late fees", "userID", userID, "error", err)
+			continue
+		}
+		if posted > 0 {
+			logger.Log.Info("Applied late fees", "userID", userID, "count", posted)
+			today, err := s.dueService.Today(userID, now)
+			if err != nil {
+				logger.Log.Error("Failed to load account for billing alert", "userID", userID, "error", err)
+				continue
+			}
+			s.notifications.NotifyQuietly(jobCtx, userID, Alert{
+				Event:     models.EventBillingRun,
+				Title:     "Billing run complete",
+				Body:      fmt.Sprintf("%d late fees were posted or topped up.", posted),
+				DedupeKey: "billing:" + today.Format("2006-01-02"),
+			})
+		}
+	}
+	return nil
+}
+
+// ApplyLateFees posts a late fee for every month a tenant still owes once
+// the house's grace period has lapsed. Per-day fees are topped up on each
+// run until the cap; waived fees are left alone. It returns the number of
+// fees posted or updated. Days late are counted on the landlord's own
+// calendar.
+func (s *BillingService) ApplyLateFees(ctx context.Context, userID uuid.UUID, now time.Time) (int, error) {
+	today, err := s.dueService.Today(userID, now)
+	if err != nil {
+		return 0, err
+	}
+	tenants, err := s.tenantRepo.GetAll(userID)
+	if err != nil {
+		return 0, err
+	}
+
+	lateFeeType, err := s.chargeTypeRepo.GetByCode(userID, models.ChargeKindLateFee)
+	if err != nil {
+		return 0, fmt.Errorf("late fee charge type missing: %w", err)
+	}
+
+	applied := 0
+	for _, t := range tenants {
+		if !t.IsActive {
+			continue
+		}
+
+		policy, err := s.policyRepo.GetByHouseID(t.HouseID)
+		if err != nil {
+			return applied, err
+		}
+		if policy.LateFeeType == models.LateFeeNone {
+			continue
+		}
+
+		months, err := s.dueService.TenantMonthlyDues(t, today)
+		if err != nil {
+			return applied, err
+		}
+
+		charges, err := s.chargeRepo.GetByTenantID(t.ID)
+		if err != nil {
+			return applied, err
+		}
+		existing := map[billing.Period]models.Charge{}
+		for _, ch := range charges {
+			if ch.Kind == models.ChargeKindLateFee {
+				existing[ch.Period] = ch
+			}
+		}
+
+		for _, m := range months {
+			if !m.IsLate {
+				continue
+			}
+			// The fee is charged on what is owed for the month, not on earlier fees
+			outstanding := m.Due - m.Items[models.ChargeKindLateFee]
+			if outstanding <= 0 {
+				continue
+			}
+			fee := policy.LateFee(outstanding, m.DaysLate(today))
+			if fee <= 0 {
+				continue
+			}
+
+			// A fee entered by hand is left as the landlord set it
+			if charge, ok := existing[m.Period]; ok {
+				if charge.IsWaived() || charge.SourceType != models.ChargeSourceLateFee || charge.Amount >= fee {
+					continue
+				}
+				charge.Amount = fee
+				if err := s.chargeRepo.Update(ctx, &charge); err != nil {
+					return applied, err
+				}
+				applied++
+				continue
+			}
+
+			charge := models.Charge{
+				ID:           uuid.New(),
+				TenantID:     t.ID,
+				FlatID:       t.FlatID,
+				Period:       m.Period,
+				Kind:         models.ChargeKindLateFee,
+				ChargeTypeID: lateFeeType.ID,
+				Description:  fmt.Sprintf("Late fee for %s (due by %s)", m.Period.Label(), m.LateFrom.AddDate(0, 0, -1).Format("2 Jan 2006")),
+				Amount:       fee,
+				SourceType:   models.ChargeSourceLateFee,
+			}
+			if err := s.chargeRepo.Create(ctx, &charge); err != nil {
+				return applied, err
+			}
+			applied++
+		}
+	}
+
+	return applied, nil
+}
@@ -0,0 +1,421 @@
+package service
+
+import (
+	"rented-backend/billing"
+	"rented-backend/models"
+	"rented-backend/money"
+	"rented-backend/repository"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DueSummary is the landlord-wide view of what is owed, split between
+// amounts past their grace period and amounts due but not yet late.
+type DueSummary struct {
+	TotalDue         money.Amount
+	OverdueAmount    money.Amount
+	OverdueCount     int
+	NotYetLateAmount money.Amount
+	NotYetLateCount  int
+	Dues             []repository.TenantDue
+}
+
+type DueService struct {
+	rentRepo       repository.RentRepository
+	chargeRepo     repository.ChargeRepository
+	chargeTypeRepo repository.ChargeTypeRepository
+	policyRepo     repository.PolicyRepository
+	tenantRepo     repository.TenantRepository
+	userRepo       repository.UserRepository
+}
+
+func NewDueService(rentRepo repository.RentRepository, chargeRepo repository.ChargeRepository, chargeTypeRepo repository.ChargeTypeRepository, policyRepo repository.PolicyRepository, tenantRepo repository.TenantRepository, userRepo repository.UserRepository) *DueService {
+	return &DueService{
+		rentRepo:       rentRepo,
+		chargeRepo:     chargeRepo,
+		chargeTypeRepo: chargeTypeRepo,
+		policyRepo:     policyRepo,
+		tenantRepo:     tenantRepo,
+		userRepo:       userRepo,
+	}
+}
+
+// Today returns the landlord's current calendar date. Every asOf taken by
+// the due service is such a date, never a raw time.Now().
+func (s *DueService) Today(userID uuid.UUID, now time.Time) (time.Time, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return user.Today(now), nil
+}
+
+// Location returns the landlord's time zone, which calendar dates sent by
+// their clients are read in.
+func (s *DueService) Location(userID uuid.UUID) (*time.Location, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	return user.Location(), nil
+}
+
+// TenantMonthlyDues returns the tenant's month-by-month dues. The tenant
+// must be loaded with its flat's charges.
+func (s *DueService) TenantMonthlyDues(t models.Tenant, asOf time.Time) ([]MonthDue, error) {
+	dues, err := s.TenantDues(t, asOf)
+	if err != nil {
+		return nil, err
+	}
+	return dues.Months, nil
+}
+
+// TenantDues returns the tenant's due breakdown and totals. The tenant must
+// be loaded with its flat's charges.
+func (s *DueService) TenantDues(t models.Tenant, asOf time.Time) (*TenantDues, error) {
+	metered, err := s.meteredCodes(t.UserID)
+	if err != nil {
+		return nil, err
+	}
+	policy, err := s.policyRepo.GetByHouseID(t.HouseID)
+	if err != nil {
+		return nil, err
+	}
+	return s.tenantDues(t, asOf, metered, *policy)
+}
+
+// AllTenantDues returns the dues of every tenant of the landlord, active or
+// not, keyed by tenant ID.
+func (s *DueService) AllTenantDues(tenants []models.Tenant, asOf time.Time) (map[uuid.UUID]*TenantDues, error) {
+	result := map[uuid.UUID]*TenantDues{}
+	if len(tenants) == 0 {
+		return result, nil
+	}
+	l, err := s.loadLedgers(tenants[0].UserID)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range tenants {
+		in, err := s.ledgerInput(l, t, asOf)
+		if err != nil {
+			return nil, err
+		}
+		dues := CalculateDues(in)
+		result[t.ID] = &dues
+	}
+	return result, nil
+}
+
+// Aging returns the landlord's receivables aging report, including tenants
+// who have left owing money.
+func (s *DueService) Aging(userID uuid.UUID, asOf time.Time, groupBy string, houseNames map[uuid.UUID]string) (*AgingReport, error) {
+	tenants, err := s.tenantRepo.GetAll(userID)
+	if err != nil {
+		return nil, err
+	}
+	dues, err := s.AllTenantDues(tenants, asOf)
+	if err != nil {
+		return nil, err
+	}
+	report := BuildAging(tenants, dues, houseNames, groupBy, asOf)
+	return &report, nil
+}
+
+// Summary totals the dues of the landlord's active tenants, optionally
+// limited to one house.
+func (s *DueService) Summary(userID uuid.UUID, houseID *uuid.UUID, asOf time.Time) (*DueSummary, error) {
+	tenants, err := s.tenantRepo.GetAll(userID)
+	if err != nil {
+		return nil, err
+	}
+	l, err := s.loadLedgers(userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.summarize(tenants, l, houseID, asOf)
+}
+
+func (s *DueService) summarize(tenants []models.Tenant, l *ledgers, houseID *uuid.UUID, asOf time.Time) (*DueSummary, error) {
+	summary := &DueSummary{}
+	for _, t := range tenants {
+		if !t.IsActive || (houseID != nil && t.HouseID != *houseID) {
+			continue
+		}
+
+		in, err := s.ledgerInput(l, t, asOf)
+		if err != nil {
+			return nil, err
+		}
+		dues := CalculateDues(in)
+
+		tenantDue := dues.TotalDue
+		var overdue, notYetLate money.Amount
+		items := map[string]money.Amount{}
+		for _, m := range dues.Months {
+			if m.IsLate {
+				overdue += m.Due
+			} else {
+				notYetLate += m.Due
+			}
+			for code, amount := range m.Items {
+				items[code] += amount
+			}
+		}
+
+		if tenantDue > 0 {
+			summary.TotalDue += tenantDue
+			summary.Dues = append(summary.Dues, repository.TenantDue{
+				TenantName: t.Name,
+				TenantID:   t.ID,
+				FlatNo:     t.Flat.Number,
+				DueAmount:  tenantDue,
+				Items:      items,
+			})
+		}
+		if overdue > 0 {
+			summary.OverdueAmount += overdue
+			summary.OverdueCount++
+		}
+		if notYetLate > 0 {
+			summary.NotYetLateAmount += notYetLate
+			summary.NotYetLateCount++
+		}
+	}
+
+	return summary, nil
+}
+
+// billed returns what the tenants were billed for each month from from to
+// to, optionally limited to one house. Tenants count for the months they
+// occupied a flat, as on the dashboard, and the first and last month of a
+// tenancy are prorated like their dues.
+func (s *DueService) billed(tenants []models.Tenant, l *ledgers, houseID *uuid.UUID, from, to billing.Period) (map[billing.Period]money.Amount, error) {
+	billed := map[billing.Period]money.Amount{}
+	for _, t := range tenants {
+		if houseID != nil && t.HouseID != *houseID {
+			continue
+		}
+		// Gone without a leave date: no longer occupying the flat
+		if !t.IsActive && t.LeaveDate == nil {
+			continue
+		}
+
+		in, err := s.ledgerInput(l, t, to.Start())
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range MonthlyDues(in) {
+			if m.Period.Before(from) {
+				continue
+			}
+			for _, amount := range m.Expected {
+				billed[m.Period] += amount
+			}
+		}
+	}
+	return billed, nil
+}
+
+// DashboardStats returns the dashboard figures for the filter, with the
+// trend of the 12 months ending with it and the dues of active tenants as
+// of asOf. The month figures come from one query over the period and the
+// trend together, and every tenant's ledger is loaded once for billing and
+// dues alike.
+func (s *DueService) DashboardStats(userID uuid.UUID, filter repository.DashboardFilter, asOf time.Time) (*repository.DashboardStats, *DueSummary, error) {
+	stats, err := s.rentRepo.GetDashboardStats(userID, filter)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	span := filter
+	if trendFrom := filter.To.AddDate(0, -11, 0); trendFrom.Before(span.From) {
+		span.From = trendFrom
+	}
+	months, err := s.rentRepo.GetMonthlyFigures(userID, span)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tenants, err := s.tenantRepo.GetAll(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	l, err := s.loadLedgers(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	billed, err := s.billed(tenants, l, filter.HouseID, billing.Of(span.From), billing.Of(span.To))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	periodFrom, trendFrom := billing.Of(filter.From), billing.Of(filter.To).AddMonths(-11)
+	period := []repository.MonthlyFigures{}
+	stats.Trend = []repository.MonthlyFigures{}
+	for _, m := range months {
+		m.Billed = billed[m.Period]
+		if !m.Period.Before(periodFrom) {
+			period = append(period, m)
+		}
+		if !m.Period.Before(trendFrom) {
+			stats.Trend = append(stats.Trend, m)
+		}
+	}
+	stats.SetMonths(period)
+
+	dues, err := s.summarize(tenants, l, filter.HouseID, asOf)
+	if err != nil {
+		return nil, nil, err
+	}
+	stats.TotalDue = dues.TotalDue
+	stats.OverdueCount = dues.OverdueCount
+	stats.OverdueAmount = dues.OverdueAmount
+	stats.NotYetLateCount = dues.NotYetLateCount
+	stats.NotYetLateAmount = dues.NotYetLateAmount
+	return stats, dues, nil
+}
+
+func (s *DueService) housePolicy(cache map[uuid.UUID]models.RentPolicy, houseID uuid.UUID) (models.RentPolicy, error) {
+	if policy, ok := cache[houseID]; ok {
+		return policy, nil
+	}
+	policy, err := s.policyRepo.GetByHouseID(houseID)
+	if err != nil {
+		return models.RentPolicy{}, err
+	}
+	cache[houseID] = *policy
+	return *policy, nil
+}
+
+func (s *DueService) tenantDues(t models.Tenant, asOf time.Time, metered map[string]bool, policy models.RentPolicy) (*TenantDues, error) {
+	in, err := s.dueInput(t, asOf, metered, policy)
+	if err != nil {
+		return nil, err
+	}
+	dues := CalculateDues(in)
+	return &dues, nil
+}
+
+// Statement returns the tenant's account statement between from and to,
+// both inclusive.
+func (s *DueService) Statement(t models.Tenant, from, to time.Time) (*Statement, error) {
+	metered, err := s.meteredCodes(t.UserID)
+	if err != nil {
+		return nil, err
+	}
+	policy, err := s.policyRepo.GetByHouseID(t.HouseID)
+	if err != nil {
+		return nil, err
+	}
+	in, err := s.dueInput(t, to, metered, *policy)
+	if err != nil {
+		return nil, err
+	}
+
+	chargeTypes, err := s.chargeTypeRepo.GetAll(t.UserID)
+	if err != nil {
+		return nil, err
+	}
+	names := map[string]string{}
+	for _, ct := range chargeTypes {
+		names[ct.Code] = ct.Name
+	}
+
+	user, err := s.userRepo.GetByID(t.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	statement := BuildStatement(in, names, from, to, user.Location())
+	statement.TenantID = t.ID
+	statement.TenantName = t.Name
+	statement.FlatNumber = t.Flat.Number
+	return &statement, nil
+}
+
+func (s *DueService) dueInput(t models.Tenant, asOf time.Time, metered map[string]bool, policy models.RentPolicy) (DueInput, error) {
+	rents, err := s.rentRepo.GetByTenantID(t.ID)
+	if err != nil {
+		return DueInput{}, err
+	}
+	charges, err := s.chargeRepo.GetByTenantID(t.ID)
+	if err != nil {
+		return DueInput{}, err
+	}
+	return newDueInput(t, asOf, metered, policy, rents, charges), nil
+}
+
+func newDueInput(t models.Tenant, asOf time.Time, metered map[string]bool, policy models.RentPolicy, rents []models.RentPayment, charges []models.Charge) DueInput {
+	return DueInput{
+		JoinDate:    t.JoinDate,
+		LeaveDate:   t.LeaveDate,
+		AsOf:        asOf,
+		FlatCharges: t.Flat.Charges,
+		Charges:     charges,
+		Payments:    rents,
+		Metered:     metered,
+		Policy:      policy,
+	}
+}
+
+// ledgers holds the payments and charges of all of a landlord's tenants,
+// keyed by tenant, so walking every tenant takes two queries rather than
+// two a tenant.
+type ledgers struct {
+	rents    map[uuid.UUID][]models.RentPayment
+	charges  map[uuid.UUID][]models.Charge
+	metered  map[string]bool
+	policies map[uuid.UUID]models.RentPolicy
+}
+
+func (s *DueService) loadLedgers(userID uuid.UUID) (*ledgers, error) {
+	metered, err := s.meteredCodes(userID)
+	if err != nil {
+		return nil, err
+	}
+	rents, err := s.rentRepo.GetByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	charges, err := s.chargeRepo.GetByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &ledgers{
+		rents:    map[uuid.UUID][]models.RentPayment{},
+		charges:  map[uuid.UUID][]models.Charge{},
+		metered:  metered,
+		policies: map[uuid.UUID]models.RentPolicy{},
+	}
+	for _, r := range rents {
+		l.rents[r.TenantID] = append(l.rents[r.TenantID], r)
+	}
+	for _, c := range charges {
+		l.charges[c.TenantID] = append(l.charges[c.TenantID], c)
+	}
+	return l, nil
+}
+
+func (s *DueService) ledgerInput(l *ledgers, t models.Tenant, asOf time.Time) (DueInput, error) {
+	policy, err := s.housePolicy(l.policies, t.HouseID)
+	if err != nil {
+		return DueInput{}, err
+	}
+	return newDueInput(t, asOf, l.metered, policy, l.rents[t.ID], l.charges[t.ID]), nil
+}
+
+func (s *DueService) meteredCodes(userID uuid.UUID) (map[string]bool, error) {
+	chargeTypes, err := s.chargeTypeRepo.GetAll(userID)
+	if err != nil {
+		return nil, err
+	}
+	metered := map[string]bool{}
+	for _, ct := range chargeTypes {
+		if ct.Calculation == models.CalculationMetered {
+			metered[ct.Code] = true
+		}
+	}
+	return metered, nil
+}
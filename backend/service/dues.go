@@ -0,0 +1,167 @@
+package service
+
+// Due calculation rules, shared by every screen and report that shows what
+// a tenant owes:
+//
+//   - A month is billed from the flat's recurring fixed charges, prorated
+//     for the first and last month of the tenancy. A charge posted for the
+//     month (meter reading, shared bill, late fee, ...) replaces the fixed
+//     amount of the same charge type; waived charges are ignored.
+//   - Payments count towards the month they were recorded for. A month is
+//     settled once the payments reach its total; amounts are exact paisa,
+//     so there is no rounding tolerance.
+//   - The advance is a deposit held by the landlord. It never reduces the
+//     monthly dues and is reported separately as AdvanceHeld.
+
+import (
+	"rented-backend/billing"
+	"rented-backend/models"
+	"rented-backend/money"
+	"time"
+)
+
+// DueInput is everything needed to work out what a tenant owes.
+type DueInput struct {
+	JoinDate    time.Time
+	LeaveDate   *time.Time
+	AsOf        time.Time
+	FlatCharges []models.FlatCharge
+	Charges     []models.Charge
+	Payments    []models.RentPayment
+	Metered     map[string]bool // charge codes billed from readings
+	Policy      models.RentPolicy
+}
+
+// MonthDue is the outcome of one billing month.
+type MonthDue struct {
+	Period   billing.Period          `json:"period"`
+	Expected map[string]money.Amount `json:"expected"`
+	Paid     money.Amount            `json:"paid"`
+	Due      money.Amount            `json:"due"`
+	Items    map[string]money.Amount `json:"items,omitempty"` // due broken down by charge code
+	DueDate  time.Time               `json:"due_date"`
+	LateFrom time.Time               `json:"late_from"`
+	IsLate   bool                    `json:"is_late"`
+}
+
+// MonthlyDues walks every month from the join month up to AsOf, or the
+// leave month if earlier, and compares what was billed with what was paid
+// for that month. Fixed charges of the first and last month are prorated
+// by the policy.
+func MonthlyDues(in DueInput) []MonthDue {
+	last := billing.Of(in.AsOf)
+	if in.LeaveDate != nil {
+		if leave := billing.Of(*in.LeaveDate); leave.Before(last) {
+			last = leave
+		}
+	}
+
+	months := []MonthDue{}
+	for period := billing.Of(in.JoinDate); !period.After(last); period = period.AddMonths(1) {
+		// Expected amount per charge type for the month
+		share := in.Policy.OccupiedShare(period, in.JoinDate, in.LeaveDate)
+		expected := map[string]money.Amount{}
+		for _, fc := range in.FlatCharges {
+			if amount := fc.MonthlyAmount().Mul(share); amount > 0 {
+				expected[fc.ChargeType.Code] += amount
+			}
+		}
+
+		// A posted charge (meter reading, shared bill split, ...) replaces the
+		// flat's fixed amount for the same charge type
+		posted := map[string]money.Amount{}
+		for _, ch := range in.Charges {
+			if ch.Period == period && !ch.IsWaived() {
+				posted[ch.Kind] += ch.Amount
+			}
+		}
+		for code, amount := range posted {
+			expected[code] = amount
+		}
+
+		var paidAmount money.Amount
+		var hasPayment bool
+		paid := map[string]money.Amount{}
+		for _, r := range in.Payments {
+			if !r.IsAdvance && r.Period == period {
+				paidAmount += r.TotalPaid
+				for _, item := range r.Items {
+					paid[item.Code] += item.Amount
+				}
+				hasPayment = true
+			}
+		}
+
+		// Fall back to the hand-entered amount for metered charges with no reading
+		for code, amount := range paid {
+			if _, ok := expected[code]; !ok && in.Metered[code] {
+				expected[code] = amount
+			}
+		}
+
+		var expectedTotal money.Amount
+		for _, amount := range expected {
+			expectedTotal += amount
+		}
+
+		month := MonthDue{
+			Period:   period,
+			Expected: expected,
+			Paid:     paidAmount,
+			Items:    map[string]money.Amount{},
+			DueDate:  in.Policy.DueDate(period),
+			LateFrom: in.Policy.LateFrom(period),
+		}
+
+		if !hasPayment {
+			month.Due = expectedTotal
+			for code, amount := range expected {
+				month.Items[code] = amount
+			}
+		} else if paidAmount < expectedTotal {
+			month.Due = expectedTotal - paidAmount
+			for code, amount := range expected {
+				if short := amount - paid[code]; short > 0 {
+					month.Items[code] = short
+				}
+			}
+		}
+		month.IsLate = month.Due > 0 && !in.AsOf.Before(month.LateFrom)
+
+		months = append(months, month)
+	}
+
+	return months
+}
+
+// TenantDues is a tenant's full due breakdown.
+type TenantDues struct {
+	Months      []MonthDue   `json:"months"`
+	TotalDue    money.Amount `json:"total_due"`
+	TotalPaid   money.Amount `json:"total_paid"`   // excludes the advance
+	AdvanceHeld money.Amount `json:"advance_held"` // deposit, not applied to dues
+}
+
+// CalculateDues runs MonthlyDues and totals the result.
+func CalculateDues(in DueInput) TenantDues {
+	dues := TenantDues{Months: MonthlyDues(in)}
+	for _, m := range dues.Months {
+		dues.TotalDue += m.Due
+	}
+	for _, r := range in.Payments {
+		if r.IsAdvance {
+			dues.AdvanceHeld += r.TotalPaid
+		} else {
+			dues.TotalPaid += r.TotalPaid
+		}
+	}
+	return dues
+}
+
+// DaysLate returns how many days past the grace period the month is.
+func (m MonthDue) DaysLate(asOf time.Time) int {
+	if asOf.Before(m.LateFrom) {
+		return 0
+	}
+	return int(asOf.Sub(m.LateFrom).Hours()/24) + 1
+}
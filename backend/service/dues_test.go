@@ -0,0 +1,186 @@
+package service
+
+import (
+	"rented-backend/billing"
+	"rented-backend/models"
+	"rented-backend/money"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// tk is a whole number of taka.
+func tk(major int64) money.Amount {
+	return money.New(major, 0)
+}
+
+func fixedCharge(code string, amount money.Amount) models.FlatCharge {
+	return models.FlatCharge{
+		Amount: amount,
+		ChargeType: models.ChargeType{
+			Code:        code,
+			Recurrence:  models.RecurrenceRecurring,
+			Calculation: models.CalculationFixed,
+			IsActive:    true,
+		},
+	}
+}
+
+func payment(period billing.Period, items map[string]money.Amount) models.RentPayment {
+	p := models.RentPayment{Period: period}
+	for code, amount := range items {
+		p.Items = append(p.Items, models.PaymentItem{Code: code, Amount: amount})
+		p.TotalPaid += amount
+	}
+	return p
+}
+
+var january = billing.New(2026, time.January)
+
+func noProration() models.RentPolicy {
+	policy := models.DefaultRentPolicy(uuid.Nil)
+	policy.ProrationMode = models.ProrationNone
+	return policy
+}
+
+func TestMonthlyDues(t *testing.T) {
+	flat := []models.FlatCharge{
+		fixedCharge(models.ChargeKindBasicRent, tk(10000)),
+		fixedCharge(models.ChargeKindGas, tk(1000)),
+	}
+	leftInFebruary := date(2026, time.February, 28)
+
+	tests := []struct {
+		name      string
+		in        DueInput
+		wantDues  []money.Amount // per month, from the join month
+		wantItems map[string]money.Amount
+		wantLate  []bool
+	}{
+		{
+			name:     "unpaid months owe every fixed charge",
+			in:       DueInput{JoinDate: date(2026, time.January, 1), AsOf: date(2026, time.February, 5), FlatCharges: flat, Policy: noProration()},
+			wantDues: []money.Amount{tk(11000), tk(11000)},
+			wantLate: []bool{true, false},
+		},
+		{
+			name: "paid in full",
+			in: DueInput{JoinDate: date(2026, time.January, 1), AsOf: date(2026, time.January, 20), FlatCharges: flat, Policy: noProration(),
+				Payments: []models.RentPayment{payment(january, map[string]money.Amount{"basic_rent": tk(10000), "gas": tk(1000)})}},
+			wantDues: []money.Amount{0},
+		},
+		{
+			name: "a shortfall of paisa is still owed",
+			in: DueInput{JoinDate: date(2026, time.January, 1), AsOf: date(2026, time.January, 20), FlatCharges: flat, Policy: noProration(),
+				Payments: []models.RentPayment{payment(january, map[string]money.Amount{"basic_rent": money.New(9999, 50), "gas": tk(1000)})}},
+			wantDues:  []money.Amount{money.New(0, 50)},
+			wantItems: map[string]money.Amount{"basic_rent": money.New(0, 50)},
+		},
+		{
+			name: "partial payment is broken down by charge",
+			in: DueInput{JoinDate: date(2026, time.January, 1), AsOf: date(2026, time.January, 20), FlatCharges: flat, Policy: noProration(),
+				Payments: []models.RentPayment{payment(january, map[string]money.Amount{"basic_rent": tk(10000)})}},
+			wantDues:  []money.Amount{tk(1000)},
+			wantItems: map[string]money.Amount{"gas": tk(1000)},
+			wantLate:  []bool{true},
+		},
+		{
+			name: "posted charge replaces the fixed amount",
+			in: DueInput{JoinDate: date(2026, time.January, 1), AsOf: date(2026, time.January, 5), FlatCharges: flat, Policy: noProration(),
+				Charges: []models.Charge{{Period: january, Kind: "gas", Amount: tk(1500)}}},
+			wantDues: []money.Amount{tk(11500)},
+		},
+		{
+			name: "waived charge is ignored",
+			in: DueInput{JoinDate: date(2026, time.January, 1), AsOf: date(2026, time.January, 5), FlatCharges: flat, Policy: noProration(),
+				Charges: []models.Charge{{Period: january, Kind: "late_fee", Amount: tk(500), WaivedAt: &leftInFebruary}}},
+			wantDues: []money.Amount{tk(11000)},
+		},
+		{
+			name: "metered charge without a reading uses the amount paid",
+			in: DueInput{JoinDate: date(2026, time.January, 1), AsOf: date(2026, time.January, 20), FlatCharges: flat, Policy: noProration(),
+				Metered:  map[string]bool{"electricity": true},
+				Payments: []models.RentPayment{payment(january, map[string]money.Amount{"basic_rent": tk(10000), "gas": tk(1000), "electricity": tk(700)})}},
+			wantDues: []money.Amount{0},
+		},
+		{
+			name: "advance does not reduce dues",
+			in: DueInput{JoinDate: date(2026, time.January, 1), AsOf: date(2026, time.January, 5), FlatCharges: flat, Policy: noProration(),
+				Payments: []models.RentPayment{{TotalPaid: tk(20000), IsAdvance: true}}},
+			wantDues: []money.Amount{tk(11000)},
+		},
+		{
+			name:     "move-in month is prorated by calendar days",
+			in:       DueInput{JoinDate: date(2026, time.January, 25), AsOf: date(2026, time.January, 31), FlatCharges: flat, Policy: models.DefaultRentPolicy(uuid.Nil)},
+			wantDues: []money.Amount{money.New(2483, 87)}, // 7 of 31 days: 2258.06 + 225.81
+		},
+		{
+			name:     "no months after the leave date",
+			in:       DueInput{JoinDate: date(2026, time.January, 1), LeaveDate: &leftInFebruary, AsOf: date(2026, time.June, 1), FlatCharges: flat, Policy: noProration()},
+			wantDues: []money.Amount{tk(11000), tk(11000)},
+		},
+		{
+			name:     "not late within the grace period",
+			in:       DueInput{JoinDate: date(2026, time.January, 1), AsOf: date(2026, time.January, 10), FlatCharges: flat, Policy: noProration()},
+			wantDues: []money.Amount{tk(11000)},
+			wantLate: []bool{false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			months := MonthlyDues(tt.in)
+			if len(months) != len(tt.wantDues) {
+				t.Fatalf("got %d months, want %d", len(months), len(tt.wantDues))
+			}
+			for i, m := range months {
+				if m.Due != tt.wantDues[i] {
+					t.Errorf("%s: due %s, want %s", m.Period, m.Due, tt.wantDues[i])
+				}
+				if tt.wantLate != nil && m.IsLate != tt.wantLate[i] {
+					t.Errorf("%s: late %v, want %v", m.Period, m.IsLate, tt.wantLate[i])
+				}
+			}
+			if tt.wantItems != nil {
+				last := months[len(months)-1]
+				if len(last.Items) != len(tt.wantItems) {
+					t.Fatalf("items %v, want %v", last.Items, tt.wantItems)
+				}
+				for code, amount := range tt.wantItems {
+					if last.Items[code] != amount {
+						t.Errorf("item %s: %s, want %s", code, last.Items[code], amount)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestCalculateDues(t *testing.T) {
+	in := DueInput{
+		JoinDate:    date(2026, time.January, 1),
+		AsOf:        date(2026, time.February, 20),
+		FlatCharges: []models.FlatCharge{fixedCharge(models.ChargeKindBasicRent, tk(10000))},
+		Policy:      noProration(),
+		Payments: []models.RentPayment{
+			{TotalPaid: tk(20000), IsAdvance: true},
+			payment(january, map[string]money.Amount{"basic_rent": tk(10000)}),
+		},
+	}
+
+	dues := CalculateDues(in)
+	if dues.TotalDue != tk(10000) {
+		t.Errorf("total due %s, want 10000.00", dues.TotalDue)
+	}
+	if dues.TotalPaid != tk(10000) {
+		t.Errorf("total paid %s, want 10000.00", dues.TotalPaid)
+	}
+	if dues.AdvanceHeld != tk(20000) {
+		t.Errorf("advance held %s, want 20000.00", dues.AdvanceHeld)
+	}
+}
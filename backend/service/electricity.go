@@ -0,0 +1,45 @@
+package service
+
+import (
+	"math"
+	"rented-backend/models"
+	"rented-backend/money"
+)
+
+type ElectricityBill struct {
+	Units        float64      `json:"units"`
+	EnergyCharge money.Amount `json:"energy_charge"`
+	MeterRent    money.Amount `json:"meter_rent"`
+	VAT          money.Amount `json:"vat"`
+	Total        money.Amount `json:"total"`
+}
+
+// CalculateElectricityBill prices units against the tariff's slabs
+// cumulatively: each slab only bills the units that fall inside it.
+func CalculateElectricityBill(units float64, tariff models.ElectricityTariff) ElectricityBill {
+	bill := ElectricityBill{Units: units, MeterRent: tariff.MeterRent}
+
+	remaining := units
+	lower := 0.0
+	energy := 0.0
+	for _, slab := range tariff.Slabs {
+		if remaining <= 0 {
+			break
+		}
+		inSlab := remaining
+		if slab.UpTo > 0 {
+			inSlab = math.Min(remaining, slab.UpTo-lower)
+			lower = slab.UpTo
+		}
+		if inSlab <= 0 {
+			continue
+		}
+		energy += inSlab * slab.Rate
+		remaining -= inSlab
+	}
+
+	bill.EnergyCharge = money.FromFloat(energy)
+	bill.VAT = (bill.EnergyCharge + bill.MeterRent).Percent(tariff.VATRate)
+	bill.Total = bill.EnergyCharge + bill.MeterRent + bill.VAT
+	return bill
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"rented-backend/models"
+	"rented-backend/money"
+	"testing"
+)
+
+func TestCalculateElectricityBill(t *testing.T) {
+	tariff := models.DefaultElectricityTariff()
+	noRent := tariff
+	noRent.MeterRent = 0
+	noRent.VATRate = 0
+
+	tests := []struct {
+		name       string
+		units      float64
+		tariff     models.ElectricityTariff
+		wantEnergy money.Amount
+		wantVAT    money.Amount
+		wantTotal  money.Amount
+	}{
+		{
+			name:       "no units still pays meter rent and its VAT",
+			units:      0,
+			tariff:     tariff,
+			wantEnergy: 0,
+			wantVAT:    money.New(2, 0),
+			wantTotal:  money.New(42, 0),
+		},
+		{
+			name:       "top of the first slab",
+			units:      75,
+			tariff:     tariff,
+			wantEnergy: money.New(394, 50),
+			wantVAT:    money.New(21, 73), // 21.725 rounds up
+			wantTotal:  money.New(456, 23),
+		},
+		{
+			name:       "one unit into the second slab",
+			units:      76,
+			tariff:     tariff,
+			wantEnergy: money.New(401, 70),
+			wantVAT:    money.New(22, 9), // 22.085
+			wantTotal:  money.New(463, 79),
+		},
+		{
+			name:       "top of the second slab",
+			units:      200,
+			tariff:     tariff,
+			wantEnergy: money.New(1294, 50),
+			wantVAT:    money.New(66, 73),
+			wantTotal:  money.New(1401, 23),
+		},
+		{
+			name:       "past the last bounded slab",
+			units:      650,
+			tariff:     tariff,
+			wantEnergy: money.New(6120, 0),
+			wantVAT:    money.New(308, 0),
+			wantTotal:  money.New(6468, 0),
+		},
+		{
+			name:       "fractional units",
+			units:      10.5,
+			tariff:     tariff,
+			wantEnergy: money.New(55, 23),
+			wantVAT:    money.New(4, 76), // 4.7615
+			wantTotal:  money.New(99, 99),
+		},
+		{
+			name:       "no meter rent or VAT",
+			units:      100,
+			tariff:     noRent,
+			wantEnergy: money.New(574, 50),
+			wantVAT:    0,
+			wantTotal:  money.New(574, 50),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bill := CalculateElectricityBill(tt.units, tt.tariff)
+			if bill.EnergyCharge != tt.wantEnergy {
+				t.Errorf("energy charge %s, want %s", bill.EnergyCharge, tt.wantEnergy)
+			}
+			if bill.MeterRent != tt.tariff.MeterRent {
+				t.Errorf("meter rent %s, want %s", bill.MeterRent, tt.tariff.MeterRent)
+			}
+			if bill.VAT != tt.wantVAT {
+				t.Errorf("VAT %s, want %s", bill.VAT, tt.wantVAT)
+			}
+			if bill.Total != tt.wantTotal {
+				t.Errorf("total %s, want %s", bill.Total, tt.wantTotal)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"rented-backend/audit"
+	"rented-backend/logger"
+	"rented-backend/models"
+	"rented-backend/repository"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ExpenseService struct {
+	repo     repository.ExpenseRepository
+	userRepo repository.UserRepository
+}
+
+func NewExpenseService(repo repository.ExpenseRepository, userRepo repository.UserRepository) *ExpenseService {
+	return &ExpenseService{repo: repo, userRepo: userRepo}
+}
+
+// GenerateRecurring records every copy of a recurring expense that has
+// fallen due on its landlord's calendar, catching up on missed runs. Each
+// template's copies are booked together with its next date, so a run that
+// fails part way, or races another replica, books nothing twice.
+func (s *ExpenseService) GenerateRecurring(ctx context.Context, now time.Time) error {
+	// Local dates run up to a day ahead of UTC
+	templates, err := s.repo.GetDueRecurring(now.AddDate(0, 0, 1))
+	if err != nil {
+		return err
+	}
+
+	today := map[uuid.UUID]time.Time{}
+	for _, tmpl := range templates {
+		if _, ok := today[tmpl.UserID]; !ok {
+			user, err := s.userRepo.GetByID(tmpl.UserID)
+			if err != nil {
+				logger.Log.Error("Failed to load account for recurring expense", "expenseID", tmpl.ID, "error", err)
+				continue
+			}
+			today[tmpl.UserID] = user.Today(now)
+		}
+		if tmpl.NextDate.After(today[tmpl.UserID]) {
+			continue
+		}
+
+		jobCtx := audit.WithActor(ctx, audit.Actor{AccountID: tmpl.UserID, Type: audit.ActorSystem})
+		var occurrences []models.Expense
+		next := *tmpl.NextDate
+		for !next.After(today[tmpl.UserID]) {
+			parentID := tmpl.ID
+			occurrences = append(occurrences, models.Expense{
+				ID:          uuid.New(),
+				UserID:      tmpl.UserID,
+				HouseID:     tmpl.HouseID,
+				FlatID:      tmpl.FlatID,
+				Category:    tmpl.Category,
+				Vendor:      tmpl.Vendor,
+				Amount:      tmpl.Amount,
+				Date:        next,
+				Description: tmpl.Description,
+				Recurrence:  models.ExpenseRepeatNone,
+				ParentID:    &parentID,
+			})
+			next = tmpl.NextOccurrence(next)
+		}
+
+		err := s.repo.BookRecurring(jobCtx, &tmpl, occurrences, next)
+		if err != nil && !errors.Is(err, repository.ErrAlreadyBooked) {
+			logger.Log.Error("Failed to record recurring expense", "expenseID", tmpl.ID, "error", err)
+		}
+	}
+
+	return nil
+}
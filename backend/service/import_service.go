@@ -0,0 +1,560 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"rented-backend/billing"
+	"rented-backend/export"
+	"rented-backend/models"
+	"rented-backend/money"
+	"rented-backend/repository"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/xuri/excelize/v2"
+)
+
+// MaxImportRows caps the data rows of one import file.
+const MaxImportRows = 5000
+
+var ErrImportKind = errors.New("kind must be houses, flats, tenants or payments")
+
+// importDateFormats are the accepted date cell formats.
+var importDateFormats = []string{"2006-01-02", "02/01/2006", "2/1/2006"}
+
+// importColumns are each template's fixed columns, required ones first.
+// Flat and payment templates also take one column per charge code.
+var importColumns = map[string][]string{
+	models.ImportHouses:   {"name"},
+	models.ImportFlats:    {"house", "number", "size"},
+	models.ImportTenants:  {"house", "flat", "name", "phone", "join_date", "members", "nid_number", "advance_amount", "lease_end_date", "leave_date"},
+	models.ImportPayments: {"house", "flat", "period", "phone", "payment_date", "method", "transaction_id"},
+}
+
+var paymentMethods = []string{
+	models.PaymentMethodCash, models.PaymentMethodBkash, models.PaymentMethodNagad,
+	models.PaymentMethodRocket, models.PaymentMethodBank, models.PaymentMethodOther,
+}
+
+// ImportService validates spreadsheets of houses, flats, tenants or past
+// payments and commits them as one import batch. Rows refer to houses,
+// flats and tenants that already exist, so files are imported in that
+// order.
+type ImportService struct {
+	importRepo     repository.ImportRepository
+	houseRepo      repository.HouseRepository
+	tenantRepo     repository.TenantRepository
+	chargeTypeRepo repository.ChargeTypeRepository
+}
+
+func NewImportService(importRepo repository.ImportRepository, houseRepo repository.HouseRepository, tenantRepo repository.TenantRepository, chargeTypeRepo repository.ChargeTypeRepository) *ImportService {
+	return &ImportService{importRepo: importRepo, houseRepo: houseRepo, tenantRepo: tenantRepo, chargeTypeRepo: chargeTypeRepo}
+}
+
+// TemplateColumns returns the header row of the kind's template.
+func (s *ImportService) TemplateColumns(userID uuid.UUID, kind string) ([]string, error) {
+	columns, ok := importColumns[kind]
+	if !ok {
+		return nil, ErrImportKind
+	}
+	columns = slices.Clone(columns)
+	if kind == models.ImportFlats || kind == models.ImportPayments {
+		chargeTypes, err := s.chargeTypeRepo.GetAll(userID)
+		if err != nil {
+			return nil, err
+		}
+		for _, ct := range chargeTypes {
+			if ct.IsActive {
+				columns = append(columns, ct.Code)
+			}
+		}
+	}
+	return columns, nil
+}
+
+// Import reads a CSV or XLSX file of the given kind and validates every
+// row. Unless dryRun is set or a row has errors, the rows are written in
+// a single transaction and the result carries the new batch.
+func (s *ImportService) Import(ctx context.Context, userID uuid.UUID, kind, filename string, file io.Reader, dryRun bool) (*models.ImportResult, error) {
+	if _, ok := importColumns[kind]; !ok {
+		return nil, ErrImportKind
+	}
+	result := &models.ImportResult{Kind: kind, DryRun: dryRun, Errors: []models.ImportRowError{}}
+
+	sheet, err := readImportFile(filename, file)
+	if err != nil {
+		result.Errors = append(result.Errors, models.ImportRowError{Row: 1, Message: err.Error()})
+		return result, nil
+	}
+	result.Rows = len(sheet.rows)
+
+	chargeTypes, err := s.chargeTypeRepo.GetAll(userID)
+	if err != nil {
+		return nil, err
+	}
+	codes := map[string]models.ChargeType{}
+	for _, ct := range chargeTypes {
+		codes[ct.Code] = ct
+	}
+
+	allowed := importColumns[kind]
+	for _, column := range sheet.header {
+		if column == "" || slices.Contains(allowed, column) {
+			continue
+		}
+		if _, ok := codes[column]; ok && (kind == models.ImportFlats || kind == models.ImportPayments) {
+			continue
+		}
+		result.Errors = append(result.Errors, models.ImportRowError{Row: 1, Column: column, Message: "unknown column"})
+	}
+	for _, column := range allowed {
+		if isRequiredImportColumn(kind, column) && !slices.Contains(sheet.header, column) {
+			result.Errors = append(result.Errors, models.ImportRowError{Row: 1, Column: column, Message: "missing column"})
+		}
+	}
+	if len(sheet.rows) == 0 {
+		result.Errors = append(result.Errors, models.ImportRowError{Row: 1, Message: "the file has no data rows"})
+	}
+	if len(sheet.rows) > MaxImportRows {
+		result.Errors = append(result.Errors, models.ImportRowError{Row: 1, Message: fmt.Sprintf("at most %d rows can be imported at once", MaxImportRows)})
+	}
+	if len(result.Errors) > 0 {
+		return result, nil
+	}
+
+	p := &importPlanner{userID: userID, chargeTypes: chargeTypes, plan: &repository.ImportPlan{}}
+	if err := p.load(s.houseRepo, s.tenantRepo); err != nil {
+		return nil, err
+	}
+	for _, row := range sheet.rows {
+		switch kind {
+		case models.ImportHouses:
+			p.house(row)
+		case models.ImportFlats:
+			p.flat(row)
+		case models.ImportTenants:
+			p.tenant(row)
+		case models.ImportPayments:
+			p.payment(row)
+		}
+	}
+	result.Errors = append(result.Errors, p.errors...)
+	result.Valid = len(result.Errors) == 0
+	if !result.Valid || dryRun {
+		return result, nil
+	}
+
+	batch := &models.ImportBatch{
+		ID:       uuid.New(),
+		UserID:   userID,
+		Kind:     kind,
+		Filename: filename,
+		Rows:     result.Rows,
+		Status:   models.ImportCommitted,
+	}
+	if err := s.importRepo.Commit(ctx, batch, p.plan); err != nil {
+		return nil, err
+	}
+	result.Batch = batch
+	return result, nil
+}
+
+func isRequiredImportColumn(kind, column string) bool {
+	switch kind {
+	case models.ImportHouses:
+		return column == "name"
+	case models.ImportFlats:
+		return column == "house" || column == "number"
+	case models.ImportTenants:
+		return slices.Contains([]string{"house", "flat", "name", "phone", "join_date"}, column)
+	case models.ImportPayments:
+		return slices.Contains([]string{"house", "flat", "period"}, column)
+	}
+	return false
+}
+
+// importSheet is a file's header, normalised to column keys, and its
+// non-blank data rows.
+type importSheet struct {
+	header []string
+	rows   []importRow
+}
+
+type importRow struct {
+	number int // spreadsheet row, the header being 1
+	cells  map[string]string
+}
+
+func (r importRow) get(column string) string {
+	return r.cells[column]
+}
+
+func readImportFile(filename string, file io.Reader) (*importSheet, error) {
+	var records [][]string
+	switch strings.ToLower(filename[strings.LastIndex(filename, ".")+1:]) {
+	case export.FormatCSV:
+		reader := csv.NewReader(file)
+		reader.FieldsPerRecord = -1
+		reader.TrimLeadingSpace = true
+		var err error
+		if records, err = reader.ReadAll(); err != nil {
+			return nil, fmt.Errorf("could not read the CSV file: %v", err)
+		}
+	case export.FormatXLSX:
+		book, err := excelize.OpenReader(file)
+		if err != nil {
+			return nil, fmt.Errorf("could not read the XLSX file: %v", err)
+		}
+		defer book.Close()
+		if records, err = book.GetRows(book.GetSheetName(0)); err != nil {
+			return nil, fmt.Errorf("could not read the XLSX file: %v", err)
+		}
+	default:
+		return nil, errors.New("the file must be .csv or .xlsx")
+	}
+	if len(records) == 0 {
+		return nil, errors.New("the file is empty")
+	}
+
+	sheet := &importSheet{}
+	for i, cell := range records[0] {
+		if i == 0 {
+			cell = strings.TrimPrefix(cell, "\xEF\xBB\xBF")
+		}
+		key := strings.ToLower(strings.TrimSpace(cell))
+		sheet.header = append(sheet.header, strings.ReplaceAll(key, " ", "_"))
+	}
+	for i, record := range records[1:] {
+		row := importRow{number: i + 2, cells: map[string]string{}}
+		for j, cell := range record {
+			if j < len(sheet.header) && sheet.header[j] != "" {
+				if cell = strings.TrimSpace(cell); cell != "" {
+					row.cells[sheet.header[j]] = cell
+				}
+			}
+		}
+		if len(row.cells) > 0 {
+			sheet.rows = append(sheet.rows, row)
+		}
+	}
+	return sheet, nil
+}
+
+// importPlanner validates rows against the landlord's existing records
+// and the rows before them, collecting what to create.
+type importPlanner struct {
+	userID      uuid.UUID
+	chargeTypes []models.ChargeType
+	plan        *repository.ImportPlan
+	errors      []models.ImportRowError
+
+	houses  map[string]*models.House // by lower-case name
+	flats   map[uuid.UUID]map[string]*models.Flat
+	tenants map[uuid.UUID][]models.Tenant // by flat
+}
+
+func (p *importPlanner) load(houseRepo repository.HouseRepository, tenantRepo repository.TenantRepository) error {
+	houses, err := houseRepo.GetUserHouses(p.userID)
+	if err != nil {
+		return err
+	}
+	p.houses = map[string]*models.House{}
+	p.flats = map[uuid.UUID]map[string]*models.Flat{}
+	for i := range houses {
+		h := &houses[i]
+		p.houses[strings.ToLower(h.Name)] = h
+		p.flats[h.ID] = map[string]*models.Flat{}
+		for j := range h.Flats {
+			p.flats[h.ID][strings.ToLower(h.Flats[j].Number)] = &h.Flats[j]
+		}
+	}
+
+	tenants, err := tenantRepo.GetAll(p.userID)
+	if err != nil {
+		return err
+	}
+	p.tenants = map[uuid.UUID][]models.Tenant{}
+	for _, t := range tenants {
+		p.tenants[t.FlatID] = append(p.tenants[t.FlatID], t)
+	}
+	return nil
+}
+
+func (p *importPlanner) fail(row importRow, column, message string) {
+	p.errors = append(p.errors, models.ImportRowError{Row: row.number, Column: column, Message: message})
+}
+
+func (p *importPlanner) required(row importRow, columns ...string) bool {
+	ok := true
+	for _, column := range columns {
+		if row.get(column) == "" {
+			p.fail(row, column, "is required")
+			ok = false
+		}
+	}
+	return ok
+}
+
+func (p *importPlanner) number(row importRow, column string) (float64, bool) {
+	value := row.get(column)
+	if value == "" {
+		return 0, true
+	}
+	number, err := strconv.ParseFloat(strings.ReplaceAll(value, ",", ""), 64)
+	if err != nil || number < 0 {
+		p.fail(row, column, "must be a number of at least 0")
+		return 0, false
+	}
+	return number, true
+}
+
+func (p *importPlanner) amount(row importRow, column string) (money.Amount, bool) {
+	value := row.get(column)
+	if value == "" {
+		return 0, true
+	}
+	amount, err := money.Parse(value)
+	if err != nil || amount < 0 {
+		p.fail(row, column, "must be an amount of at least 0 with at most two decimals")
+		return 0, false
+	}
+	return amount, true
+}
+
+func (p *importPlanner) date(row importRow, column string) (*time.Time, bool) {
+	value := row.get(column)
+	if value == "" {
+		return nil, true
+	}
+	for _, layout := range importDateFormats {
+		if d, err := time.Parse(layout, value); err == nil {
+			return &d, true
+		}
+	}
+	p.fail(row, column, "must be a date like 2024-01-31 or 31/01/2024")
+	return nil, false
+}
+
+func (p *importPlanner) flatOf(row importRow) (*models.House, *models.Flat, bool) {
+	house, ok := p.houses[strings.ToLower(row.get("house"))]
+	if !ok {
+		p.fail(row, "house", "no house with this name")
+		return nil, nil, false
+	}
+	flat, ok := p.flats[house.ID][strings.ToLower(row.get("flat"))]
+	if !ok {
+		p.fail(row, "flat", "no flat with this number in the house")
+		return nil, nil, false
+	}
+	return house, flat, true
+}
+
+func (p *importPlanner) house(row importRow) {
+	if !p.required(row, "name") {
+		return
+	}
+	name := row.get("name")
+	if _, exists := p.houses[strings.ToLower(name)]; exists {
+		p.fail(row, "name", "a house with this name already exists")
+		return
+	}
+	house := models.House{ID: uuid.New(), UserID: p.userID, Name: name}
+	p.houses[strings.ToLower(name)] = &house
+	p.flats[house.ID] = map[string]*models.Flat{}
+	p.plan.Houses = append(p.plan.Houses, house)
+}
+
+func (p *importPlanner) flat(row importRow) {
+	if !p.required(row, "house", "number") {
+		return
+	}
+	house, ok := p.houses[strings.ToLower(row.get("house"))]
+	if !ok {
+		p.fail(row, "house", "no house with this name")
+		return
+	}
+	number := row.get("number")
+	if _, exists := p.flats[house.ID][strings.ToLower(number)]; exists {
+		p.fail(row, "number", "the house already has a flat with this number")
+		return
+	}
+	size, ok := p.number(row, "size")
+	if !ok {
+		return
+	}
+
+	flat := models.Flat{ID: uuid.New(), HouseID: house.ID, Number: number, Size: size}
+	for _, ct := range p.chargeTypes {
+		amount, ok := p.amount(row, ct.Code)
+		if !ok {
+			return
+		}
+		if amount > 0 {
+			flat.Charges = append(flat.Charges, models.FlatCharge{ID: uuid.New(), FlatID: flat.ID, ChargeTypeID: ct.ID, Amount: amount})
+		}
+	}
+	p.flats[house.ID][strings.ToLower(number)] = &flat
+	p.plan.Flats = append(p.plan.Flats, flat)
+}
+
+func (p *importPlanner) tenant(row importRow) {
+	if !p.required(row, "house", "flat", "name", "phone", "join_date") {
+		return
+	}
+	house, flat, ok := p.flatOf(row)
+	if !ok {
+		return
+	}
+
+	members := 1
+	if value := row.get("members"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			p.fail(row, "members", "must be a whole number of at least 1")
+			return
+		}
+		members = n
+	}
+	advance, ok := p.amount(row, "advance_amount")
+	if !ok {
+		return
+	}
+	joinDate, ok := p.date(row, "join_date")
+	if !ok {
+		return
+	}
+	leaseEnd, ok := p.date(row, "lease_end_date")
+	if !ok {
+		return
+	}
+	leaveDate, ok := p.date(row, "leave_date")
+	if !ok {
+		return
+	}
+	if leaveDate != nil && leaveDate.Before(*joinDate) {
+		p.fail(row, "leave_date", "is before the join date")
+		return
+	}
+
+	tenant := models.Tenant{
+		ID:            uuid.New(),
+		UserID:        p.userID,
+		HouseID:       house.ID,
+		FlatID:        flat.ID,
+		Name:          row.get("name"),
+		Phone:         row.get("phone"),
+		Members:       members,
+		NIDNumber:     row.get("nid_number"),
+		IsActive:      leaveDate == nil,
+		JoinDate:      *joinDate,
+		LeaveDate:     leaveDate,
+		LeaseEndDate:  leaseEnd,
+		AdvanceAmount: advance,
+	}
+	if tenant.IsActive {
+		for _, other := range p.tenants[flat.ID] {
+			if other.IsActive {
+				p.fail(row, "flat", "the flat already has an active tenant")
+				return
+			}
+		}
+	}
+	p.tenants[flat.ID] = append(p.tenants[flat.ID], tenant)
+	p.plan.Tenants = append(p.plan.Tenants, tenant)
+
+	if advance > 0 {
+		p.plan.Payments = append(p.plan.Payments, models.RentPayment{
+			ID:          uuid.New(),
+			TenantID:    tenant.ID,
+			TotalPaid:   advance,
+			IsAdvance:   true,
+			PaymentDate: tenant.JoinDate,
+		})
+	}
+}
+
+func (p *importPlanner) payment(row importRow) {
+	if !p.required(row, "house", "flat", "period") {
+		return
+	}
+	_, flat, ok := p.flatOf(row)
+	if !ok {
+		return
+	}
+	period, err := billing.Parse(row.get("period"))
+	if err != nil {
+		p.fail(row, "period", "must be a month like 2026-01")
+		return
+	}
+	paymentDate, ok := p.date(row, "payment_date")
+	if !ok {
+		return
+	}
+	if paymentDate == nil {
+		first := period.Start()
+		paymentDate = &first
+	}
+	method := strings.ToLower(row.get("method"))
+	if method == "" {
+		method = models.PaymentMethodCash
+	}
+	if !slices.Contains(paymentMethods, method) {
+		p.fail(row, "method", "must be one of "+strings.Join(paymentMethods, ", "))
+		return
+	}
+
+	// The tenant is whoever lived in the flat that month, or the one with
+	// the given phone when several did
+	start := period.Start()
+	end := period.End().AddDate(0, 0, -1)
+	var matches []models.Tenant
+	for _, t := range p.tenants[flat.ID] {
+		if phone := row.get("phone"); phone != "" {
+			if models.NormalizePhone(t.Phone) == models.NormalizePhone(phone) {
+				matches = append(matches, t)
+			}
+			continue
+		}
+		if !t.JoinDate.After(end) && (t.LeaveDate == nil || !t.LeaveDate.Before(start)) {
+			matches = append(matches, t)
+		}
+	}
+	switch {
+	case len(matches) == 0:
+		p.fail(row, "flat", "no tenant of this flat matches the row")
+		return
+	case len(matches) > 1:
+		p.fail(row, "phone", "several tenants match; give the tenant's phone")
+		return
+	}
+
+	payment := models.RentPayment{
+		ID:            uuid.New(),
+		TenantID:      matches[0].ID,
+		Period:        period,
+		PaymentDate:   *paymentDate,
+		Method:        method,
+		TransactionID: row.get("transaction_id"),
+	}
+	for _, ct := range p.chargeTypes {
+		amount, ok := p.amount(row, ct.Code)
+		if !ok {
+			return
+		}
+		if amount > 0 {
+			payment.Items = append(payment.Items, models.PaymentItem{ID: uuid.New(), RentPaymentID: payment.ID, ChargeTypeID: ct.ID, Code: ct.Code, Amount: amount})
+			payment.TotalPaid += amount
+		}
+	}
+	if payment.TotalPaid <= 0 {
+		p.fail(row, "", "no amount paid against any charge")
+		return
+	}
+	p.plan.Payments = append(p.plan.Payments, payment)
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"rented-backend/logger"
+	"rented-backend/models"
+	"rented-backend/repository"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// leaseNoticeDays are the days before a lease ends that the landlord is
+// alerted, smallest first.
+var leaseNoticeDays = []int{0, 7, 30}
+
+// LeaseAlertService warns landlords about leases about to run out.
+type LeaseAlertService struct {
+	notifications *NotificationService
+	userRepo      repository.UserRepository
+	tenantRepo    repository.TenantRepository
+}
+
+func NewLeaseAlertService(notifications *NotificationService, userRepo repository.UserRepository, tenantRepo repository.TenantRepository) *LeaseAlertService {
+	return &LeaseAlertService{notifications: notifications, userRepo: userRepo, tenantRepo: tenantRepo}
+}
+
+// NotifyAllExpiring sends the day's lease alerts for every landlord.
+func (s *LeaseAlertService) NotifyAllExpiring(ctx context.Context, now time.Time) error {
+	userIDs, err := s.userRepo.ListIDs()
+	if err != nil {
+		return err
+	}
+	for _, userID := range userIDs {
+		if err := s.NotifyExpiring(ctx, userID, now); err != nil {
+			logger.Log.Error("Failed to send lease alerts", "userID", userID, "error", err)
+		}
+	}
+	return nil
+}
+
+// NotifyExpiring alerts the landlord once per notice period for each active
+// tenant whose lease ends within it. A missed run is caught up by the next.
+func (s *LeaseAlertService) NotifyExpiring(ctx context.Context, userID uuid.UUID, now time.Time) error {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return err
+	}
+	tenants, err := s.tenantRepo.GetAll(userID)
+	if err != nil {
+		return err
+	}
+	today := user.Today(now)
+
+	for _, t := range tenants {
+		if !t.IsActive || t.LeaseEndDate == nil {
+			continue
+		}
+		end := time.Date(t.LeaseEndDate.Year(), t.LeaseEndDate.Month(), t.LeaseEndDate.Day(), 0, 0, 0, 0, time.UTC)
+		daysLeft := int(end.Sub(today).Hours() / 24)
+		if daysLeft < 0 {
+			continue
+		}
+
+		notice := -1
+		for _, d := range leaseNoticeDays {
+			if daysLeft <= d {
+				notice = d
+				break
+			}
+		}
+		if notice < 0 {
+			continue
+		}
+
+		body := fmt.Sprintf("%s's lease for flat %s ends in %d days, on %s.", t.Name, t.Flat.Number, daysLeft, end.Format("2 Jan 2006"))
+		if daysLeft == 0 {
+			body = fmt.Sprintf("%s's lease for flat %s ends today.", t.Name, t.Flat.Number)
+		}
+		_, err := s.notifications.Notify(ctx, userID, Alert{
+			Event:     models.EventLeaseExpiring,
+			Title:     "Lease ending soon",
+			Body:      body,
+			Data:      map[string]string{"tenant_id": t.ID.String()},
+			DedupeKey: fmt.Sprintf("lease:%s:%s:%d", t.ID, end.Format("2006-01-02"), notice),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"rented-backend/models"
+	"rented-backend/notify"
+	"rented-backend/repository"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+var banglaMonths = map[string]string{
+	"January": "জানুয়ারি", "February": "ফেব্রুয়ারি", "March": "মার্চ", "April": "এপ্রিল",
+	"May": "মে", "June": "জুন", "July": "জুলাই", "August": "আগস্ট",
+	"September": "সেপ্টেম্বর", "October": "অক্টোবর", "November": "নভেম্বর", "December": "ডিসেম্বর",
+}
+
+// Messenger texts tenants using the landlord's templates, honours opt-outs
+// and records every message with its delivery status.
+type Messenger struct {
+	repo     repository.ReminderRepository
+	notifier notify.Notifier
+}
+
+func NewMessenger(repo repository.ReminderRepository, notifier notify.Notifier) *Messenger {
+	return &Messenger{repo: repo, notifier: notifier}
+}
+
+// Templates returns the landlord's effective templates: their own where
+// customised, the built-in wording otherwise.
+func (m *Messenger) Templates(userID uuid.UUID) ([]models.MessageTemplate, error) {
+	custom, err := m.repo.GetTemplates(userID)
+	if err != nil {
+		return nil, err
+	}
+	saved := map[string]models.MessageTemplate{}
+	for _, t := range custom {
+		saved[t.Kind+":"+t.Language] = t
+	}
+
+	templates := []models.MessageTemplate{}
+	for _, kind := range []string{models.MessageBeforeDue, models.MessageDueToday, models.MessageOverdue, models.MessagePaymentConfirmed, models.MessagePaymentRejected} {
+		for _, lang := range []string{models.LanguageBangla, models.LanguageEnglish} {
+			if t, ok := saved[kind+":"+lang]; ok {
+				templates = append(templates, t)
+				continue
+			}
+			templates = append(templates, models.MessageTemplate{UserID: userID, Kind: kind, Language: lang, Body: models.DefaultTemplates[kind][lang], IsDefault: true})
+		}
+	}
+	return templates, nil
+}
+
+// SendToTenant renders the kind's template in the tenant's language and
+// texts it. vars fills the placeholders; name and flat are added from the
+// tenant. With a non-empty dedupeKey the message is sent once: a repeat
+// returns repository.ErrMessageClaimed without texting the tenant.
+func (m *Messenger) SendToTenant(ctx context.Context, t models.Tenant, kind string, vars map[string]string, dedupeKey string) (*models.SMSMessage, error) {
+	settings, err := m.repo.GetSettings(t.UserID)
+	if err != nil {
+		return nil, err
+	}
+	lang := t.Language
+	if lang == "" {
+		lang = settings.Language
+	}
+
+	templates, err := m.Templates(t.UserID)
+	if err != nil {
+		return nil, err
+	}
+	body := models.DefaultTemplates[kind][lang]
+	for _, tmpl := range templates {
+		if tmpl.Kind == kind && tmpl.Language == lang {
+			body = tmpl.Body
+		}
+	}
+
+	values := map[string]string{"name": t.Name, "flat": t.Flat.Number}
+	for k, v := range vars {
+		values[k] = v
+	}
+	if month, ok := values["month"]; ok && lang == models.LanguageBangla {
+		values["month"] = banglaMonth(month)
+	}
+
+	message := &models.SMSMessage{
+		ID:        uuid.New(),
+		UserID:    t.UserID,
+		TenantID:  &t.ID,
+		Kind:      kind,
+		Phone:     t.Phone,
+		Body:      RenderTemplate(body, values),
+		DedupeKey: dedupeKey,
+	}
+	if err := m.repo.ClaimMessage(message); err != nil {
+		return nil, err
+	}
+
+	if t.SMSOptOut {
+		message.Status = models.SMSOptedOut
+	} else {
+		result, err := m.notifier.Send(ctx, t.Phone, message.Body)
+		message.Status = models.SMSSent
+		message.ProviderID = result.ProviderID
+		if err != nil {
+			message.Status = models.SMSFailed
+			message.Error = err.Error()
+		}
+	}
+
+	if err := m.repo.UpdateMessage(message); err != nil {
+		return nil, err
+	}
+	return message, nil
+}
+
+// RenderTemplate replaces {placeholder}s with their values. Unknown
+// placeholders are left as they are.
+func RenderTemplate(body string, values map[string]string) string {
+	pairs := make([]string, 0, len(values)*2)
+	for k, v := range values {
+		pairs = append(pairs, "{"+k+"}", v)
+	}
+	return strings.NewReplacer(pairs...).Replace(body)
+}
+
+// banglaMonth translates the month name in a "January 2026" label.
+func banglaMonth(label string) string {
+	for en, bn := range banglaMonths {
+		if strings.HasPrefix(label, en) {
+			return bn + label[len(en):]
+		}
+	}
+	return label
+}
@@ -0,0 +1,223 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"rented-backend/billing"
+	"rented-backend/logger"
+	"rented-backend/mail"
+	"rented-backend/models"
+	"rented-backend/money"
+	"rented-backend/repository"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// VacantFlat is a flat nobody occupied at any point in the month.
+type VacantFlat struct {
+	FlatID     uuid.UUID `json:"flat_id"`
+	HouseName  string    `json:"house_name"`
+	FlatNumber string    `json:"flat_number"`
+}
+
+// MonthlyReport is a landlord's summary of one month: the dashboard
+// figures for it, what tenants still owed at its end, the flats that stood
+// empty and the money spent.
+type MonthlyReport struct {
+	Month              time.Time                  `json:"month"` // first day of the month
+	Landlord           string                     `json:"landlord"`
+	Currency           money.Currency             `json:"currency"`
+	Stats              *repository.DashboardStats `json:"stats"`
+	TotalDue           money.Amount               `json:"total_due"`
+	Dues               []repository.TenantDue     `json:"dues"` // largest first
+	Vacancies          []VacantFlat               `json:"vacancies"`
+	Expenses           []models.Expense           `json:"expenses"`
+	ExpensesByCategory map[string]money.Amount    `json:"expenses_by_category"`
+}
+
+// MonthlyReportService builds the monthly summary and emails it to
+// landlords who have not turned it off.
+type MonthlyReportService struct {
+	dueService     *DueService
+	houseRepo      repository.HouseRepository
+	tenantRepo     repository.TenantRepository
+	expenseRepo    repository.ExpenseRepository
+	userRepo       repository.UserRepository
+	emailRepo      repository.EmailRepository
+	mailer         mail.Mailer
+	unsubscribeURL string
+	preferencesURL string
+}
+
+func NewMonthlyReportService(dueService *DueService, houseRepo repository.HouseRepository, tenantRepo repository.TenantRepository, expenseRepo repository.ExpenseRepository, userRepo repository.UserRepository, emailRepo repository.EmailRepository, mailer mail.Mailer, unsubscribeURL, preferencesURL string) *MonthlyReportService {
+	return &MonthlyReportService{
+		dueService:     dueService,
+		houseRepo:      houseRepo,
+		tenantRepo:     tenantRepo,
+		expenseRepo:    expenseRepo,
+		userRepo:       userRepo,
+		emailRepo:      emailRepo,
+		mailer:         mailer,
+		unsubscribeURL: unsubscribeURL,
+		preferencesURL: preferencesURL,
+	}
+}
+
+// Build assembles the report for the month starting at month.
+func (s *MonthlyReportService) Build(userID uuid.UUID, month time.Time) (*MonthlyReport, error) {
+	month = time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.UTC)
+	next := month.AddDate(0, 1, 0)
+	report := &MonthlyReport{Month: month, ExpensesByCategory: map[string]money.Amount{}}
+
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	report.Landlord = user.Name
+	report.Currency = user.Currency
+
+	stats, dues, err := s.dueService.DashboardStats(userID, repository.DashboardFilter{From: month, To: month}, next.AddDate(0, 0, -1))
+	if err != nil {
+		return nil, err
+	}
+	report.Stats = stats
+	report.TotalDue = dues.TotalDue
+	report.Dues = dues.Dues
+	sort.SliceStable(report.Dues, func(i, j int) bool {
+		return report.Dues[i].DueAmount > report.Dues[j].DueAmount
+	})
+
+	// Occupied means the same as on the dashboard: moved in by the month's
+	// end and not gone before it started
+	tenants, err := s.tenantRepo.GetAll(userID)
+	if err != nil {
+		return nil, err
+	}
+	occupied := map[uuid.UUID]bool{}
+	for _, t := range tenants {
+		if !t.JoinDate.Before(next) {
+			continue
+		}
+		if (t.LeaveDate != nil && !t.LeaveDate.Before(month)) || (t.LeaveDate == nil && t.IsActive) {
+			occupied[t.FlatID] = true
+		}
+	}
+	houses, err := s.houseRepo.GetUserHouses(userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, h := range houses {
+		for _, f := range h.Flats {
+			if !occupied[f.ID] {
+				report.Vacancies = append(report.Vacancies, VacantFlat{FlatID: f.ID, HouseName: h.Name, FlatNumber: f.Number})
+			}
+		}
+	}
+
+	report.Expenses, err = s.expenseRepo.List(userID, repository.ExpenseFilter{From: &month, To: &next})
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range report.Expenses {
+		report.ExpensesByCategory[e.Category] += e.Amount
+	}
+
+	return report, nil
+}
+
+// SendAll emails last month's summary to every landlord who has not had
+// it yet, once the month has ended on their own calendar. It runs
+// repeatedly so a missed first of the month is caught up.
+func (s *MonthlyReportService) SendAll(ctx context.Context, now time.Time) error {
+	userIDs, err := s.userRepo.ListIDs()
+	if err != nil {
+		return err
+	}
+	for _, userID := range userIDs {
+		today, err := s.dueService.Today(userID, now)
+		if err != nil {
+			logger.Log.Error("Failed to send monthly summary", "userID", userID, "error", err)
+			continue
+		}
+		month := billing.Of(today).AddMonths(-1).Start()
+		if err := s.Send(ctx, userID, month); err != nil {
+			logger.Log.Error("Failed to send monthly summary", "userID", userID, "error", err)
+		}
+	}
+	return nil
+}
+
+// Send emails the month's summary to the landlord unless they turned it
+// off or already received it. The send is claimed in the log first, so
+// overlapping runs email it once.
+func (s *MonthlyReportService) Send(ctx context.Context, userID uuid.UUID, month time.Time) error {
+	period := month.Format("2006-01")
+	prefs, err := s.emailRepo.GetPreferences(userID)
+	if err != nil {
+		return err
+	}
+	if !prefs.MonthlySummary {
+		return nil
+	}
+
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return err
+	}
+	record := models.ReportEmail{ID: uuid.New(), UserID: userID, Period: period, To: user.Email}
+	if err := s.emailRepo.ClaimReportEmail(&record); errors.Is(err, repository.ErrReportClaimed) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	sendErr := s.sendReport(ctx, user.Email, prefs, month)
+	record.Status = models.EmailSent
+	if sendErr != nil {
+		record.Status = models.EmailFailed
+		record.Error = sendErr.Error()
+	}
+	if err := s.emailRepo.UpdateReportEmail(&record); err != nil {
+		return err
+	}
+	return sendErr
+}
+
+// sendReport builds the month's summary with its attachments and mails it.
+func (s *MonthlyReportService) sendReport(ctx context.Context, to string, prefs *models.EmailPreferences, month time.Time) error {
+	period := month.Format("2006-01")
+	report, err := s.Build(prefs.UserID, month)
+	if err != nil {
+		return err
+	}
+
+	var csvBuf, pdfBuf bytes.Buffer
+	if err := WriteMonthlyReportCSV(&csvBuf, report); err != nil {
+		return err
+	}
+	if err := WriteMonthlyReportPDF(&pdfBuf, report); err != nil {
+		return err
+	}
+
+	unsubscribe := s.unsubscribeURL + "?token=" + url.QueryEscape(prefs.UnsubscribeToken)
+	filename := "summary-" + period
+	msg := mail.Message{
+		To:      to,
+		Subject: fmt.Sprintf("Your rental summary for %s", month.Format("January 2006")),
+		Text:    MonthlyReportText(report, unsubscribe, s.preferencesURL),
+		Headers: map[string]string{
+			"List-Unsubscribe":      "<" + unsubscribe + ">",
+			"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+		},
+		Attachments: []mail.Attachment{
+			{Filename: filename + ".csv", ContentType: "text/csv", Data: csvBuf.Bytes()},
+			{Filename: filename + ".pdf", ContentType: "application/pdf", Data: pdfBuf.Bytes()},
+		},
+	}
+
+	return s.mailer.Send(ctx, msg)
+}
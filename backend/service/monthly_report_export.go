@@ -0,0 +1,172 @@
+package service
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/go-pdf/fpdf"
+)
+
+// summaryLines are the headline figures shared by the email, CSV and PDF.
+func summaryLines(r *MonthlyReport) [][2]string {
+	return [][2]string{
+		{"Currency", string(r.Currency)},
+		{"Billed", r.Stats.ExpectedIncome.String()},
+		{"Collected", r.Stats.ActualIncome.String()},
+		{"Collection rate", fmt.Sprintf("%.0f%%", r.Stats.CollectionRate*100)},
+		{"Outstanding dues", r.TotalDue.String()},
+		{"Vacant flats", fmt.Sprintf("%d of %d", len(r.Vacancies), r.Stats.TotalFlats)},
+		{"Expenses", r.Stats.Expenses.String()},
+		{"Net income", r.Stats.NOI.String()},
+	}
+}
+
+func sortedCategories(r *MonthlyReport) []string {
+	categories := make([]string, 0, len(r.ExpensesByCategory))
+	for c := range r.ExpensesByCategory {
+		categories = append(categories, c)
+	}
+	sort.Strings(categories)
+	return categories
+}
+
+// MonthlyReportText is the plain text body of the summary email.
+func MonthlyReportText(r *MonthlyReport, unsubscribeURL, preferencesURL string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Hi %s,\n\nHere is your rental summary for %s.\n\n", r.Landlord, r.Month.Format("January 2006"))
+	for _, line := range summaryLines(r) {
+		fmt.Fprintf(&b, "%-18s %s\n", line[0]+":", line[1])
+	}
+
+	if len(r.Dues) > 0 {
+		b.WriteString("\nLargest outstanding dues:\n")
+		for i, d := range r.Dues {
+			if i == 5 {
+				fmt.Fprintf(&b, "  and %d more in the attached report\n", len(r.Dues)-5)
+				break
+			}
+			fmt.Fprintf(&b, "  %s (flat %s): %s\n", d.TenantName, d.FlatNo, d.DueAmount)
+		}
+	}
+
+	b.WriteString("\nThe full report is attached as CSV and PDF.\n\n")
+	b.WriteString("--\nYou receive this email because monthly summaries are on for your account.\n")
+	fmt.Fprintf(&b, "Unsubscribe: %s\n", unsubscribeURL)
+	if preferencesURL != "" {
+		fmt.Fprintf(&b, "Email preferences: %s\n", preferencesURL)
+	}
+	return b.String()
+}
+
+// WriteMonthlyReportCSV writes the report as CSV, one section after
+// another separated by blank rows.
+func WriteMonthlyReportCSV(w io.Writer, r *MonthlyReport) error {
+	cw := csv.NewWriter(w)
+
+	rows := [][]string{{"Monthly summary", r.Month.Format("January 2006")}, {}}
+	rows = append(rows, []string{"Figure", "Value"})
+	for _, line := range summaryLines(r) {
+		rows = append(rows, []string{line[0], line[1]})
+	}
+
+	rows = append(rows, []string{}, []string{"Outstanding dues"}, []string{"Tenant", "Flat", "Amount"})
+	for _, d := range r.Dues {
+		rows = append(rows, []string{d.TenantName, d.FlatNo, d.DueAmount.String()})
+	}
+
+	rows = append(rows, []string{}, []string{"Vacant flats"}, []string{"House", "Flat"})
+	for _, v := range r.Vacancies {
+		rows = append(rows, []string{v.HouseName, v.FlatNumber})
+	}
+
+	rows = append(rows, []string{}, []string{"Expenses"}, []string{"Date", "Category", "Vendor", "Description", "Amount"})
+	for _, e := range r.Expenses {
+		rows = append(rows, []string{e.Date.Format(statementDateFormat), e.Category, e.Vendor, e.Description, e.Amount.String()})
+	}
+	for _, c := range sortedCategories(r) {
+		rows = append(rows, []string{"", c, "", "Total", r.ExpensesByCategory[c].String()})
+	}
+
+	if err := cw.WriteAll(rows); err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteMonthlyReportPDF renders the report with a table per section.
+func WriteMonthlyReportPDF(w io.Writer, r *MonthlyReport) error {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(12, 12, 12)
+	pdf.AddPage()
+
+	pdf.SetFont("Helvetica", "B", 14)
+	pdf.Cell(0, 8, "Monthly Summary - "+r.Month.Format("January 2006"))
+	pdf.Ln(9)
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.Cell(0, 5, "Landlord: "+r.Landlord)
+	pdf.Ln(8)
+
+	heading := func(text string) {
+		pdf.Ln(3)
+		pdf.SetFont("Helvetica", "B", 11)
+		pdf.Cell(0, 6, text)
+		pdf.Ln(7)
+	}
+	table := func(widths []float64, rightFrom int, header []string, rows [][]string) {
+		row := func(cells []string, bold bool) {
+			style := ""
+			if bold {
+				style = "B"
+			}
+			pdf.SetFont("Helvetica", style, 9)
+			for i, text := range cells {
+				align := "L"
+				if i >= rightFrom {
+					align = "R"
+				}
+				pdf.CellFormat(widths[i], 6, text, "1", 0, align, false, 0, "")
+			}
+			pdf.Ln(-1)
+		}
+		row(header, true)
+		for _, cells := range rows {
+			row(cells, false)
+		}
+	}
+
+	var summary [][]string
+	for _, line := range summaryLines(r) {
+		summary = append(summary, []string{line[0], line[1]})
+	}
+	table([]float64{60, 40}, 1, []string{"Figure", "Value"}, summary)
+
+	heading("Outstanding dues")
+	var dues [][]string
+	for _, d := range r.Dues {
+		dues = append(dues, []string{d.TenantName, d.FlatNo, d.DueAmount.String()})
+	}
+	table([]float64{90, 40, 40}, 2, []string{"Tenant", "Flat", "Amount"}, dues)
+
+	heading("Vacant flats")
+	var vacancies [][]string
+	for _, v := range r.Vacancies {
+		vacancies = append(vacancies, []string{v.HouseName, v.FlatNumber})
+	}
+	table([]float64{90, 40}, 2, []string{"House", "Flat"}, vacancies)
+
+	heading("Expenses")
+	var expenses [][]string
+	for _, e := range r.Expenses {
+		expenses = append(expenses, []string{e.Date.Format(statementDateFormat), e.Category, e.Vendor, e.Description, e.Amount.String()})
+	}
+	for _, c := range sortedCategories(r) {
+		expenses = append(expenses, []string{"", c, "", "Total", r.ExpensesByCategory[c].String()})
+	}
+	table([]float64{24, 34, 36, 70, 22}, 4, []string{"Date", "Category", "Vendor", "Description", "Amount"}, expenses)
+
+	return pdf.Output(w)
+}
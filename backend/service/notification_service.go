@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"rented-backend/logger"
+	"rented-backend/models"
+	"rented-backend/push"
+	"rented-backend/repository"
+
+	"github.com/google/uuid"
+)
+
+// NotificationService alerts landlords: every alert goes to the inbox and
+// is pushed to the user's devices unless they turned the event off.
+type NotificationService struct {
+	repo   repository.NotificationRepository
+	pusher push.Pusher
+}
+
+func NewNotificationService(repo repository.NotificationRepository, pusher push.Pusher) *NotificationService {
+	return &NotificationService{repo: repo, pusher: pusher}
+}
+
+// Alert is one notification to deliver. A non-empty DedupeKey makes it
+// go out at most once per user.
+type Alert struct {
+	Event     string
+	Title     string
+	Body      string
+	Data      map[string]string
+	DedupeKey string
+}
+
+// Notify stores the alert in the user's inbox and pushes it. Push failures
+// are recorded on the notification, not returned.
+func (s *NotificationService) Notify(ctx context.Context, userID uuid.UUID, alert Alert) (*models.Notification, error) {
+	if alert.DedupeKey != "" {
+		sent, err := s.repo.WasSent(userID, alert.DedupeKey)
+		if err != nil || sent {
+			return nil, err
+		}
+	}
+
+	prefs, err := s.repo.GetPreferences(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	notification := models.Notification{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Event:     alert.Event,
+		Title:     alert.Title,
+		Body:      alert.Body,
+		Data:      alert.Data,
+		DedupeKey: alert.DedupeKey,
+	}
+
+	if prefs[alert.Event] {
+		notification.PushStatus, err = s.push(ctx, userID, notification)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		notification.PushStatus = models.PushMuted
+	}
+
+	if err := s.repo.Create(&notification); err != nil {
+		return nil, err
+	}
+	return &notification, nil
+}
+
+// push sends to every device of the user and returns the push status.
+// Tokens the push service no longer knows are removed.
+func (s *NotificationService) push(ctx context.Context, userID uuid.UUID, n models.Notification) (string, error) {
+	devices, err := s.repo.GetDevices(userID)
+	if err != nil {
+		return "", err
+	}
+	if len(devices) == 0 {
+		return models.PushNoDevice, nil
+	}
+
+	// The app opens the inbox entry from these
+	data := map[string]string{"notification_id": n.ID.String(), "event": n.Event}
+	for k, v := range n.Data {
+		data[k] = v
+	}
+	msg := push.Message{Title: n.Title, Body: n.Body, Data: data}
+
+	status := models.PushNoDevice
+	for _, d := range devices {
+		err := s.pusher.Send(ctx, d.Token, msg)
+		switch {
+		case err == nil:
+			status = models.PushSent
+		case errors.Is(err, push.ErrInvalidToken):
+			if err := s.repo.DeleteToken(d.Token); err != nil {
+				logger.Log.Error("Failed to remove stale device token", "userID", userID, "error", err)
+			}
+		default:
+			logger.Log.Error("Failed to push notification", "userID", userID, "platform", d.Platform, "error", err)
+			if status != models.PushSent {
+				status = models.PushFailed
+			}
+		}
+	}
+	return status, nil
+}
+
+// NotifyQuietly is Notify for callers that must not fail because of it,
+// such as request handlers that already did their work.
+func (s *NotificationService) NotifyQuietly(ctx context.Context, userID uuid.UUID, alert Alert) {
+	if _, err := s.Notify(ctx, userID, alert); err != nil {
+		logger.Log.Error("Failed to notify user", "userID", userID, "event", alert.Event, "error", err)
+	}
+}
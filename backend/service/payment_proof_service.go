@@ -0,0 +1,188 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"rented-backend/logger"
+	"rented-backend/models"
+	"rented-backend/money"
+	"rented-backend/repository"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidItems is returned when a confirmation's items do not add up to
+// the submitted amount or name unknown charge types.
+var ErrInvalidItems = errors.New("items must use valid charge types, each once, and add up to the submitted amount")
+
+// PaymentProofService turns reviewed payment proofs into ledger payments.
+type PaymentProofService struct {
+	proofRepo      repository.PaymentProofRepository
+	tenantRepo     repository.TenantRepository
+	chargeTypeRepo repository.ChargeTypeRepository
+	dueService     *DueService
+	messenger      *Messenger
+}
+
+func NewPaymentProofService(proofRepo repository.PaymentProofRepository, tenantRepo repository.TenantRepository, chargeTypeRepo repository.ChargeTypeRepository, dueService *DueService, messenger *Messenger) *PaymentProofService {
+	return &PaymentProofService{
+		proofRepo:      proofRepo,
+		tenantRepo:     tenantRepo,
+		chargeTypeRepo: chargeTypeRepo,
+		dueService:     dueService,
+		messenger:      messenger,
+	}
+}
+
+// Confirm records the proof as a rent payment and texts the tenant their
+// receipt number. Without items the amount is applied to what the tenant
+// owes for the proof's month, see AllocatePayment.
+func (s *PaymentProofService) Confirm(ctx context.Context, reviewerID uuid.UUID, proof *models.PaymentProof, items []models.PaymentItem) (*models.RentPayment, error) {
+	tenant, err := s.tenantRepo.GetByID(proof.TenantID, proof.UserID)
+	if err != nil {
+		return nil, err
+	}
+	chargeTypes, err := s.chargeTypeRepo.GetAll(proof.UserID)
+	if err != nil {
+		return nil, err
+	}
+	byCode := map[string]models.ChargeType{}
+	byID := map[uuid.UUID]models.ChargeType{}
+	for _, ct := range chargeTypes {
+		byCode[ct.Code] = ct
+		byID[ct.ID] = ct
+	}
+
+	if len(items) == 0 {
+		due := map[string]money.Amount{}
+		today, err := s.dueService.Today(tenant.UserID, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		months, err := s.dueService.TenantMonthlyDues(*tenant, today)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range months {
+			if m.Period == proof.Period {
+				due = m.Items
+			}
+		}
+		for code, amount := range AllocatePayment(proof.Amount, due) {
+			ct, ok := byCode[code]
+			if !ok {
+				return nil, fmt.Errorf("charge type %s missing", code)
+			}
+			items = append(items, models.PaymentItem{ChargeTypeID: ct.ID, Code: code, Amount: amount})
+		}
+	} else {
+		var sum money.Amount
+		seen := map[uuid.UUID]bool{}
+		for i, item := range items {
+			ct, ok := byID[item.ChargeTypeID]
+			if !ok || item.Amount < 0 || seen[item.ChargeTypeID] {
+				return nil, ErrInvalidItems
+			}
+			seen[item.ChargeTypeID] = true
+			items[i].Code = ct.Code
+			sum += item.Amount
+		}
+		if sum != proof.Amount {
+			return nil, ErrInvalidItems
+		}
+	}
+
+	payment := &models.RentPayment{
+		ID:            uuid.New(),
+		TenantID:      proof.TenantID,
+		Period:        proof.Period,
+		PaymentDate:   proof.CreatedAt,
+		Method:        proof.Method,
+		TransactionID: proof.TransactionID,
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Code < items[j].Code })
+	for _, item := range items {
+		item.ID = uuid.New()
+		item.RentPaymentID = payment.ID
+		payment.Items = append(payment.Items, item)
+		payment.TotalPaid += item.Amount
+	}
+
+	now := time.Now()
+	proof.Status = models.ProofConfirmed
+	proof.ReviewedBy = &reviewerID
+	proof.ReviewedAt = &now
+	proof.RentPaymentID = &payment.ID
+	if err := s.proofRepo.Confirm(ctx, proof, payment); err != nil {
+		return nil, err
+	}
+
+	s.notify(ctx, *tenant, models.MessagePaymentConfirmed, map[string]string{
+		"amount":  proof.Amount.String(),
+		"month":   proof.Period.Label(),
+		"receipt": ReceiptNumber(*payment),
+	})
+	return payment, nil
+}
+
+// Reject marks the proof rejected and tells the tenant why.
+func (s *PaymentProofService) Reject(ctx context.Context, reviewerID uuid.UUID, proof *models.PaymentProof, reason string) error {
+	now := time.Now()
+	proof.Status = models.ProofRejected
+	proof.ReviewedBy = &reviewerID
+	proof.ReviewedAt = &now
+	proof.RejectReason = reason
+	if err := s.proofRepo.Reject(ctx, proof); err != nil {
+		return err
+	}
+
+	if proof.Tenant != nil {
+		s.notify(ctx, *proof.Tenant, models.MessagePaymentRejected, map[string]string{
+			"amount": proof.Amount.String(),
+			"month":  proof.Period.Label(),
+			"reason": reason,
+		})
+	}
+	return nil
+}
+
+// notify texts the tenant. A failed message does not undo the review.
+func (s *PaymentProofService) notify(ctx context.Context, t models.Tenant, kind string, vars map[string]string) {
+	if _, err := s.messenger.SendToTenant(ctx, t, kind, vars, ""); err != nil {
+		logger.Log.Error("Failed to send payment review SMS", "tenantID", t.ID, "error", err)
+	}
+}
+
+// AllocatePayment splits an amount across a month's outstanding items:
+// basic rent first, then the other charges by code. Anything left over is
+// booked as basic rent.
+func AllocatePayment(amount money.Amount, due map[string]money.Amount) map[string]money.Amount {
+	codes := make([]string, 0, len(due))
+	for code := range due {
+		if code != models.ChargeKindBasicRent {
+			codes = append(codes, code)
+		}
+	}
+	sort.Strings(codes)
+	codes = append([]string{models.ChargeKindBasicRent}, codes...)
+
+	allocated := map[string]money.Amount{}
+	remaining := amount
+	for _, code := range codes {
+		if remaining <= 0 {
+			break
+		}
+		share := min(due[code], remaining)
+		if share > 0 {
+			allocated[code] = share
+			remaining -= share
+		}
+	}
+	if remaining > 0 {
+		allocated[models.ChargeKindBasicRent] += remaining
+	}
+	return allocated
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"fmt"
+	"io"
+	"rented-backend/billing"
+	"rented-backend/models"
+	"rented-backend/money"
+	"strings"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+)
+
+type ReceiptLine struct {
+	Description string       `json:"description"`
+	Amount      money.Amount `json:"amount"`
+}
+
+// Receipt is what the tenant is given for a recorded payment.
+type Receipt struct {
+	Number        string         `json:"number"`
+	Date          time.Time      `json:"date"`
+	TenantName    string         `json:"tenant_name"`
+	FlatNumber    string         `json:"flat_number"`
+	Period        billing.Period `json:"period"` // none for an advance
+	Method        string         `json:"method"`
+	TransactionID string         `json:"transaction_id"`
+	Lines         []ReceiptLine  `json:"lines"`
+	Total         money.Amount   `json:"total"`
+	IsAdvance     bool           `json:"is_advance"`
+}
+
+// ReceiptNumber is the short reference printed on a payment's receipt.
+func ReceiptNumber(p models.RentPayment) string {
+	return strings.ToUpper(p.ID.String()[:8])
+}
+
+// BuildReceipt describes a payment. names maps charge codes to the names
+// shown on the receipt.
+func BuildReceipt(p models.RentPayment, t models.Tenant, names map[string]string) Receipt {
+	r := Receipt{
+		Number:        ReceiptNumber(p),
+		Date:          p.PaymentDate,
+		TenantName:    t.Name,
+		FlatNumber:    t.Flat.Number,
+		Period:        p.Period,
+		Method:        p.Method,
+		TransactionID: p.TransactionID,
+		Lines:         []ReceiptLine{},
+		Total:         p.TotalPaid,
+		IsAdvance:     p.IsAdvance,
+	}
+	if p.IsAdvance {
+		r.Lines = append(r.Lines, ReceiptLine{Description: "Advance (deposit)", Amount: p.TotalPaid})
+		return r
+	}
+	for _, item := range p.Items {
+		name := names[item.Code]
+		if name == "" {
+			name = item.Code
+		}
+		r.Lines = append(r.Lines, ReceiptLine{Description: name, Amount: item.Amount})
+	}
+	return r
+}
+
+// WriteReceiptPDF renders the receipt on a single A5 page.
+func WriteReceiptPDF(w io.Writer, r Receipt) error {
+	pdf := fpdf.New("P", "mm", "A5", "")
+	pdf.SetMargins(12, 12, 12)
+	pdf.AddPage()
+
+	pdf.SetFont("Helvetica", "B", 14)
+	pdf.Cell(0, 8, "Payment Receipt")
+	pdf.Ln(9)
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.Cell(0, 5, fmt.Sprintf("Receipt no: %s    Date: %s", r.Number, r.Date.Format(statementDateFormat)))
+	pdf.Ln(5)
+	pdf.Cell(0, 5, fmt.Sprintf("Tenant: %s    Flat: %s", r.TenantName, r.FlatNumber))
+	pdf.Ln(5)
+	if !r.IsAdvance {
+		pdf.Cell(0, 5, "For: "+r.Period.Label())
+		pdf.Ln(5)
+	}
+	if r.Method != "" {
+		paid := "Paid by: " + r.Method
+		if r.TransactionID != "" {
+			paid += "    Transaction: " + r.TransactionID
+		}
+		pdf.Cell(0, 5, paid)
+		pdf.Ln(5)
+	}
+	pdf.Ln(3)
+
+	pdf.SetFont("Helvetica", "B", 9)
+	pdf.CellFormat(94, 6, "Description", "1", 0, "L", false, 0, "")
+	pdf.CellFormat(30, 6, "Amount", "1", 0, "R", false, 0, "")
+	pdf.Ln(-1)
+	pdf.SetFont("Helvetica", "", 9)
+	for _, line := range r.Lines {
+		pdf.CellFormat(94, 6, line.Description, "1", 0, "L", false, 0, "")
+		pdf.CellFormat(30, 6, line.Amount.String(), "1", 0, "R", false, 0, "")
+		pdf.Ln(-1)
+	}
+	pdf.SetFont("Helvetica", "B", 9)
+	pdf.CellFormat(94, 6, "Total", "1", 0, "L", false, 0, "")
+	pdf.CellFormat(30, 6, r.Total.String(), "1", 0, "R", false, 0, "")
+	pdf.Ln(-1)
+
+	return pdf.Output(w)
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"rented-backend/audit"
+	"rented-backend/logger"
+	"rented-backend/models"
+	"rented-backend/repository"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// reminderSendHour is the hour of the landlord's day from which scheduled
+// reminders go out, so tenants are not texted just after local midnight.
+const reminderSendHour = 9
+
+// ReminderService sends the automatic rent reminders.
+type ReminderService struct {
+	messenger  *Messenger
+	repo       repository.ReminderRepository
+	dueService *DueService
+	userRepo   repository.UserRepository
+	tenantRepo repository.TenantRepository
+}
+
+func NewReminderService(messenger *Messenger, repo repository.ReminderRepository, dueService *DueService, userRepo repository.UserRepository, tenantRepo repository.TenantRepository) *ReminderService {
+	return &ReminderService{messenger: messenger, repo: repo, dueService: dueService, userRepo: userRepo, tenantRepo: tenantRepo}
+}
+
+// SendAllReminders sends the day's reminders for every landlord whose
+// local time has reached reminderSendHour.
+func (s *ReminderService) SendAllReminders(ctx context.Context, now time.Time) error {
+	userIDs, err := s.userRepo.ListIDs()
+	if err != nil {
+		return err
+	}
+	for _, userID := range userIDs {
+		user, err := s.userRepo.GetByID(userID)
+		if err != nil {
+			logger.Log.Error("Failed to load account for rent reminders", "userID", userID, "error", err)
+			continue
+		}
+		if now.In(user.Location()).Hour() < reminderSendHour {
+			continue
+		}
+		jobCtx := audit.WithActor(ctx, audit.Actor{AccountID: userID, Type: audit.ActorSystem})
+		sent, err := s.SendReminders(jobCtx, userID, now)
+		if err != nil {
+			logger.Log.Error("Failed to send rent reminders", "userID", userID, "error", err)
+			continue
+		}
+		if sent > 0 {
+			logger.Log.Info("Sent rent reminders", "userID", userID, "count", sent)
+		}
+	}
+	return nil
+}
+
+// SendReminders texts active tenants who owe for a month whose due date is
+// DaysBefore days away, today, or one of the OverdueDays behind. Each
+// reminder goes out once; it returns the number of messages recorded.
+func (s *ReminderService) SendReminders(ctx context.Context, userID uuid.UUID, now time.Time) (int, error) {
+	settings, err := s.repo.GetSettings(userID)
+	if err != nil {
+		return 0, err
+	}
+	if !settings.Enabled {
+		return 0, nil
+	}
+
+	today, err := s.dueService.Today(userID, now)
+	if err != nil {
+		return 0, err
+	}
+	tenants, err := s.tenantRepo.GetAll(userID)
+	if err != nil {
+		return 0, err
+	}
+
+	// Months not yet started are not in the dues, so look ahead for the
+	// advance reminder
+	asOf := today.AddDate(0, 0, settings.DaysBefore)
+
+	sent := 0
+	for _, t := range tenants {
+		if !t.IsActive || t.Phone == "" {
+			continue
+		}
+
+		months, err := s.dueService.TenantMonthlyDues(t, asOf)
+		if err != nil {
+			return sent, err
+		}
+
+		for _, m := range months {
+			if m.Due <= 0 {
+				continue
+			}
+			days := int(today.Sub(m.DueDate).Hours() / 24)
+
+			var kind string
+			switch {
+			case settings.DaysBefore > 0 && days == -settings.DaysBefore:
+				kind = models.MessageBeforeDue
+			case settings.OnDueDate && days == 0:
+				kind = models.MessageDueToday
+			case days > 0 && slices.Contains(settings.OverdueDays, days):
+				kind = models.MessageOverdue
+			default:
+				continue
+			}
+
+			key := fmt.Sprintf("%s:%d-%s:%d", kind, m.Period.Year, m.Period.Month, days)
+			vars := map[string]string{
+				"amount":   m.Due.String(),
+				"month":    m.Period.Label(),
+				"due_date": m.DueDate.Format("02 Jan 2006"),
+			}
+			if _, err := s.messenger.SendToTenant(ctx, t, kind, vars, key); errors.Is(err, repository.ErrMessageClaimed) {
+				continue
+			} else if err != nil {
+				return sent, err
+			}
+			sent++
+		}
+	}
+	return sent, nil
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"fmt"
+	"rented-backend/billing"
+	"rented-backend/models"
+	"rented-backend/money"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Statement entry types.
+const (
+	EntryCharge     = "charge"
+	EntryLateFee    = "late_fee"
+	EntryPayment    = "payment"
+	EntryAdjustment = "adjustment"
+)
+
+// StatementEntry is one line of a tenant's statement. Debits increase what
+// the tenant owes, credits reduce it.
+type StatementEntry struct {
+	Date        time.Time      `json:"date"`
+	Type        string         `json:"type"`
+	Description string         `json:"description"`
+	Period      billing.Period `json:"period"`
+	Debit       money.Amount   `json:"debit"`
+	Credit      money.Amount   `json:"credit"`
+	Balance     money.Amount   `json:"balance"`
+}
+
+// Statement is a tenant's account over a period.
+type Statement struct {
+	TenantID       uuid.UUID        `json:"tenant_id"`
+	TenantName     string           `json:"tenant_name"`
+	FlatNumber     string           `json:"flat_number"`
+	From           time.Time        `json:"from"`
+	To             time.Time        `json:"to"`
+	OpeningBalance money.Amount     `json:"opening_balance"`
+	Entries        []StatementEntry `json:"entries"`
+	ClosingBalance money.Amount     `json:"closing_balance"`
+	AdvanceHeld    money.Amount     `json:"advance_held"`
+}
+
+// BuildStatement lays the tenant's ledger out in date order with a running
+// balance. Charges are taken from the same monthly billing as the dues and
+// dated on the month's due date (or the move-in date if later); waived
+// charges appear as a charge and a matching adjustment. Entries before from
+// make up the opening balance. names maps charge codes to display names;
+// payment and waiver timestamps are dated by the calendar in loc.
+func BuildStatement(in DueInput, names map[string]string, from, to time.Time, loc *time.Location) Statement {
+	entries := []StatementEntry{}
+
+	for _, m := range MonthlyDues(in) {
+		date := m.DueDate
+		if in.JoinDate.After(date) {
+			date = in.JoinDate
+		}
+		for _, code := range sortedCodes(m.Expected) {
+			entries = append(entries, StatementEntry{
+				Date:        date,
+				Type:        chargeEntryType(code),
+				Description: fmt.Sprintf("%s, %s", chargeName(names, code), m.Period.Label()),
+				Period:      m.Period,
+				Debit:       m.Expected[code],
+			})
+		}
+	}
+
+	for _, ch := range in.Charges {
+		if !ch.IsWaived() {
+			continue
+		}
+		entries = append(entries,
+			StatementEntry{
+				Date:        in.Policy.DueDate(ch.Period),
+				Type:        chargeEntryType(ch.Kind),
+				Description: fmt.Sprintf("%s, %s", chargeName(names, ch.Kind), ch.Period.Label()),
+				Period:      ch.Period,
+				Debit:       ch.Amount,
+			},
+			StatementEntry{
+				Date:        billing.Today(*ch.WaivedAt, loc),
+				Type:        EntryAdjustment,
+				Description: fmt.Sprintf("Waived %s, %s: %s", chargeName(names, ch.Kind), ch.Period.Label(), ch.WaiveReason),
+				Period:      ch.Period,
+				Credit:      ch.Amount,
+			},
+		)
+	}
+
+	var advance money.Amount
+	for _, r := range in.Payments {
+		if r.IsAdvance {
+			advance += r.TotalPaid
+			continue
+		}
+		date := r.PaymentDate
+		if date.IsZero() {
+			date = r.CreatedAt
+		}
+		entries = append(entries, StatementEntry{
+			Date:        billing.Today(date, loc),
+			Type:        EntryPayment,
+			Description: "Payment for " + r.Period.Label(),
+			Period:      r.Period,
+			Credit:      r.TotalPaid,
+		})
+	}
+
+	// Debits first on the same day so a same-day payment never shows a credit balance
+	sort.SliceStable(entries, func(i, j int) bool {
+		if !entries[i].Date.Equal(entries[j].Date) {
+			return entries[i].Date.Before(entries[j].Date)
+		}
+		return entries[i].Debit > 0 && entries[j].Debit == 0
+	})
+
+	statement := Statement{From: from, To: to, Entries: []StatementEntry{}, AdvanceHeld: advance}
+	end := to.AddDate(0, 0, 1)
+	var balance money.Amount
+	for _, e := range entries {
+		if !e.Date.Before(end) {
+			break
+		}
+		balance += e.Debit - e.Credit
+		if e.Date.Before(from) {
+			statement.OpeningBalance = balance
+			continue
+		}
+		e.Balance = balance
+		statement.Entries = append(statement.Entries, e)
+	}
+	statement.ClosingBalance = balance
+
+	return statement
+}
+
+func chargeEntryType(code string) string {
+	if code == models.ChargeKindLateFee {
+		return EntryLateFee
+	}
+	return EntryCharge
+}
+
+func chargeName(names map[string]string, code string) string {
+	if name, ok := names[code]; ok {
+		return name
+	}
+	return code
+}
+
+func sortedCodes(amounts map[string]money.Amount) []string {
+	codes := make([]string, 0, len(amounts))
+	for code := range amounts {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"rented-backend/money"
+
+	"github.com/go-pdf/fpdf"
+)
+
+const statementDateFormat = "02 Jan 2006"
+
+// WriteStatementCSV writes the statement as CSV, with the opening and
+// closing balances as the first and last rows.
+func WriteStatementCSV(w io.Writer, s *Statement) error {
+	cw := csv.NewWriter(w)
+
+	rows := [][]string{
+		{"Date", "Type", "Description", "Debit", "Credit", "Balance"},
+		{s.From.Format(statementDateFormat), "", "Opening balance", "", "", s.OpeningBalance.String()},
+	}
+	for _, e := range s.Entries {
+		rows = append(rows, []string{
+			e.Date.Format(statementDateFormat),
+			e.Type,
+			e.Description,
+			e.Debit.String(),
+			e.Credit.String(),
+			e.Balance.String(),
+		})
+	}
+	rows = append(rows, []string{s.To.Format(statementDateFormat), "", "Closing balance", "", "", s.ClosingBalance.String()})
+
+	if err := cw.WriteAll(rows); err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteStatementPDF renders the statement as a single table PDF.
+func WriteStatementPDF(w io.Writer, s *Statement) error {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(12, 12, 12)
+	pdf.AddPage()
+
+	pdf.SetFont("Helvetica", "B", 14)
+	pdf.Cell(0, 8, "Account Statement")
+	pdf.Ln(9)
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.Cell(0, 5, fmt.Sprintf("Tenant: %s    Flat: %s", s.TenantName, s.FlatNumber))
+	pdf.Ln(5)
+	pdf.Cell(0, 5, fmt.Sprintf("Period: %s to %s", s.From.Format(statementDateFormat), s.To.Format(statementDateFormat)))
+	pdf.Ln(8)
+
+	widths := []float64{24, 20, 76, 22, 22, 22}
+	amount := func(v money.Amount) string {
+		if v == 0 {
+			return ""
+		}
+		return v.String()
+	}
+	row := func(cells []string, bold bool) {
+		style := ""
+		if bold {
+			style = "B"
+		}
+		pdf.SetFont("Helvetica", style, 9)
+		for i, text := range cells {
+			align := "L"
+			if i >= 3 {
+				align = "R"
+			}
+			pdf.CellFormat(widths[i], 6, text, "1", 0, align, false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+
+	row([]string{"Date", "Type", "Description", "Debit", "Credit", "Balance"}, true)
+	row([]string{s.From.Format(statementDateFormat), "", "Opening balance", "", "", s.OpeningBalance.String()}, true)
+	for _, e := range s.Entries {
+		row([]string{e.Date.Format(statementDateFormat), e.Type, e.Description, amount(e.Debit), amount(e.Credit), e.Balance.String()}, false)
+	}
+	row([]string{s.To.Format(statementDateFormat), "", "Closing balance", "", "", s.ClosingBalance.String()}, true)
+
+	if s.AdvanceHeld > 0 {
+		pdf.Ln(4)
+		pdf.SetFont("Helvetica", "", 9)
+		pdf.Cell(0, 5, fmt.Sprintf("Advance held as deposit (not applied above): %s", s.AdvanceHeld))
+	}
+
+	return pdf.Output(w)
+}
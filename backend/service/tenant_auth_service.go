@@ -0,0 +1,194 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"rented-backend/models"
+	"rented-backend/notify"
+	"rented-backend/repository"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	otpTTL          = 10 * time.Minute
+	linkTTL         = 15 * time.Minute
+	loginResendWait = time.Minute
+	maxOTPAttempts  = 5
+)
+
+var (
+	ErrInvalidLoginCode = errors.New("invalid or expired code")
+	ErrTooManyAttempts  = errors.New("too many attempts, request a new code")
+)
+
+// ChooseTenantError is returned when a phone number belongs to more than
+// one active tenancy and the caller did not say which one to log in to.
+type ChooseTenantError struct {
+	Tenants []models.Tenant
+}
+
+func (e *ChooseTenantError) Error() string {
+	return "phone number matches several tenancies, tenant_id is required"
+}
+
+// TenantAuthService issues and redeems the one-time codes and magic links
+// tenants log in with.
+type TenantAuthService struct {
+	repo       repository.TenantAuthRepository
+	tenantRepo repository.TenantRepository
+	sms        notify.Notifier
+	portalURL  string
+}
+
+func NewTenantAuthService(repo repository.TenantAuthRepository, tenantRepo repository.TenantRepository, sms notify.Notifier, portalURL string) *TenantAuthService {
+	return &TenantAuthService{repo: repo, tenantRepo: tenantRepo, sms: sms, portalURL: portalURL}
+}
+
+// RequestOTP texts a six digit code to the phone if it belongs to an
+// active tenant. Unknown numbers, and numbers sent a code in the last
+// minute, are silently ignored: the caller sees the same result either
+// way, so the endpoint cannot be used to find out who is a tenant.
+func (s *TenantAuthService) RequestOTP(ctx context.Context, phone string) error {
+	phone = models.NormalizePhone(phone)
+	ok, err := s.canSend(phone, models.LoginCodeOTP)
+	if err != nil || !ok {
+		return err
+	}
+
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return err
+	}
+	code := fmt.Sprintf("%06d", n.Int64())
+
+	if err := s.repo.CreateCode(&models.TenantLoginCode{
+		ID:         uuid.New(),
+		Phone:      phone,
+		Kind:       models.LoginCodeOTP,
+		SecretHash: hashSecret(phone + ":" + code),
+		ExpiresAt:  time.Now().Add(otpTTL),
+	}); err != nil {
+		return err
+	}
+
+	// Login codes are not recorded with other messages: the body is a secret
+	_, err = s.sms.Send(ctx, phone, fmt.Sprintf("Your login code is %s. It expires in %d minutes.", code, int(otpTTL.Minutes())))
+	return err
+}
+
+// RequestLink texts a one-time login link to the phone if it belongs to an
+// active tenant, on the same terms as RequestOTP.
+func (s *TenantAuthService) RequestLink(ctx context.Context, phone string) error {
+	phone = models.NormalizePhone(phone)
+	ok, err := s.canSend(phone, models.LoginCodeLink)
+	if err != nil || !ok {
+		return err
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return err
+	}
+	token := hex.EncodeToString(secret)
+
+	if err := s.repo.CreateCode(&models.TenantLoginCode{
+		ID:         uuid.New(),
+		Phone:      phone,
+		Kind:       models.LoginCodeLink,
+		SecretHash: hashSecret(token),
+		ExpiresAt:  time.Now().Add(linkTTL),
+	}); err != nil {
+		return err
+	}
+
+	_, err = s.sms.Send(ctx, phone, fmt.Sprintf("Log in to see your rent account: %s?token=%s", s.portalURL, token))
+	return err
+}
+
+// VerifyOTP redeems a code and returns the tenant to log in as.
+func (s *TenantAuthService) VerifyOTP(phone, code string, tenantID *uuid.UUID) (*models.Tenant, error) {
+	phone = models.NormalizePhone(phone)
+	loginCode, err := s.repo.GetLatestCode(phone, models.LoginCodeOTP)
+	if err != nil || loginCode.UsedAt != nil || time.Now().After(loginCode.ExpiresAt) {
+		return nil, ErrInvalidLoginCode
+	}
+	// Every try, right or wrong, uses up an attempt before the code is checked
+	if err := s.repo.UseAttempt(loginCode.ID, maxOTPAttempts); err != nil {
+		if errors.Is(err, repository.ErrNoAttemptsLeft) {
+			return nil, ErrTooManyAttempts
+		}
+		return nil, err
+	}
+	if hashSecret(phone+":"+code) != loginCode.SecretHash {
+		return nil, ErrInvalidLoginCode
+	}
+	return s.redeem(loginCode, tenantID)
+}
+
+// VerifyLink redeems a magic link token and returns the tenant to log in as.
+func (s *TenantAuthService) VerifyLink(token string, tenantID *uuid.UUID) (*models.Tenant, error) {
+	loginCode, err := s.repo.GetCodeByHash(models.LoginCodeLink, hashSecret(token))
+	if err != nil || loginCode.UsedAt != nil || time.Now().After(loginCode.ExpiresAt) {
+		return nil, ErrInvalidLoginCode
+	}
+	return s.redeem(loginCode, tenantID)
+}
+
+// redeem picks the tenancy to log in to and consumes the code. When the
+// phone matches several tenancies the code is left unused so the caller can
+// retry with a tenant_id.
+func (s *TenantAuthService) redeem(loginCode *models.TenantLoginCode, tenantID *uuid.UUID) (*models.Tenant, error) {
+	tenants, err := s.tenantRepo.GetActiveByPhone(loginCode.Phone)
+	if err != nil {
+		return nil, err
+	}
+
+	var tenant *models.Tenant
+	switch {
+	case len(tenants) == 0:
+		return nil, ErrInvalidLoginCode
+	case tenantID != nil:
+		for i := range tenants {
+			if tenants[i].ID == *tenantID {
+				tenant = &tenants[i]
+			}
+		}
+		if tenant == nil {
+			return nil, ErrInvalidLoginCode
+		}
+	case len(tenants) == 1:
+		tenant = &tenants[0]
+	default:
+		return nil, &ChooseTenantError{Tenants: tenants}
+	}
+
+	if err := s.repo.MarkUsed(loginCode.ID, time.Now()); err != nil {
+		return nil, ErrInvalidLoginCode
+	}
+	return tenant, nil
+}
+
+// canSend reports whether a new code may be sent to the phone: it must
+// belong to an active tenant and not have had a code in the last minute.
+func (s *TenantAuthService) canSend(phone, kind string) (bool, error) {
+	tenants, err := s.tenantRepo.GetActiveByPhone(phone)
+	if err != nil || len(tenants) == 0 {
+		return false, err
+	}
+	if latest, err := s.repo.GetLatestCode(phone, kind); err == nil && time.Since(latest.CreatedAt) < loginResendWait {
+		return false, nil
+	}
+	return true, nil
+}
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}